@@ -52,20 +52,126 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	// 写真の重複検出のハッシュを起動時にバックグラウンドで更新する
+	if _, err := koujiService.StartPhotoHashJob(); err != nil {
+		log.Println(err)
+	}
 
 	// Create handlers
 	fileSystemHandler := handlers.NewFileSystemHandler(fileSystemService)
 	koujiHandler := handlers.NewKoujiHandler(fileSystemService, koujiService)
 	timeHandler := handlers.NewTimeHandler()
+	tagHandler := handlers.NewTagHandler(koujiService)
+	companyHandler := handlers.NewCompanyHandler(koujiService)
+	locationHandler := handlers.NewLocationHandler(koujiService)
+	workerHandler := handlers.NewWorkerHandler(koujiService)
+	materialHandler := handlers.NewMaterialHandler(koujiService)
 
 	api := app.Group("/api")
 
 	// File entries routes
 	api.Get("/file-entries", fileSystemHandler.GetFileEntries)
+	api.Get("/file-entries/thumbnail", fileSystemHandler.GetThumbnail)
 
 	// Kouji routes
 	api.Get("/kouji-entries", koujiHandler.GetKoujiEntries)
+	api.Get("/kouji-entries/calendar.ics", koujiHandler.GetKoujiCalendar)
+	api.Get("/kouji-entries/export", koujiHandler.ExportKoujiEntries)
+	api.Get("/kouji-entries/geojson", koujiHandler.GetKoujiGeoJSON)
+	api.Post("/kouji-entries/import", koujiHandler.ImportKoujiEntries)
 	api.Post("/kouji-entries/save", koujiHandler.SaveKoujiEntries)
+	api.Put("/kouji-entries/:id/custom-fields", koujiHandler.UpdateKoujiCustomFields)
+	api.Get("/kouji-entries/:id/assignments", koujiHandler.GetKoujiAssignments)
+	api.Post("/kouji-entries/:id/assignments", koujiHandler.CreateKoujiAssignment)
+	api.Put("/kouji-entries/:id/assignments/:assignmentId", koujiHandler.UpdateKoujiAssignment)
+	api.Delete("/kouji-entries/:id/assignments/:assignmentId", koujiHandler.DeleteKoujiAssignment)
+	api.Get("/assignments/schedule/:name", koujiHandler.GetWorkerSchedule)
+	api.Get("/assignments/load", koujiHandler.GetAssignmentLoad)
+	api.Get("/kouji-entries/:id/qualification-check", workerHandler.CheckKoujiQualifications)
+	api.Get("/kouji-entries/:id/reports", koujiHandler.GetDailyReports)
+	api.Post("/kouji-entries/:id/reports", koujiHandler.CreateDailyReport)
+	api.Get("/kouji-entries/:id/reports/:date", koujiHandler.GetDailyReport)
+	api.Put("/kouji-entries/:id/reports/:date", koujiHandler.UpdateDailyReport)
+	api.Get("/kouji-entries/:id/man-hours", koujiHandler.GetManHoursSummary)
+	api.Get("/kouji-entries/:id/labour-cost", koujiHandler.GetLabourCostSummary)
+	api.Get("/timesheets", koujiHandler.GetTimesheets)
+	api.Post("/timesheets", koujiHandler.SaveTimesheetEntry)
+	api.Delete("/timesheets/:id", koujiHandler.DeleteTimesheetEntry)
+	api.Get("/labour-cost/monthly", koujiHandler.GetMonthlyLabourCosts)
+	api.Get("/kouji-entries/:id/finance", koujiHandler.GetKoujiFinance)
+	api.Put("/kouji-entries/:id/finance", koujiHandler.UpdateKoujiFinance)
+	api.Post("/kouji-entries/:id/invoices", koujiHandler.CreateKoujiInvoice)
+	api.Put("/kouji-entries/:id/invoices/:invoiceId", koujiHandler.UpdateKoujiInvoice)
+	api.Delete("/kouji-entries/:id/invoices/:invoiceId", koujiHandler.DeleteKoujiInvoice)
+	api.Post("/kouji-entries/:id/invoices/:invoiceId/document", koujiHandler.CreateInvoiceDocument)
+	api.Post("/kouji-entries/:id/documents/estimate", koujiHandler.CreateEstimateDocument)
+	api.Post("/kouji-entries/:id/photo-ledger", koujiHandler.CreatePhotoLedger)
+	api.Post("/kouji-entries/:id/photos/sort", koujiHandler.SortKoujiPhotos)
+	api.Get("/photo-duplicates", koujiHandler.GetPhotoDuplicates)
+	api.Get("/photo-duplicates/scan", koujiHandler.GetPhotoHashJobStatus)
+	api.Post("/photo-duplicates/scan", koujiHandler.StartPhotoHashJob)
+	api.Get("/receivables", koujiHandler.GetReceivables)
+	api.Get("/revenue/monthly", koujiHandler.GetMonthlyRevenue)
+	api.Get("/kouji-entries/:id/materials", materialHandler.GetKoujiMaterials)
+	api.Put("/kouji-entries/:id/materials", materialHandler.UpdateKoujiMaterials)
+	api.Get("/kouji-stats", koujiHandler.GetKoujiStats)
+	api.Get("/kouji-conflicts", koujiHandler.GetKoujiConflicts)
+	api.Get("/kouji-rules", koujiHandler.GetKoujiRules)
+	api.Put("/kouji-rules", koujiHandler.UpdateKoujiRules)
+	api.Post("/kouji-rules/preview", koujiHandler.PreviewKoujiRules)
+	api.Post("/kouji-rules/apply", koujiHandler.ApplyKoujiRules)
+	api.Get("/kouji-fields", koujiHandler.GetCustomFieldSchema)
+	api.Put("/kouji-fields", koujiHandler.UpdateCustomFieldSchema)
+	api.Get("/kouji-settings", koujiHandler.GetKoujiSettings)
+	api.Put("/kouji-settings", koujiHandler.UpdateKoujiSettings)
+	api.Get("/document-settings", koujiHandler.GetDocumentSettings)
+	api.Put("/document-settings", koujiHandler.UpdateDocumentSettings)
+
+	// Tag routes
+	api.Get("/tags", tagHandler.GetTags)
+	api.Post("/tags/rename", tagHandler.RenameTag)
+	api.Post("/tags/merge", tagHandler.MergeTags)
+	api.Get("/tags/vocabulary", tagHandler.GetTagVocabulary)
+	api.Put("/tags/vocabulary", tagHandler.UpdateTagVocabulary)
+
+	// Company routes
+	api.Get("/companies", companyHandler.GetCompanies)
+	api.Post("/companies", companyHandler.CreateCompany)
+	api.Get("/companies/:name", companyHandler.GetCompany)
+	api.Put("/companies/:name", companyHandler.UpdateCompany)
+	api.Delete("/companies/:name", companyHandler.DeleteCompany)
+	api.Get("/companies/:name/kouji-entries", companyHandler.GetCompanyKoujiEntries)
+
+	// Worker routes
+	api.Get("/workers", workerHandler.GetWorkers)
+	api.Post("/workers", workerHandler.CreateWorker)
+	api.Get("/workers/expiries", workerHandler.GetQualificationExpiries)
+	api.Get("/workers/qualification-check", workerHandler.CheckAllQualifications)
+	api.Get("/workers/:name", workerHandler.GetWorker)
+	api.Put("/workers/:name", workerHandler.UpdateWorker)
+	api.Delete("/workers/:name", workerHandler.DeleteWorker)
+
+	// Material routes
+	api.Get("/materials", materialHandler.GetMaterials)
+	api.Post("/materials", materialHandler.CreateMaterial)
+	api.Get("/materials/consumption", materialHandler.GetMaterialConsumption)
+	api.Get("/materials/:code", materialHandler.GetMaterial)
+	api.Put("/materials/:code", materialHandler.UpdateMaterial)
+	api.Delete("/materials/:code", materialHandler.DeleteMaterial)
+	api.Get("/stock", materialHandler.GetStockLevels)
+	api.Get("/stock/movements", materialHandler.GetStockMovements)
+	api.Post("/stock/movements", materialHandler.CreateStockMovement)
+	api.Delete("/stock/movements/:id", materialHandler.DeleteStockMovement)
+
+	// Location routes
+	api.Get("/locations", locationHandler.GetLocations)
+	api.Post("/locations", locationHandler.CreateLocation)
+	api.Get("/locations/predictions", locationHandler.GetMaintenancePredictions)
+	api.Get("/locations/:company/:location", locationHandler.GetLocation)
+	api.Put("/locations/:company/:location", locationHandler.UpdateLocation)
+	api.Delete("/locations/:company/:location", locationHandler.DeleteLocation)
+	api.Get("/locations/:company/:location/history", locationHandler.GetLocationHistory)
+
 	api.Post("/time/parse", timeHandler.ParseTime)
 	api.Get("/time/formats", timeHandler.GetSupportedFormats)
 
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CompanyHandler 取引先会社のHTTPリクエストを処理するハンドラー
+type CompanyHandler struct {
+	koujiService *services.KoujiService
+}
+
+// NewCompanyHandler 新しいCompanyHandlerインスタンスを作成します
+func NewCompanyHandler(koujiService *services.KoujiService) *CompanyHandler {
+	return &CompanyHandler{
+		koujiService: koujiService,
+	}
+}
+
+// GetCompanies godoc
+// @Summary      会社一覧の取得
+// @Description  登録されている取引先会社の一覧を返します。
+// @Tags         会社管理
+// @Produce      json
+// @Success      200 {array} models.Company "会社一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /companies [get]
+func (h *CompanyHandler) GetCompanies(c *fiber.Ctx) error {
+	companies, err := h.koujiService.GetCompanies()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load companies",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(companies)
+}
+
+// GetCompany godoc
+// @Summary      会社の取得
+// @Description  正式名または別名で指定した会社を返します。
+// @Tags         会社管理
+// @Produce      json
+// @Param        name path string true "会社名（正式名または別名）"
+// @Success      200 {object} models.Company "会社"
+// @Failure      404 {object} map[string]string "会社が登録されていない"
+// @Router       /companies/{name} [get]
+func (h *CompanyHandler) GetCompany(c *fiber.Ctx) error {
+	company, err := h.koujiService.GetCompany(pathParam(c, "name"))
+	if err != nil {
+		return companyErrorResponse(c, "Failed to get company", err)
+	}
+	return c.JSON(company)
+}
+
+// CreateCompany godoc
+// @Summary      会社の登録
+// @Description  取引先会社を登録します。正式名と別名は既存の会社と重複できません。
+// @Tags         会社管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.Company true "会社"
+// @Success      201 {object} models.Company "登録した会社"
+// @Failure      400 {object} map[string]string "不正な会社情報"
+// @Router       /companies [post]
+func (h *CompanyHandler) CreateCompany(c *fiber.Ctx) error {
+	var req models.Company
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	company, err := h.koujiService.CreateCompany(req)
+	if err != nil {
+		return companyErrorResponse(c, "Failed to create company", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(company)
+}
+
+// UpdateCompany godoc
+// @Summary      会社の更新
+// @Description  正式名または別名で指定した会社の情報を置き換えます。
+// @Tags         会社管理
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "会社名（正式名または別名）"
+// @Param        request body models.Company true "会社"
+// @Success      200 {object} models.Company "更新後の会社"
+// @Failure      400 {object} map[string]string "不正な会社情報"
+// @Failure      404 {object} map[string]string "会社が登録されていない"
+// @Router       /companies/{name} [put]
+func (h *CompanyHandler) UpdateCompany(c *fiber.Ctx) error {
+	var req models.Company
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	company, err := h.koujiService.UpdateCompany(pathParam(c, "name"), req)
+	if err != nil {
+		return companyErrorResponse(c, "Failed to update company", err)
+	}
+	return c.JSON(company)
+}
+
+// DeleteCompany godoc
+// @Summary      会社の削除
+// @Description  正式名または別名で指定した会社を削除します。工事のフォルダーは変更しません。
+// @Tags         会社管理
+// @Param        name path string true "会社名（正式名または別名）"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "会社が登録されていない"
+// @Router       /companies/{name} [delete]
+func (h *CompanyHandler) DeleteCompany(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteCompany(pathParam(c, "name")); err != nil {
+		return companyErrorResponse(c, "Failed to delete company", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetCompanyKoujiEntries godoc
+// @Summary      会社の工事一覧の取得
+// @Description  指定した会社（別名のフォルダーを含む）の工事一覧を返します。
+// @Tags         会社管理
+// @Produce      json
+// @Param        name path string true "会社名（正式名または別名）"
+// @Success      200 {object} models.CompanyKoujiEntriesResponse "会社と工事一覧"
+// @Failure      404 {object} map[string]string "会社が登録されていない"
+// @Router       /companies/{name}/kouji-entries [get]
+func (h *CompanyHandler) GetCompanyKoujiEntries(c *fiber.Ctx) error {
+	company, entries, err := h.koujiService.GetCompanyKoujiEntries(pathParam(c, "name"))
+	if err != nil {
+		return companyErrorResponse(c, "Failed to get kouji entries", err)
+	}
+	return c.JSON(models.CompanyKoujiEntriesResponse{
+		Company:      company,
+		KoujiEntries: entries,
+		Count:        len(entries),
+	})
+}
+
+// pathParam はURLエンコードされたパスパラメータをデコードして返す
+func pathParam(c *fiber.Ctx, key string) string {
+	value := c.Params(key)
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+// companyErrorResponse は会社が見つからない場合は404、それ以外は400を返す
+func companyErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrCompanyNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"io/fs"
+	"net/http"
 	"penguin-backend/internal/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -23,6 +26,7 @@ func NewFileSystemHandler(fsService *services.FileSystemService) *FileSystemHand
 // @Accept       json
 // @Produce      json
 // @Param        path query string false "Path to the directory to list" default(~/penguin)
+// @Param        photo query bool false "Read EXIF capture time, camera and GPS of JPEG photos" default(false)
 // @Success      200 {object} models.FolderListResponse "Successful response"
 // @Failure      500 {object} map[string]string "Internal server error"
 // @Router       /file-entries [get]
@@ -36,6 +40,44 @@ func (h *FileSystemHandler) GetFileEntries(c *fiber.Ctx) error {
 			"message": err.Error(),
 		})
 	}
+	if c.QueryBool("photo") {
+		h.FileSystemService.AddPhotoMetadata(fileEntries.FileEntries)
+	}
 
 	return c.JSON(fileEntries)
 }
+
+// GetThumbnail godoc
+// @Summary      画像のサムネイルの取得
+// @Description  JPEG・PNG・WebPの画像を縮小したサムネイルを返します。EXIFの向きを反映し、透過のある画像はPNG、それ以外はJPEGで返します。
+// @Description  作成したサムネイルはルートの .thumbnails フォルダーにキャッシュし、合計サイズが上限を超えたら使われていないものから削除します。
+// @Tags         file-entries
+// @Produce      jpeg
+// @Produce      png
+// @Param        path query string true "画像のパス（ルートからの相対パスまたはルート配下の絶対パス）"
+// @Param        size query string false "大きさ（small: 160px、medium: 320px、large: 640px）" Enums(small, medium, large) default(medium)
+// @Success      200 {file} binary "サムネイル"
+// @Failure      400 {object} map[string]string "対応していない画像または大きさ"
+// @Failure      404 {object} map[string]string "画像がない"
+// @Router       /file-entries/thumbnail [get]
+func (h *FileSystemHandler) GetThumbnail(c *fiber.Ctx) error {
+	thumb, err := h.FileSystemService.GetThumbnail(c.Query("path"), c.Query("size", "medium"))
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			status = fiber.StatusNotFound
+		case errors.Is(err, services.ErrInvalidThumbnailSize), errors.Is(err, services.ErrUnsupportedImage), errors.Is(err, services.ErrOutsideRoot):
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":   "Failed to create thumbnail",
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, thumb.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	c.Set(fiber.HeaderLastModified, thumb.ModTime.UTC().Format(http.TimeFormat))
+	return c.Send(thumb.Data)
+}
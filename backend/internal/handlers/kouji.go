@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"penguin-backend/internal/models"
 	"penguin-backend/internal/services"
+	"penguin-backend/internal/utils"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -29,12 +33,26 @@ func NewKoujiHandler(fsService *services.FileSystemService, koujiService *servic
 // @Accept       json
 // @Produce      json
 // @Param        path query string false "工事フォルダーのパス" default(~/penguin/豊田築炉/2-工事)
+// @Param        q query string false "検索クエリ (例: company:豊田築炉 year:2024..2025 -tag:見積のみ 名和)"
+// @Param        company query string false "会社名（部分一致）"
+// @Param        status query string false "状態" Enums(予定, 進行中, 完了, 不明)
+// @Param        tag query string false "タグ"
+// @Param        fiscal_year query int false "年度"
+// @Param        from query string false "開始日の下限 (例: 2024-04-01)"
+// @Param        to query string false "開始日の上限 (例: 2025-03-31)"
 // @Success      200 {object} models.KoujiEntriesResponse "工事プロジェクト一覧"
+// @Failure      400 {object} map[string]any "クエリの構文エラー"
 // @Failure      500 {object} map[string]string "サーバーエラー"
 // @Router       /kouji-entries [get]
 func (h *KoujiHandler) GetKoujiEntries(c *fiber.Ctx) error {
+	// 絞り込み条件を解析
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
 	// KoujiServiceを使用して工事エントリを取得
-	koujiEntries := h.koujiService.GetKoujiEntries()
+	koujiEntries := filter.Apply(h.koujiService.GetKoujiEntries())
 
 	totalSize := int64(0)
 	for _, kouji := range koujiEntries {
@@ -69,6 +87,22 @@ func (h *KoujiHandler) SaveKoujiEntries(c *fiber.Ctx) error {
 		})
 	}
 
+	// 管理語彙にないタグを検証
+	if err := h.koujiService.ValidateKoujiTags(entries); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid tags",
+			"message": err.Error(),
+		})
+	}
+
+	// カスタムフィールドを定義に従って検証
+	if err := h.koujiService.ValidateKoujiCustomFields(entries); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid custom fields",
+			"message": err.Error(),
+		})
+	}
+
 	// KoujiServiceを使用して工事プロジェクトを保存
 	err := h.koujiService.SaveKoujiEntries(entries)
 	if err != nil {
@@ -86,3 +120,46 @@ func (h *KoujiHandler) SaveKoujiEntries(c *fiber.Ctx) error {
 		"count":       len(entries),
 	})
 }
+
+// parseKoujiFilter はクエリパラメータ（q, company, status, tag, fiscal_year, from, to）から絞り込み条件を作成する
+func parseKoujiFilter(c *fiber.Ctx) (*services.KoujiFilter, error) {
+	query, err := services.ParseKoujiQuery(c.Query("q"))
+	if err != nil {
+		return nil, err
+	}
+	filter := &services.KoujiFilter{
+		Query:   query,
+		Company: c.Query("company"),
+		Status:  c.Query("status"),
+		Tag:     c.Query("tag"),
+	}
+	if fy := c.Query("fiscal_year"); fy != "" {
+		if filter.FiscalYear, err = strconv.Atoi(fy); err != nil {
+			return nil, fmt.Errorf("fiscal_year: %w", err)
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = utils.ParseTime(from); err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = utils.ParseTime(to); err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// queryErrorResponse は絞り込み条件のエラーを返す（構文エラーの場合は位置情報付き）
+func queryErrorResponse(c *fiber.Ctx, err error) error {
+	response := fiber.Map{
+		"error":   "Invalid query",
+		"message": err.Error(),
+	}
+	var parseErr *services.QueryParseError
+	if errors.As(err, &parseErr) {
+		response["position"] = parseErr.Pos
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(response)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+	"penguin-backend/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiAssignments godoc
+// @Summary      工事の割り当て一覧の取得
+// @Description  工事に割り当てた作業員・協力会社・機材の一覧を返します。
+// @Tags         割り当て管理
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {array} models.KoujiAssignment "割り当て一覧"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/assignments [get]
+func (h *KoujiHandler) GetKoujiAssignments(c *fiber.Ctx) error {
+	assignments, err := h.koujiService.GetKoujiAssignments(c.Params("id"))
+	if err != nil {
+		return assignmentErrorResponse(c, "Failed to get assignments", err)
+	}
+	return c.JSON(assignments)
+}
+
+// CreateKoujiAssignment godoc
+// @Summary      工事への割り当ての追加
+// @Description  工事に作業員・協力会社・機材を割り当てます。期間を省略すると工事の期間全体になります。
+// @Description  同じ名前が期間の重複する別の工事に割り当てられている場合や、作業員の資格・安全書類が期間中に切れる場合は warnings に警告を返します（登録は行います）。
+// @Tags         割り当て管理
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.KoujiAssignment true "割り当て"
+// @Success      201 {object} models.KoujiAssignmentResponse "登録した割り当てと警告"
+// @Failure      400 {object} map[string]string "不正な割り当て"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/assignments [post]
+func (h *KoujiHandler) CreateKoujiAssignment(c *fiber.Ctx) error {
+	var req models.KoujiAssignment
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	assignment, warnings, err := h.koujiService.AddKoujiAssignment(c.Params("id"), req)
+	if err != nil {
+		return assignmentErrorResponse(c, "Failed to add assignment", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(models.KoujiAssignmentResponse{
+		Assignment: assignment,
+		Warnings:   warnings,
+	})
+}
+
+// UpdateKoujiAssignment godoc
+// @Summary      工事の割り当ての更新
+// @Description  工事の割り当てを置き換えます。名前・種類・期間が変わると割り当てのIDも変わります。
+// @Tags         割り当て管理
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        assignmentId path string true "割り当てID"
+// @Param        request body models.KoujiAssignment true "割り当て"
+// @Success      200 {object} models.KoujiAssignmentResponse "更新後の割り当てと警告"
+// @Failure      400 {object} map[string]string "不正な割り当て"
+// @Failure      404 {object} map[string]string "工事または割り当てがない"
+// @Router       /kouji-entries/{id}/assignments/{assignmentId} [put]
+func (h *KoujiHandler) UpdateKoujiAssignment(c *fiber.Ctx) error {
+	var req models.KoujiAssignment
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	assignment, warnings, err := h.koujiService.UpdateKoujiAssignment(c.Params("id"), c.Params("assignmentId"), req)
+	if err != nil {
+		return assignmentErrorResponse(c, "Failed to update assignment", err)
+	}
+	return c.JSON(models.KoujiAssignmentResponse{
+		Assignment: assignment,
+		Warnings:   warnings,
+	})
+}
+
+// DeleteKoujiAssignment godoc
+// @Summary      工事の割り当ての削除
+// @Description  工事の割り当てを削除します。
+// @Tags         割り当て管理
+// @Param        id path string true "工事ID"
+// @Param        assignmentId path string true "割り当てID"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "工事または割り当てがない"
+// @Router       /kouji-entries/{id}/assignments/{assignmentId} [delete]
+func (h *KoujiHandler) DeleteKoujiAssignment(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteKoujiAssignment(c.Params("id"), c.Params("assignmentId")); err != nil {
+		return assignmentErrorResponse(c, "Failed to delete assignment", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWorkerSchedule godoc
+// @Summary      作業員の予定の取得
+// @Description  作業員・協力会社・機材のすべての工事の割り当てを期間順に返します。期間が重複する割り当ては warnings に含まれます。
+// @Tags         割り当て管理
+// @Produce      json
+// @Param        name path string true "作業員名・協力会社名・機材名"
+// @Param        from query string false "期間の開始日 (例: 2024-04-01)"
+// @Param        to query string false "期間の終了日 (例: 2024-06-30)"
+// @Success      200 {object} models.WorkerScheduleResponse "予定"
+// @Failure      400 {object} map[string]string "不正なパラメータ"
+// @Router       /assignments/schedule/{name} [get]
+func (h *KoujiHandler) GetWorkerSchedule(c *fiber.Ctx) error {
+	from, to, err := parsePeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid period",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(h.koujiService.GetWorkerSchedule(pathParam(c, "name"), from, to))
+}
+
+// GetAssignmentLoad godoc
+// @Summary      日ごとの割り当て状況の取得
+// @Description  すべての工事の割り当てを日ごとに集計します。同じ日に複数の工事に割り当てられている名前は double_booked に含まれます。
+// @Tags         割り当て管理
+// @Produce      json
+// @Param        from query string false "期間の開始日（省略時は今日）"
+// @Param        to query string false "期間の終了日（省略時は開始日の4週間後、最大366日）"
+// @Success      200 {array} models.DailyLoad "日ごとの割り当て状況"
+// @Failure      400 {object} map[string]string "不正なパラメータ"
+// @Router       /assignments/load [get]
+func (h *KoujiHandler) GetAssignmentLoad(c *fiber.Ctx) error {
+	from, to, err := parsePeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid period",
+			"message": err.Error(),
+		})
+	}
+	if from.IsZero() {
+		from = time.Now()
+	}
+	if to.IsZero() {
+		to = from.AddDate(0, 0, 27)
+	}
+
+	loads, err := h.koujiService.GetAssignmentLoad(from, to)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid period",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(loads)
+}
+
+// parsePeriod はクエリパラメータ from, to を解析する（省略時はゼロ値）
+func parsePeriod(c *fiber.Ctx) (from, to time.Time, err error) {
+	if s := c.Query("from"); s != "" {
+		if from, err = utils.ParseTime(s); err != nil {
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		to, err = utils.ParseTime(s)
+	}
+	return
+}
+
+// assignmentErrorResponse は工事または割り当てが見つからない場合は404、それ以外は400を返す
+func assignmentErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrKoujiNotFound) || errors.Is(err, services.ErrAssignmentNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bytes"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiCalendar godoc
+// @Summary      工事予定のiCalendarフィード
+// @Description  各工事を開始日から終了日までの終日イベント(VEVENT)として出力します。
+// @Description  カレンダーアプリからURLを購読できます。UIDは工事IDから生成されるため安定しています。
+// @Description  設定のUIのベースURL（ui_base_url）がある場合は、各予定に工事の画面へのリンクを付けます。
+// @Tags         工事管理
+// @Produce      text/calendar
+// @Param        company query string false "会社名（部分一致）"
+// @Param        status query string false "状態" Enums(予定, 進行中, 完了, 不明)
+// @Param        tag query string false "タグ"
+// @Param        q query string false "検索クエリ"
+// @Success      200 {string} string "iCalendarデータ"
+// @Failure      400 {object} map[string]any "不正な絞り込み条件"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-entries/calendar.ics [get]
+func (h *KoujiHandler) GetKoujiCalendar(c *fiber.Ctx) error {
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
+	entries := filter.Apply(h.koujiService.GetKoujiEntries())
+
+	var buf bytes.Buffer
+	if err := services.WriteKoujiCalendar(&buf, entries, h.koujiService.GetSettings().UIBaseURL); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to generate calendar",
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="kouji.ics"`)
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiConflicts godoc
+// @Summary      工程の競合の検出
+// @Description  工事一覧（絞り込み可）から工程の競合を検出します。
+// @Description  同じ現場で工期が重複する工事、同じ作業員・協力会社・機材が割り当てられ工期が重複する工事、終了日が開始日より前の工事を返します。
+// @Tags         工事管理
+// @Produce      json
+// @Param        include_completed query bool false "完了した工事同士の重複も含める" default(false)
+// @Param        q query string false "検索クエリ"
+// @Param        company query string false "会社名（部分一致）"
+// @Param        status query string false "状態" Enums(予定, 進行中, 完了, 不明)
+// @Param        tag query string false "タグ"
+// @Param        fiscal_year query int false "年度"
+// @Param        from query string false "開始日の下限 (例: 2024-04-01)"
+// @Param        to query string false "開始日の上限 (例: 2025-03-31)"
+// @Success      200 {object} models.KoujiConflictsResponse "工程の競合一覧"
+// @Failure      400 {object} map[string]any "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-conflicts [get]
+func (h *KoujiHandler) GetKoujiConflicts(c *fiber.Ctx) error {
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
+	conflicts, err := h.koujiService.GetKoujiConflicts(filter, c.QueryBool("include_completed", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to detect conflicts",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.KoujiConflictsResponse{
+		Conflicts: conflicts,
+		Count:     len(conflicts),
+	})
+}
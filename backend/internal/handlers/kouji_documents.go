@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDocumentSettings godoc
+// @Summary      帳票の設定の取得
+// @Description  見積書・請求書の発行元・印影・振込先・フォント・文面の設定を返します。
+// @Tags         請求・売上
+// @Produce      json
+// @Success      200 {object} models.DocumentSettings "帳票の設定"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /document-settings [get]
+func (h *KoujiHandler) GetDocumentSettings(c *fiber.Ctx) error {
+	settings, err := h.koujiService.GetDocumentSettings()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to read document settings",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(settings)
+}
+
+// UpdateDocumentSettings godoc
+// @Summary      帳票の設定の更新
+// @Description  見積書・請求書の設定を更新します。フォントと印影の画像は読み込めることを確認してから保存します。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        request body models.DocumentSettings true "帳票の設定"
+// @Success      200 {object} models.DocumentSettings "更新後の設定"
+// @Failure      400 {object} map[string]string "不正な設定"
+// @Router       /document-settings [put]
+func (h *KoujiHandler) UpdateDocumentSettings(c *fiber.Ctx) error {
+	var req models.DocumentSettings
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.koujiService.SaveDocumentSettings(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to save document settings",
+			"message": err.Error(),
+		})
+	}
+	return h.GetDocumentSettings(c)
+}
+
+// CreateEstimateDocument godoc
+// @Summary      見積書のPDFの作成
+// @Description  工事の見積書のPDFを作成し、工事フォルダーに版番号付きのファイル名（見積書_会社名_現場名_v2.pdf など）で保存します。
+// @Description  明細を省略した場合は見積金額の一式とします。消費税は発行日の税率で計算します。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.DocumentRequest false "見積書の内容"
+// @Success      201 {object} models.FileEntry "保存したPDF"
+// @Failure      400 {object} map[string]string "不正な内容"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Failure      500 {object} map[string]string "帳票用フォントがない"
+// @Router       /kouji-entries/{id}/documents/estimate [post]
+func (h *KoujiHandler) CreateEstimateDocument(c *fiber.Ctx) error {
+	var req models.DocumentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	entry, err := h.koujiService.CreateEstimateDocument(c.Params("id"), req)
+	if err != nil {
+		return financeErrorResponse(c, "Failed to create estimate", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// CreateInvoiceDocument godoc
+// @Summary      請求書のPDFの作成
+// @Description  工事の請求の請求書のPDFを作成し、工事フォルダーに版番号付きのファイル名で保存します。
+// @Description  明細を省略した場合は請求金額の一式とし、指定した場合は合計が請求金額（税抜）と一致する必要があります。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        invoiceId path string true "請求ID"
+// @Param        request body models.DocumentRequest false "請求書の内容"
+// @Success      201 {object} models.FileEntry "保存したPDF"
+// @Failure      400 {object} map[string]string "不正な内容"
+// @Failure      404 {object} map[string]string "工事または請求がない"
+// @Failure      500 {object} map[string]string "帳票用フォントがない"
+// @Router       /kouji-entries/{id}/invoices/{invoiceId}/document [post]
+func (h *KoujiHandler) CreateInvoiceDocument(c *fiber.Ctx) error {
+	var req models.DocumentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	entry, err := h.koujiService.CreateInvoiceDocument(c.Params("id"), c.Params("invoiceId"), req)
+	if err != nil {
+		return financeErrorResponse(c, "Failed to create invoice document", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"penguin-backend/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportKoujiEntries godoc
+// @Summary      工事台帳のエクスポート
+// @Description  工事一覧（絞り込み可）をCSVまたはExcel形式で出力します。
+// @Description  CSVは日本語版Excelで開けるようUTF-8(BOM付き)またはShift_JISを選択できます。
+// @Tags         工事管理
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format query string false "出力形式" Enums(csv, xlsx) default(csv)
+// @Param        encoding query string false "CSVの文字コード" Enums(utf8, utf8bom, sjis) default(utf8bom)
+// @Param        company query string false "会社名（部分一致）"
+// @Param        status query string false "状態" Enums(予定, 進行中, 完了, 不明)
+// @Param        tag query string false "タグ"
+// @Param        fiscal_year query int false "年度"
+// @Param        from query string false "開始日の下限 (例: 2024-04-01)"
+// @Param        to query string false "開始日の上限 (例: 2025-03-31)"
+// @Param        q query string false "検索クエリ"
+// @Success      200 {file} file "工事台帳"
+// @Failure      400 {object} map[string]any "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-entries/export [get]
+func (h *KoujiHandler) ExportKoujiEntries(c *fiber.Ctx) error {
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid format",
+			"message": fmt.Sprintf("未対応の出力形式です: %s", format),
+		})
+	}
+
+	rows, err := h.koujiService.BuildKoujiLedger(filter.Apply(h.koujiService.GetKoujiEntries()))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to export kouji entries",
+			"message": err.Error(),
+		})
+	}
+	filename := fmt.Sprintf("工事台帳_%s.%s", time.Now().Format("20060102"), format)
+
+	var buf bytes.Buffer
+	switch format {
+	case "xlsx":
+		err = services.WriteLedgerXLSX(&buf, rows)
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		enc := c.Query("encoding", services.CSVEncodingUTF8BOM)
+		err = services.WriteLedgerCSV(&buf, rows, enc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid encoding",
+				"message": err.Error(),
+			})
+		}
+		charset := "utf-8"
+		if enc == services.CSVEncodingSJIS {
+			charset = "shift_jis"
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset="+charset)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to export kouji entries",
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(filename))
+	return c.Send(buf.Bytes())
+}
+
+// contentDisposition は日本語ファイル名に対応したContent-Dispositionヘッダー値を返す
+func contentDisposition(filename string) string {
+	return fmt.Sprintf(`attachment; filename="download"; filename*=UTF-8''%s`, url.PathEscape(filename))
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCustomFieldSchema godoc
+// @Summary      カスタムフィールド定義の取得
+// @Description  工事に保存できるカスタムフィールドの定義（型・必須・選択肢・範囲・正規表現・対象会社）を返します。
+// @Tags         工事管理
+// @Produce      json
+// @Success      200 {array} models.CustomFieldDefinition "カスタムフィールド定義"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-fields [get]
+func (h *KoujiHandler) GetCustomFieldSchema(c *fiber.Ctx) error {
+	schema, err := h.koujiService.GetCustomFieldSchema()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to read custom field schema",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(schema)
+}
+
+// UpdateCustomFieldSchema godoc
+// @Summary      カスタムフィールド定義の更新
+// @Description  カスタムフィールドの定義を置き換えます。
+// @Tags         工事管理
+// @Accept       json
+// @Produce      json
+// @Param        request body []models.CustomFieldDefinition true "カスタムフィールド定義"
+// @Success      200 {array} models.CustomFieldDefinition "保存した定義"
+// @Failure      400 {object} map[string]string "不正な定義"
+// @Router       /kouji-fields [put]
+func (h *KoujiHandler) UpdateCustomFieldSchema(c *fiber.Ctx) error {
+	var schema []models.CustomFieldDefinition
+	if err := c.BodyParser(&schema); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.koujiService.SaveCustomFieldSchema(schema); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to save custom field schema",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(schema)
+}
+
+// UpdateKoujiCustomFields godoc
+// @Summary      工事のカスタムフィールドの更新
+// @Description  指定した工事のカスタムフィールドを定義に従って検証し、更新します。値にnullを指定したキーは削除します。
+// @Tags         工事管理
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.CustomFieldsUpdateRequest true "カスタムフィールドの値"
+// @Success      200 {object} models.KoujiEntry "更新後の工事"
+// @Failure      400 {object} map[string]string "検証エラー"
+// @Router       /kouji-entries/{id}/custom-fields [put]
+func (h *KoujiHandler) UpdateKoujiCustomFields(c *fiber.Ctx) error {
+	var req models.CustomFieldsUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	entry, err := h.koujiService.UpdateKoujiCustomFields(c.Params("id"), req.CustomFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to update custom fields",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(entry)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiFinance godoc
+// @Summary      工事の金額の取得
+// @Description  工事の見積金額・受注金額と、請求・入金の合計、未入金額、未請求額を返します。
+// @Tags         請求・売上
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {object} models.KoujiFinanceSummary "工事の金額"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/finance [get]
+func (h *KoujiHandler) GetKoujiFinance(c *fiber.Ctx) error {
+	summary, err := h.koujiService.GetKoujiFinance(c.Params("id"))
+	if err != nil {
+		return financeErrorResponse(c, "Failed to get finance", err)
+	}
+	return c.JSON(summary)
+}
+
+// UpdateKoujiFinance godoc
+// @Summary      見積金額・受注金額の更新
+// @Description  工事の見積金額・受注金額（税抜）を更新します。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.KoujiFinanceUpdateRequest true "見積金額・受注金額"
+// @Success      200 {object} models.KoujiFinanceSummary "更新後の工事の金額"
+// @Failure      400 {object} map[string]string "不正な金額"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/finance [put]
+func (h *KoujiHandler) UpdateKoujiFinance(c *fiber.Ctx) error {
+	var req models.KoujiFinanceUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	summary, err := h.koujiService.UpdateKoujiFinance(c.Params("id"), req)
+	if err != nil {
+		return financeErrorResponse(c, "Failed to update finance", err)
+	}
+	return c.JSON(summary)
+}
+
+// CreateKoujiInvoice godoc
+// @Summary      請求の追加
+// @Description  工事に請求を追加します。税率を省略した場合は請求日の消費税率（設定の consumption_tax_rates）を使用し、消費税額（1円未満切り捨て）と税込金額を計算します。
+// @Description  入金日のみを指定した場合は全額入金として扱います。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.KoujiInvoice true "請求"
+// @Success      201 {object} models.KoujiInvoice "追加した請求"
+// @Failure      400 {object} map[string]string "不正な請求"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/invoices [post]
+func (h *KoujiHandler) CreateKoujiInvoice(c *fiber.Ctx) error {
+	var req models.KoujiInvoice
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	invoice, err := h.koujiService.AddKoujiInvoice(c.Params("id"), req)
+	if err != nil {
+		return financeErrorResponse(c, "Failed to add invoice", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(invoice)
+}
+
+// UpdateKoujiInvoice godoc
+// @Summary      請求の更新
+// @Description  工事の請求を置き換えます。入金の記録にも使用します。
+// @Tags         請求・売上
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        invoiceId path string true "請求ID"
+// @Param        request body models.KoujiInvoice true "請求"
+// @Success      200 {object} models.KoujiInvoice "更新した請求"
+// @Failure      400 {object} map[string]string "不正な請求"
+// @Failure      404 {object} map[string]string "工事または請求がない"
+// @Router       /kouji-entries/{id}/invoices/{invoiceId} [put]
+func (h *KoujiHandler) UpdateKoujiInvoice(c *fiber.Ctx) error {
+	var req models.KoujiInvoice
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	invoice, err := h.koujiService.UpdateKoujiInvoice(c.Params("id"), c.Params("invoiceId"), req)
+	if err != nil {
+		return financeErrorResponse(c, "Failed to update invoice", err)
+	}
+	return c.JSON(invoice)
+}
+
+// DeleteKoujiInvoice godoc
+// @Summary      請求の削除
+// @Description  工事の請求を削除します。
+// @Tags         請求・売上
+// @Param        id path string true "工事ID"
+// @Param        invoiceId path string true "請求ID"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "工事または請求がない"
+// @Router       /kouji-entries/{id}/invoices/{invoiceId} [delete]
+func (h *KoujiHandler) DeleteKoujiInvoice(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteKoujiInvoice(c.Params("id"), c.Params("invoiceId")); err != nil {
+		return financeErrorResponse(c, "Failed to delete invoice", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetReceivables godoc
+// @Summary      会社ごとの売掛金
+// @Description  未入金の請求を会社ごとに集計し、未入金額の多い順に返します。支払期日を過ぎた金額と日数も返します。
+// @Tags         請求・売上
+// @Produce      json
+// @Param        as_of query string false "期日超過を判定する日付 (YYYY-MM-DD、省略時は今日)"
+// @Success      200 {array} models.CompanyReceivable "会社ごとの売掛金"
+// @Failure      400 {object} map[string]string "不正な日付"
+// @Router       /receivables [get]
+func (h *KoujiHandler) GetReceivables(c *fiber.Ctx) error {
+	asOf := c.Query("as_of", time.Now().Format("2006-01-02"))
+	if _, err := time.Parse("2006-01-02", asOf); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid as_of",
+			"message": "as_of must be YYYY-MM-DD",
+		})
+	}
+	return c.JSON(h.koujiService.GetReceivables(asOf))
+}
+
+// GetMonthlyRevenue godoc
+// @Summary      月ごとの売上の集計
+// @Description  全工事の請求金額（請求日の月）と入金額（入金日の月）を月ごとに集計します。
+// @Tags         請求・売上
+// @Produce      json
+// @Param        from query string false "開始月 (YYYY-MM)"
+// @Param        to query string false "終了月 (YYYY-MM)"
+// @Success      200 {array} models.MonthlyRevenue "月ごとの売上"
+// @Router       /revenue/monthly [get]
+func (h *KoujiHandler) GetMonthlyRevenue(c *fiber.Ctx) error {
+	return c.JSON(h.koujiService.GetMonthlyRevenue(c.Query("from"), c.Query("to")))
+}
+
+// financeErrorResponse は工事・請求が見つからない場合は404、それ以外は400を返す
+func financeErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrKoujiNotFound) || errors.Is(err, services.ErrInvoiceNotFound) {
+		status = fiber.StatusNotFound
+	} else if errors.Is(err, services.ErrDocumentFontMissing) {
+		status = fiber.StatusInternalServerError
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
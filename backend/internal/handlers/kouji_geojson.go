@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiGeoJSON godoc
+// @Summary      工事の所在地のGeoJSON出力
+// @Description  工事一覧（絞り込み可）を登録された現場の座標でGeoJSONのFeatureCollectionとして出力します。
+// @Description  座標が登録されていない現場の工事は含まれません。group=site の場合は現場ごとに出力します。
+// @Tags         工事管理
+// @Produce      application/geo+json
+// @Param        group query string false "出力単位" Enums(kouji, site) default(kouji)
+// @Param        q query string false "検索クエリ"
+// @Param        company query string false "会社名（部分一致）"
+// @Param        status query string false "状態" Enums(予定, 進行中, 完了, 不明)
+// @Param        tag query string false "タグ"
+// @Param        fiscal_year query int false "年度"
+// @Param        from query string false "開始日の下限 (例: 2024-04-01)"
+// @Param        to query string false "開始日の上限 (例: 2025-03-31)"
+// @Success      200 {object} models.GeoJSONFeatureCollection "GeoJSON"
+// @Failure      400 {object} map[string]any "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-entries/geojson [get]
+func (h *KoujiHandler) GetKoujiGeoJSON(c *fiber.Ctx) error {
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
+	group := c.Query("group", "kouji")
+	if group != "kouji" && group != "site" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid group",
+			"message": fmt.Sprintf("未対応の出力単位です: %s", group),
+		})
+	}
+
+	collection, err := h.koujiService.BuildKoujiGeoJSON(filter.Apply(h.koujiService.GetKoujiEntries()), group == "site")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to build GeoJSON",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(collection, "application/geo+json")
+}
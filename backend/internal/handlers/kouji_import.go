@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"penguin-backend/internal/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportKoujiEntries godoc
+// @Summary      工事メタデータの一括取り込み
+// @Description  CSVまたはExcelの工事台帳を読み込み、ID、または開始日・会社名・現場名で既存の工事フォルダーに照合します。
+// @Description  apply=false（既定）では変更内容のプレビュー、一致しない行、複数に一致する行を返します。
+// @Description  apply=true では不正な行がない場合に限り、すべての変更を一度にデータベースへ反映します。
+// @Tags         工事管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "工事台帳ファイル（.csv / .xlsx）"
+// @Param        format query string false "ファイル形式（省略時は拡張子から判定）" Enums(csv, xlsx)
+// @Param        apply query bool false "変更を反映する" default(false)
+// @Success      200 {object} models.KoujiImportResult "取り込み結果"
+// @Failure      400 {object} map[string]string "不正なファイル"
+// @Failure      422 {object} models.KoujiImportResult "不正な行があるため反映しなかった"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-entries/import [post]
+func (h *KoujiHandler) ImportKoujiEntries(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "File is required",
+			"message": err.Error(),
+		})
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to open file",
+			"message": err.Error(),
+		})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to read file",
+			"message": err.Error(),
+		})
+	}
+
+	table, err := services.ReadImportTable(data, format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid file",
+			"message": err.Error(),
+		})
+	}
+
+	result, err := h.koujiService.ImportKoujiEntries(table, c.QueryBool("apply", false))
+	if errors.Is(err, services.ErrImportHasErrors) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(result)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to import kouji entries",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreatePhotoLedger godoc
+// @Summary      工事写真台帳のPDFの作成
+// @Description  工事フォルダー内の写真を1ページに3枚ずつ並べた写真台帳のPDFを作成し、工事フォルダーに版番号付きのファイル名で保存します。
+// @Description  撮影日はEXIFの撮影日時（なければファイルの更新日時）、説明は写真と同じ名前の.txtファイル（IMG_0001.JPG.txt または IMG_0001.txt）から読み取ります。
+// @Tags         工事写真
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.PhotoLedgerRequest true "写真のフォルダーまたはファイル"
+// @Success      201 {object} models.FileEntry "保存したPDF"
+// @Failure      400 {object} map[string]string "不正な内容"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Failure      500 {object} map[string]string "帳票用フォントがない"
+// @Router       /kouji-entries/{id}/photo-ledger [post]
+func (h *KoujiHandler) CreatePhotoLedger(c *fiber.Ctx) error {
+	var req models.PhotoLedgerRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	entry, err := h.koujiService.CreatePhotoLedger(c.Params("id"), req)
+	if err != nil {
+		return photoErrorResponse(c, "Failed to create photo ledger", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// SortKoujiPhotos godoc
+// @Summary      写真の撮影日ごとの整理
+// @Description  フォルダー直下の写真をEXIFの撮影日時で 写真/YYYY-MM-DD/ フォルダーに振り分けます。説明の.txtファイルも一緒に移動します。
+// @Description  apply=false（既定）では移動せずに移動先のプレビューを返します。既存のファイルは上書きせず、名前が重なる場合は _2 などを付けます。
+// @Tags         工事写真
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        apply query bool false "写真を移動する" default(false)
+// @Param        request body models.PhotoSortRequest false "整理するフォルダーと名前の付け方"
+// @Success      200 {object} models.PhotoSortResult "移動先のプレビューまたは移動の結果"
+// @Failure      400 {object} map[string]string "不正な内容"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/photos/sort [post]
+func (h *KoujiHandler) SortKoujiPhotos(c *fiber.Ctx) error {
+	var req models.PhotoSortRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	result, err := h.koujiService.SortKoujiPhotos(c.Params("id"), req, c.QueryBool("apply", false))
+	if err != nil {
+		return photoErrorResponse(c, "Failed to sort photos", err)
+	}
+	return c.JSON(result)
+}
+
+// StartPhotoHashJob godoc
+// @Summary      写真の重複検出の開始
+// @Description  工事フォルダーのルート配下の画像（JPEG・PNG・WebP）の知覚ハッシュをバックグラウンドで計算します。前回から変わっていない画像は計算しません。
+// @Tags         工事写真
+// @Produce      json
+// @Success      202 {object} models.PhotoHashJobStatus "開始した処理の状況"
+// @Failure      409 {object} map[string]string "実行中"
+// @Router       /photo-duplicates/scan [post]
+func (h *KoujiHandler) StartPhotoHashJob(c *fiber.Ctx) error {
+	status, err := h.koujiService.StartPhotoHashJob()
+	if err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   "Photo scan is already running",
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(status)
+}
+
+// GetPhotoHashJobStatus godoc
+// @Summary      写真の重複検出の状況
+// @Description  知覚ハッシュを計算するバックグラウンド処理の進み具合を返します。
+// @Tags         工事写真
+// @Produce      json
+// @Success      200 {object} models.PhotoHashJobStatus "処理の状況"
+// @Router       /photo-duplicates/scan [get]
+func (h *KoujiHandler) GetPhotoHashJobStatus(c *fiber.Ctx) error {
+	return c.JSON(h.koujiService.GetPhotoHashJobStatus())
+}
+
+// GetPhotoDuplicates godoc
+// @Summary      見た目が重複する写真の一覧
+// @Description  最後に計算した知覚ハッシュから、見た目が重複する写真のグループをパス・サイズ・工事IDとともに返します。
+// @Description  グループは削減できるサイズの大きい順に並べ、各グループの先頭は最大の写真です。
+// @Description  グループの写真はすべて先頭の写真との距離が上限以下です。グループは計算の完了時に求めておき、計算後に変更・削除された写真は含めません。
+// @Tags         工事写真
+// @Produce      json
+// @Param        threshold query int false "重複とみなすハッシュの距離の上限（0〜16）" default(5)
+// @Success      200 {object} models.PhotoDuplicatesResponse "重複する写真のグループ"
+// @Failure      400 {object} map[string]string "不正な距離"
+// @Router       /photo-duplicates [get]
+func (h *KoujiHandler) GetPhotoDuplicates(c *fiber.Ctx) error {
+	response, err := h.koujiService.GetPhotoDuplicates(c.QueryInt("threshold", services.DefaultPhotoDuplicateThreshold))
+	if err != nil {
+		return photoErrorResponse(c, "Failed to get photo duplicates", err)
+	}
+	return c.JSON(response)
+}
+
+func photoErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrKoujiNotFound) {
+		status = fiber.StatusNotFound
+	} else if errors.Is(err, services.ErrDocumentFontMissing) {
+		status = fiber.StatusInternalServerError
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDailyReports godoc
+// @Summary      日報一覧の取得
+// @Description  工事の日報を日付の昇順で返します。
+// @Tags         日報
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        from query string false "期間の開始日 (YYYY-MM-DD)"
+// @Param        to query string false "期間の終了日 (YYYY-MM-DD)"
+// @Success      200 {object} models.DailyReportsResponse "日報一覧"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/reports [get]
+func (h *KoujiHandler) GetDailyReports(c *fiber.Ctx) error {
+	koujiId := c.Params("id")
+	reports, err := h.koujiService.GetDailyReports(koujiId, c.Query("from"), c.Query("to"))
+	if err != nil {
+		return dailyReportErrorResponse(c, "Failed to get daily reports", err)
+	}
+
+	var total float64
+	for _, report := range reports {
+		total += report.ManHours
+	}
+	return c.JSON(models.DailyReportsResponse{
+		KoujiId:       koujiId,
+		Reports:       reports,
+		Count:         len(reports),
+		TotalManHours: total,
+	})
+}
+
+// GetDailyReport godoc
+// @Summary      日報の取得
+// @Description  工事の指定した日の日報を返します。
+// @Tags         日報
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        date path string true "日付 (YYYY-MM-DD)"
+// @Success      200 {object} models.DailyReport "日報"
+// @Failure      404 {object} map[string]string "工事または日報がない"
+// @Router       /kouji-entries/{id}/reports/{date} [get]
+func (h *KoujiHandler) GetDailyReport(c *fiber.Ctx) error {
+	report, err := h.koujiService.GetDailyReport(c.Params("id"), c.Params("date"))
+	if err != nil {
+		return dailyReportErrorResponse(c, "Failed to get daily report", err)
+	}
+	return c.JSON(report)
+}
+
+// CreateDailyReport godoc
+// @Summary      日報の作成
+// @Description  工事の日報を作成します。日報は工事フォルダー内の .inside.reports に日付ごとのYAMLファイルとして保存されます。
+// @Description  写真は工事フォルダーからの相対パスで指定します。
+// @Tags         日報
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body models.DailyReport true "日報"
+// @Success      201 {object} models.DailyReport "作成した日報"
+// @Failure      400 {object} map[string]string "不正な日報"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Failure      409 {object} map[string]string "同じ日の日報が既にある"
+// @Router       /kouji-entries/{id}/reports [post]
+func (h *KoujiHandler) CreateDailyReport(c *fiber.Ctx) error {
+	var req models.DailyReport
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	report, err := h.koujiService.CreateDailyReport(c.Params("id"), req)
+	if err != nil {
+		return dailyReportErrorResponse(c, "Failed to create daily report", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// UpdateDailyReport godoc
+// @Summary      日報の更新
+// @Description  工事の指定した日の日報を置き換えます。
+// @Tags         日報
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        date path string true "日付 (YYYY-MM-DD)"
+// @Param        request body models.DailyReport true "日報"
+// @Success      200 {object} models.DailyReport "更新後の日報"
+// @Failure      400 {object} map[string]string "不正な日報"
+// @Failure      404 {object} map[string]string "工事または日報がない"
+// @Router       /kouji-entries/{id}/reports/{date} [put]
+func (h *KoujiHandler) UpdateDailyReport(c *fiber.Ctx) error {
+	var req models.DailyReport
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	report, err := h.koujiService.UpdateDailyReport(c.Params("id"), c.Params("date"), req)
+	if err != nil {
+		return dailyReportErrorResponse(c, "Failed to update daily report", err)
+	}
+	return c.JSON(report)
+}
+
+// GetManHoursSummary godoc
+// @Summary      延べ作業時間の集計
+// @Description  工事の日報から延べ作業時間を作業員ごと・月ごとに集計します。
+// @Tags         日報
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {object} models.ManHoursSummary "延べ作業時間"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/man-hours [get]
+func (h *KoujiHandler) GetManHoursSummary(c *fiber.Ctx) error {
+	summary, err := h.koujiService.GetManHoursSummary(c.Params("id"))
+	if err != nil {
+		return dailyReportErrorResponse(c, "Failed to aggregate man-hours", err)
+	}
+	return c.JSON(summary)
+}
+
+// dailyReportErrorResponse は工事・日報が見つからない場合は404、日報が既にある場合は409、それ以外は400を返す
+func dailyReportErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, services.ErrKoujiNotFound), errors.Is(err, services.ErrDailyReportNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrDailyReportExists):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
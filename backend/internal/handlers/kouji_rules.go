@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiRules godoc
+// @Summary      自動タグ付けルールの取得
+// @Description  新しく見つかった工事に適用される自動タグ付けルールを返します。ルールが未保存の場合は既定のルールを返します。
+// @Tags         工事管理
+// @Produce      json
+// @Success      200 {array} models.KoujiRule "ルール一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-rules [get]
+func (h *KoujiHandler) GetKoujiRules(c *fiber.Ctx) error {
+	rules, err := h.koujiService.GetKoujiRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to read kouji rules",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(rules)
+}
+
+// UpdateKoujiRules godoc
+// @Summary      自動タグ付けルールの更新
+// @Description  ルール一覧を置き換えます。正規表現・日付・フィールド名が不正な場合は保存しません。
+// @Tags         工事管理
+// @Accept       json
+// @Produce      json
+// @Param        request body []models.KoujiRule true "ルール一覧"
+// @Success      200 {array} models.KoujiRule "保存したルール一覧"
+// @Failure      400 {object} map[string]string "不正なルール"
+// @Router       /kouji-rules [put]
+func (h *KoujiHandler) UpdateKoujiRules(c *fiber.Ctx) error {
+	var rules []models.KoujiRule
+	if err := c.BodyParser(&rules); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.koujiService.SaveKoujiRules(rules); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to save kouji rules",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(rules)
+}
+
+// PreviewKoujiRules godoc
+// @Summary      自動タグ付けルールのプレビュー
+// @Description  ルールごとに一致する工事と、付与されるタグ・変更されるフィールドを返します。
+// @Description  リクエストボディにルール一覧を指定すると保存前のルールを評価します（省略時は保存済みのルール）。
+// @Tags         工事管理
+// @Accept       json
+// @Produce      json
+// @Param        request body []models.KoujiRule false "評価するルール一覧"
+// @Success      200 {array} models.KoujiRulePreview "ルールごとの適用対象"
+// @Failure      400 {object} map[string]string "不正なルール"
+// @Router       /kouji-rules/preview [post]
+func (h *KoujiHandler) PreviewKoujiRules(c *fiber.Ctx) error {
+	var rules []models.KoujiRule
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&rules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+		}
+	}
+
+	previews, err := h.koujiService.PreviewKoujiRules(rules)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to preview kouji rules",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(previews)
+}
+
+// ApplyKoujiRules godoc
+// @Summary      自動タグ付けルールの一括適用
+// @Description  保存済みのルールをすべての工事に適用して保存します。タグは追加のみ行い、フィールドはoverwriteが指定されたルールのみ上書きします。
+// @Description  工事一覧の取得時は新しく見つかった工事にのみルールを適用するため、ルールの変更を既存の工事に反映する場合に使用します。
+// @Tags         工事管理
+// @Produce      json
+// @Success      200 {object} models.KoujiRuleApplyResponse "変更した工事の数"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /kouji-rules/apply [post]
+func (h *KoujiHandler) ApplyKoujiRules(c *fiber.Ctx) error {
+	updated, err := h.koujiService.ApplyKoujiRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to apply kouji rules",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.KoujiRuleApplyResponse{Updated: updated})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiSettings godoc
+// @Summary      工事管理の設定の取得
+// @Description  年度の開始月などの設定を返します。
+// @Tags         工事管理
+// @Produce      json
+// @Success      200 {object} models.KoujiSettings "設定"
+// @Router       /kouji-settings [get]
+func (h *KoujiHandler) GetKoujiSettings(c *fiber.Ctx) error {
+	return c.JSON(h.koujiService.GetSettings())
+}
+
+// UpdateKoujiSettings godoc
+// @Summary      工事管理の設定の更新
+// @Description  年度の開始月などの設定を更新します。年度の開始月は年度タグ・統計・エクスポート・絞り込みに反映されます。消費税率の表は適用開始日の昇順に並べ替えて保存します。
+// @Tags         工事管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.KoujiSettings true "設定"
+// @Success      200 {object} models.KoujiSettings "更新後の設定"
+// @Failure      400 {object} map[string]string "不正な設定"
+// @Router       /kouji-settings [put]
+func (h *KoujiHandler) UpdateKoujiSettings(c *fiber.Ctx) error {
+	var req models.KoujiSettings
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.koujiService.SaveSettings(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to save settings",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(h.koujiService.GetSettings())
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetKoujiStats godoc
+// @Summary      工事統計の取得
+// @Description  工事一覧を集計し、年・年度・会社・状態ごとの件数、工期の平均と中央値、
+// @Description  進行中の工事、会社ごとのディスク使用量を返します。
+// @Tags         工事管理
+// @Produce      json
+// @Param        company query string false "会社名（部分一致）"
+// @Param        fiscal_year query int false "年度"
+// @Param        from query string false "開始日の下限 (例: 2024-04-01)"
+// @Param        to query string false "開始日の上限 (例: 2025-03-31)"
+// @Param        q query string false "検索クエリ"
+// @Success      200 {object} models.KoujiStats "工事統計"
+// @Failure      400 {object} map[string]any "不正な絞り込み条件"
+// @Router       /kouji-stats [get]
+func (h *KoujiHandler) GetKoujiStats(c *fiber.Ctx) error {
+	filter, err := parseKoujiFilter(c)
+	if err != nil {
+		return queryErrorResponse(c, err)
+	}
+
+	return c.JSON(h.koujiService.GetKoujiStats(filter))
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTimesheets godoc
+// @Summary      工数表の取得
+// @Description  作業員の工事ごと・日ごとの作業時間を日付順に返します。
+// @Tags         工数・労務費
+// @Produce      json
+// @Param        kouji_id query string false "工事ID"
+// @Param        worker query string false "作業員名"
+// @Param        from query string false "期間の開始日 (YYYY-MM-DD)"
+// @Param        to query string false "期間の終了日 (YYYY-MM-DD)"
+// @Success      200 {object} models.TimesheetListResponse "工数表"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /timesheets [get]
+func (h *KoujiHandler) GetTimesheets(c *fiber.Ctx) error {
+	rows, err := h.koujiService.GetTimesheets(services.TimesheetQuery{
+		KoujiId:    c.Query("kouji_id"),
+		WorkerName: c.Query("worker"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load timesheets",
+			"message": err.Error(),
+		})
+	}
+
+	var total float64
+	for _, row := range rows {
+		total += row.Hours
+	}
+	return c.JSON(models.TimesheetListResponse{
+		Entries:    rows,
+		Count:      len(rows),
+		TotalHours: total,
+	})
+}
+
+// SaveTimesheetEntry godoc
+// @Summary      工数表の登録
+// @Description  作業員の工事ごと・日ごとの作業時間を登録します。同じ工事・日付・作業員の行があれば置き換えます。
+// @Description  行には作業員名簿の現在の時間単価を記録し、労務費はその単価で計算します（後から名簿の単価を変更しても過去の労務費は変わりません）。
+// @Description  工事が編集できない状態（設定の timesheet_locked_statuses、既定は完了）の場合は409を返します。
+// @Tags         工数・労務費
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TimesheetEntry true "工数表の行"
+// @Success      200 {object} models.TimesheetEntry "登録した行"
+// @Failure      400 {object} map[string]string "不正な行"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Failure      409 {object} map[string]string "工事が編集できない状態"
+// @Router       /timesheets [post]
+func (h *KoujiHandler) SaveTimesheetEntry(c *fiber.Ctx) error {
+	var req models.TimesheetEntry
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	entry, err := h.koujiService.SaveTimesheetEntry(req)
+	if err != nil {
+		return timesheetErrorResponse(c, "Failed to save timesheet", err)
+	}
+	return c.JSON(entry)
+}
+
+// DeleteTimesheetEntry godoc
+// @Summary      工数表の行の削除
+// @Description  工数表の行を削除します。工事が編集できない状態の場合は409を返します。
+// @Tags         工数・労務費
+// @Param        id path string true "行のID"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "行がない"
+// @Failure      409 {object} map[string]string "工事が編集できない状態"
+// @Router       /timesheets/{id} [delete]
+func (h *KoujiHandler) DeleteTimesheetEntry(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteTimesheetEntry(c.Params("id")); err != nil {
+		return timesheetErrorResponse(c, "Failed to delete timesheet", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetLabourCostSummary godoc
+// @Summary      工事の労務費の集計
+// @Description  工数表の作業時間と、行の登録時に記録した時間単価から工事の労務費を作業員ごと・月ごとに集計し、予算のカスタムフィールド（設定の labour_budget_field）と比較します。
+// @Description  作業時間は工数表を正とします。日報の作業時間とは照合のみ行い、異なる日・作業員を hours_discrepancies に返します。
+// @Tags         工数・労務費
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {object} models.LabourCostSummary "労務費"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/labour-cost [get]
+func (h *KoujiHandler) GetLabourCostSummary(c *fiber.Ctx) error {
+	summary, err := h.koujiService.GetLabourCostSummary(c.Params("id"))
+	if err != nil {
+		return timesheetErrorResponse(c, "Failed to aggregate labour cost", err)
+	}
+	return c.JSON(summary)
+}
+
+// GetMonthlyLabourCosts godoc
+// @Summary      月ごとの労務費の集計
+// @Description  全工事の労務費を月ごとに集計します。
+// @Tags         工数・労務費
+// @Produce      json
+// @Param        from query string false "開始月 (YYYY-MM)"
+// @Param        to query string false "終了月 (YYYY-MM)"
+// @Success      200 {array} models.MonthlyLabourCost "月ごとの労務費"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /labour-cost/monthly [get]
+func (h *KoujiHandler) GetMonthlyLabourCosts(c *fiber.Ctx) error {
+	months, err := h.koujiService.GetMonthlyLabourCosts(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to aggregate labour cost",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(months)
+}
+
+// timesheetErrorResponse は工事・行が見つからない場合は404、編集できない場合は409、それ以外は400を返す
+func timesheetErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, services.ErrKoujiNotFound), errors.Is(err, services.ErrTimesheetNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrTimesheetLocked):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
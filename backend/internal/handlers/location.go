@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultPredictionMonths は次回工事の予測一覧の既定の期間（月数）
+const defaultPredictionMonths = 6
+
+// LocationHandler 現場のHTTPリクエストを処理するハンドラー
+type LocationHandler struct {
+	koujiService *services.KoujiService
+}
+
+// NewLocationHandler 新しいLocationHandlerインスタンスを作成します
+func NewLocationHandler(koujiService *services.KoujiService) *LocationHandler {
+	return &LocationHandler{
+		koujiService: koujiService,
+	}
+}
+
+// GetLocations godoc
+// @Summary      現場一覧の取得
+// @Description  登録されている現場の一覧を返します。
+// @Tags         現場管理
+// @Produce      json
+// @Success      200 {array} models.Location "現場一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /locations [get]
+func (h *LocationHandler) GetLocations(c *fiber.Ctx) error {
+	locations, err := h.koujiService.GetLocations()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load locations",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(locations)
+}
+
+// GetLocation godoc
+// @Summary      現場の取得
+// @Description  会社名と現場名で指定した現場を返します。
+// @Tags         現場管理
+// @Produce      json
+// @Param        company path string true "会社名"
+// @Param        location path string true "現場名"
+// @Success      200 {object} models.Location "現場"
+// @Failure      404 {object} map[string]string "現場が登録されていない"
+// @Router       /locations/{company}/{location} [get]
+func (h *LocationHandler) GetLocation(c *fiber.Ctx) error {
+	location, err := h.koujiService.GetLocation(pathParam(c, "company"), pathParam(c, "location"))
+	if err != nil {
+		return locationErrorResponse(c, "Failed to get location", err)
+	}
+	return c.JSON(location)
+}
+
+// CreateLocation godoc
+// @Summary      現場の登録
+// @Description  会社名と現場名の組で現場を登録します。
+// @Tags         現場管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.Location true "現場"
+// @Success      201 {object} models.Location "登録した現場"
+// @Failure      400 {object} map[string]string "不正な現場情報"
+// @Router       /locations [post]
+func (h *LocationHandler) CreateLocation(c *fiber.Ctx) error {
+	var req models.Location
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	location, err := h.koujiService.CreateLocation(req)
+	if err != nil {
+		return locationErrorResponse(c, "Failed to create location", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(location)
+}
+
+// UpdateLocation godoc
+// @Summary      現場の更新
+// @Description  会社名と現場名で指定した現場の情報を置き換えます。
+// @Tags         現場管理
+// @Accept       json
+// @Produce      json
+// @Param        company path string true "会社名"
+// @Param        location path string true "現場名"
+// @Param        request body models.Location true "現場"
+// @Success      200 {object} models.Location "更新後の現場"
+// @Failure      400 {object} map[string]string "不正な現場情報"
+// @Failure      404 {object} map[string]string "現場が登録されていない"
+// @Router       /locations/{company}/{location} [put]
+func (h *LocationHandler) UpdateLocation(c *fiber.Ctx) error {
+	var req models.Location
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	location, err := h.koujiService.UpdateLocation(pathParam(c, "company"), pathParam(c, "location"), req)
+	if err != nil {
+		return locationErrorResponse(c, "Failed to update location", err)
+	}
+	return c.JSON(location)
+}
+
+// DeleteLocation godoc
+// @Summary      現場の削除
+// @Description  会社名と現場名で指定した現場を削除します。工事のフォルダーは変更しません。
+// @Tags         現場管理
+// @Param        company path string true "会社名"
+// @Param        location path string true "現場名"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "現場が登録されていない"
+// @Router       /locations/{company}/{location} [delete]
+func (h *LocationHandler) DeleteLocation(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteLocation(pathParam(c, "company"), pathParam(c, "location")); err != nil {
+		return locationErrorResponse(c, "Failed to delete location", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetLocationHistory godoc
+// @Summary      現場の工事履歴の取得
+// @Description  現場で行った工事と予定している工事を開始日の昇順で返します。
+// @Description  前回の工事からの間隔と、最後に完了した工事からの経過日数を含みます。
+// @Tags         現場管理
+// @Produce      json
+// @Param        company path string true "会社名"
+// @Param        location path string true "現場名"
+// @Success      200 {object} models.LocationHistory "工事履歴"
+// @Failure      404 {object} map[string]string "現場が登録されておらず工事もない"
+// @Router       /locations/{company}/{location}/history [get]
+func (h *LocationHandler) GetLocationHistory(c *fiber.Ctx) error {
+	history, err := h.koujiService.GetLocationHistory(pathParam(c, "company"), pathParam(c, "location"))
+	if err != nil {
+		return locationErrorResponse(c, "Failed to get location history", err)
+	}
+	return c.JSON(history)
+}
+
+// GetMaintenancePredictions godoc
+// @Summary      次回工事の予測一覧の取得
+// @Description  現場ごとの過去の工事の間隔から次回工事の開始日を予測し、予測日が指定した月数以内の現場を返します。
+// @Description  予測日を過ぎても次回工事が予定されていない現場も含みます。次回工事が予定済みの現場は含みません。
+// @Tags         現場管理
+// @Produce      json
+// @Param        months query int false "今日から何か月以内の予測を返すか" default(6)
+// @Success      200 {object} models.MaintenancePredictionsResponse "次回工事の予測一覧"
+// @Failure      400 {object} map[string]string "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /locations/predictions [get]
+func (h *LocationHandler) GetMaintenancePredictions(c *fiber.Ctx) error {
+	months := c.QueryInt("months", defaultPredictionMonths)
+	if months < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid months",
+			"message": "months must not be negative",
+		})
+	}
+
+	predictions, err := h.koujiService.GetMaintenancePredictions(months)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to predict next jobs",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.MaintenancePredictionsResponse{
+		Months:      months,
+		Predictions: predictions,
+		Count:       len(predictions),
+	})
+}
+
+// locationErrorResponse は現場が見つからない場合は404、それ以外は400を返す
+func locationErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrLocationNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
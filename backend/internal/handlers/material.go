@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaterialHandler 資材・在庫のHTTPリクエストを処理するハンドラー
+type MaterialHandler struct {
+	koujiService *services.KoujiService
+}
+
+// NewMaterialHandler 新しいMaterialHandlerインスタンスを作成します
+func NewMaterialHandler(koujiService *services.KoujiService) *MaterialHandler {
+	return &MaterialHandler{
+		koujiService: koujiService,
+	}
+}
+
+// GetMaterials godoc
+// @Summary      資材マスターの取得
+// @Description  登録されている資材（れんが・キャスタブル・モルタルなど）の一覧を返します。
+// @Tags         資材管理
+// @Produce      json
+// @Success      200 {array} models.Material "資材一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /materials [get]
+func (h *MaterialHandler) GetMaterials(c *fiber.Ctx) error {
+	materials, err := h.koujiService.GetMaterials()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load materials",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(materials)
+}
+
+// GetMaterial godoc
+// @Summary      資材の取得
+// @Description  資材コードで指定した資材を返します。
+// @Tags         資材管理
+// @Produce      json
+// @Param        code path string true "資材コード"
+// @Success      200 {object} models.Material "資材"
+// @Failure      404 {object} map[string]string "資材が登録されていない"
+// @Router       /materials/{code} [get]
+func (h *MaterialHandler) GetMaterial(c *fiber.Ctx) error {
+	material, err := h.koujiService.GetMaterial(pathParam(c, "code"))
+	if err != nil {
+		return materialErrorResponse(c, "Failed to get material", err)
+	}
+	return c.JSON(material)
+}
+
+// CreateMaterial godoc
+// @Summary      資材の登録
+// @Description  資材マスターに資材を登録します。
+// @Tags         資材管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.Material true "資材"
+// @Success      201 {object} models.Material "登録した資材"
+// @Failure      400 {object} map[string]string "不正な資材"
+// @Router       /materials [post]
+func (h *MaterialHandler) CreateMaterial(c *fiber.Ctx) error {
+	var req models.Material
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	material, err := h.koujiService.CreateMaterial(req)
+	if err != nil {
+		return materialErrorResponse(c, "Failed to create material", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(material)
+}
+
+// UpdateMaterial godoc
+// @Summary      資材の更新
+// @Description  資材コードで指定した資材の情報を置き換えます。資材コードは変更できません。
+// @Tags         資材管理
+// @Accept       json
+// @Produce      json
+// @Param        code path string true "資材コード"
+// @Param        request body models.Material true "資材"
+// @Success      200 {object} models.Material "更新後の資材"
+// @Failure      400 {object} map[string]string "不正な資材"
+// @Failure      404 {object} map[string]string "資材が登録されていない"
+// @Router       /materials/{code} [put]
+func (h *MaterialHandler) UpdateMaterial(c *fiber.Ctx) error {
+	var req models.Material
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	material, err := h.koujiService.UpdateMaterial(pathParam(c, "code"), req)
+	if err != nil {
+		return materialErrorResponse(c, "Failed to update material", err)
+	}
+	return c.JSON(material)
+}
+
+// DeleteMaterial godoc
+// @Summary      資材の削除
+// @Description  資材コードで指定した資材を削除します。入出庫がある資材は削除できません。
+// @Tags         資材管理
+// @Param        code path string true "資材コード"
+// @Success      204 "削除しました"
+// @Failure      400 {object} map[string]string "入出庫がある"
+// @Failure      404 {object} map[string]string "資材が登録されていない"
+// @Router       /materials/{code} [delete]
+func (h *MaterialHandler) DeleteMaterial(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteMaterial(pathParam(c, "code")); err != nil {
+		return materialErrorResponse(c, "Failed to delete material", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetKoujiMaterials godoc
+// @Summary      工事の資材の予定と実績
+// @Description  工事の資材ごとの予定数量と実績（工事への出庫 - 工事からの返却）を返します。
+// @Tags         資材管理
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {array} models.KoujiMaterialUsage "予定と実績"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/materials [get]
+func (h *MaterialHandler) GetKoujiMaterials(c *fiber.Ctx) error {
+	usages, err := h.koujiService.GetKoujiMaterialUsage(c.Params("id"))
+	if err != nil {
+		return materialErrorResponse(c, "Failed to get kouji materials", err)
+	}
+	return c.JSON(usages)
+}
+
+// UpdateKoujiMaterials godoc
+// @Summary      工事の資材の予定数量の更新
+// @Description  工事の資材の予定数量を置き換えます。
+// @Tags         資材管理
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Param        request body []models.KoujiMaterial true "予定数量"
+// @Success      200 {array} models.KoujiMaterialUsage "更新後の予定と実績"
+// @Failure      400 {object} map[string]string "不正な予定数量"
+// @Failure      404 {object} map[string]string "工事または資材がない"
+// @Router       /kouji-entries/{id}/materials [put]
+func (h *MaterialHandler) UpdateKoujiMaterials(c *fiber.Ctx) error {
+	var req []models.KoujiMaterial
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	usages, err := h.koujiService.UpdateKoujiMaterials(c.Params("id"), req)
+	if err != nil {
+		return materialErrorResponse(c, "Failed to update kouji materials", err)
+	}
+	return c.JSON(usages)
+}
+
+// GetStockLevels godoc
+// @Summary      在庫数の取得
+// @Description  資材ごとの現在の在庫数を入出庫から計算して返します。
+// @Tags         資材管理
+// @Produce      json
+// @Success      200 {array} models.StockLevel "在庫数"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /stock [get]
+func (h *MaterialHandler) GetStockLevels(c *fiber.Ctx) error {
+	levels, err := h.koujiService.GetStockLevels()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load stock",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(levels)
+}
+
+// GetStockMovements godoc
+// @Summary      入出庫一覧の取得
+// @Description  倉庫の入出庫を日付順に返します。
+// @Tags         資材管理
+// @Produce      json
+// @Param        code query string false "資材コード"
+// @Param        kouji_id query string false "工事ID"
+// @Param        from query string false "期間の開始日 (YYYY-MM-DD)"
+// @Param        to query string false "期間の終了日 (YYYY-MM-DD)"
+// @Success      200 {array} models.StockMovement "入出庫一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /stock/movements [get]
+func (h *MaterialHandler) GetStockMovements(c *fiber.Ctx) error {
+	movements, err := h.koujiService.GetStockMovements(services.StockMovementQuery{
+		Code:    c.Query("code"),
+		KoujiId: c.Query("kouji_id"),
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load stock movements",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(movements)
+}
+
+// CreateStockMovement godoc
+// @Summary      入出庫の登録
+// @Description  倉庫の入庫・工事への出庫・工事からの返却・棚卸調整を登録します。在庫がマイナスになる場合は warnings に警告を返します。
+// @Tags         資材管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.StockMovement true "入出庫"
+// @Success      201 {object} models.StockMovementResponse "登録した入出庫と警告"
+// @Failure      400 {object} map[string]string "不正な入出庫"
+// @Failure      404 {object} map[string]string "資材または工事がない"
+// @Router       /stock/movements [post]
+func (h *MaterialHandler) CreateStockMovement(c *fiber.Ctx) error {
+	var req models.StockMovement
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	movement, warnings, err := h.koujiService.AddStockMovement(req)
+	if err != nil {
+		return materialErrorResponse(c, "Failed to add stock movement", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(models.StockMovementResponse{
+		Movement: movement,
+		Warnings: warnings,
+	})
+}
+
+// DeleteStockMovement godoc
+// @Summary      入出庫の削除
+// @Description  入出庫を削除します。
+// @Tags         資材管理
+// @Param        id path string true "入出庫のID"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "入出庫がない"
+// @Router       /stock/movements/{id} [delete]
+func (h *MaterialHandler) DeleteStockMovement(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteStockMovement(c.Params("id")); err != nil {
+		return materialErrorResponse(c, "Failed to delete stock movement", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetMaterialConsumption godoc
+// @Summary      資材の消費量の集計
+// @Description  期間中の資材の消費量（出庫 - 返却）と金額を工事ごとまたは月ごとに集計します。
+// @Tags         資材管理
+// @Produce      json
+// @Param        group query string false "集計単位" Enums(kouji, month) default(kouji)
+// @Param        from query string false "期間の開始日 (YYYY-MM-DD)"
+// @Param        to query string false "期間の終了日 (YYYY-MM-DD)"
+// @Success      200 {array} models.MaterialConsumption "消費量"
+// @Failure      400 {object} map[string]string "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /materials/consumption [get]
+func (h *MaterialHandler) GetMaterialConsumption(c *fiber.Ctx) error {
+	group := c.Query("group", "kouji")
+	if group != "kouji" && group != "month" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid group",
+			"message": fmt.Sprintf("未対応の集計単位です: %s", group),
+		})
+	}
+
+	rows, err := h.koujiService.GetMaterialConsumption(c.Query("from"), c.Query("to"), group == "month")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to aggregate material consumption",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(rows)
+}
+
+// materialErrorResponse は資材・入出庫・工事が見つからない場合は404、それ以外は400を返す
+func materialErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrMaterialNotFound) || errors.Is(err, services.ErrStockMovementNotFound) ||
+		errors.Is(err, services.ErrKoujiNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TagHandler タグ管理のHTTPリクエストを処理するハンドラー
+type TagHandler struct {
+	koujiService *services.KoujiService
+}
+
+// NewTagHandler 新しいTagHandlerインスタンスを作成します
+func NewTagHandler(koujiService *services.KoujiService) *TagHandler {
+	return &TagHandler{
+		koujiService: koujiService,
+	}
+}
+
+// GetTags godoc
+// @Summary      タグ一覧の取得
+// @Description  すべての工事で使われているタグと使用数を返します。
+// @Description  空白や全角・半角の違いのみで異なるタグは統合候補として similar_groups に含まれます。
+// @Tags         タグ管理
+// @Produce      json
+// @Success      200 {object} models.TagListResponse "タグ一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /tags [get]
+func (h *TagHandler) GetTags(c *fiber.Ctx) error {
+	tags, err := h.koujiService.ListTags()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to list tags",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(tags)
+}
+
+// RenameTag godoc
+// @Summary      タグ名の変更
+// @Description  すべての工事のタグ名を変更します。管理語彙に含まれる場合は管理語彙も更新します。
+// @Tags         タグ管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RenameTagRequest true "変更前後のタグ"
+// @Success      200 {object} models.TagUpdateResponse "変更した工事の数"
+// @Failure      400 {object} map[string]string "不正なリクエスト"
+// @Router       /tags/rename [post]
+func (h *TagHandler) RenameTag(c *fiber.Ctx) error {
+	var req models.RenameTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	updated, err := h.koujiService.RenameTag(req.From, req.To)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to rename tag",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.TagUpdateResponse{Updated: updated})
+}
+
+// MergeTags godoc
+// @Summary      タグの統合
+// @Description  複数のタグを1つのタグに統合します。
+// @Tags         タグ管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.MergeTagsRequest true "統合元と統合先のタグ"
+// @Success      200 {object} models.TagUpdateResponse "変更した工事の数"
+// @Failure      400 {object} map[string]string "不正なリクエスト"
+// @Router       /tags/merge [post]
+func (h *TagHandler) MergeTags(c *fiber.Ctx) error {
+	var req models.MergeTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	updated, err := h.koujiService.MergeTags(req.Sources, req.Target)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Failed to merge tags",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.TagUpdateResponse{Updated: updated})
+}
+
+// GetTagVocabulary godoc
+// @Summary      管理語彙の取得
+// @Tags         タグ管理
+// @Produce      json
+// @Success      200 {object} models.TagVocabulary "管理語彙"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /tags/vocabulary [get]
+func (h *TagHandler) GetTagVocabulary(c *fiber.Ctx) error {
+	vocabulary, err := h.koujiService.GetTagVocabulary()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to read tag vocabulary",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(vocabulary)
+}
+
+// UpdateTagVocabulary godoc
+// @Summary      管理語彙の更新
+// @Description  管理語彙を置き換えます。restricted=true の場合、管理語彙にない新しいタグの保存を拒否します。
+// @Tags         タグ管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TagVocabulary true "管理語彙"
+// @Success      200 {object} models.TagVocabulary "更新後の管理語彙"
+// @Failure      400 {object} map[string]string "不正なリクエスト"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /tags/vocabulary [put]
+func (h *TagHandler) UpdateTagVocabulary(c *fiber.Ctx) error {
+	var req models.TagVocabulary
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	vocabulary, err := h.koujiService.SaveTagVocabulary(req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to save tag vocabulary",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(vocabulary)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultExpiryDays は期限が近い資格・安全書類一覧の既定の日数
+const defaultExpiryDays = 60
+
+// WorkerHandler 作業員のHTTPリクエストを処理するハンドラー
+type WorkerHandler struct {
+	koujiService *services.KoujiService
+}
+
+// NewWorkerHandler 新しいWorkerHandlerインスタンスを作成します
+func NewWorkerHandler(koujiService *services.KoujiService) *WorkerHandler {
+	return &WorkerHandler{
+		koujiService: koujiService,
+	}
+}
+
+// GetWorkers godoc
+// @Summary      作業員一覧の取得
+// @Description  登録されている作業員と資格・安全書類の一覧を返します。
+// @Tags         作業員管理
+// @Produce      json
+// @Success      200 {array} models.Worker "作業員一覧"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /workers [get]
+func (h *WorkerHandler) GetWorkers(c *fiber.Ctx) error {
+	workers, err := h.koujiService.GetWorkers()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to load workers",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(workers)
+}
+
+// GetWorker godoc
+// @Summary      作業員の取得
+// @Description  名前で指定した作業員を返します。
+// @Tags         作業員管理
+// @Produce      json
+// @Param        name path string true "作業員名"
+// @Success      200 {object} models.Worker "作業員"
+// @Failure      404 {object} map[string]string "作業員が登録されていない"
+// @Router       /workers/{name} [get]
+func (h *WorkerHandler) GetWorker(c *fiber.Ctx) error {
+	worker, err := h.koujiService.GetWorker(pathParam(c, "name"))
+	if err != nil {
+		return workerErrorResponse(c, "Failed to get worker", err)
+	}
+	return c.JSON(worker)
+}
+
+// CreateWorker godoc
+// @Summary      作業員の登録
+// @Description  作業員と資格・安全書類を登録します。
+// @Tags         作業員管理
+// @Accept       json
+// @Produce      json
+// @Param        request body models.Worker true "作業員"
+// @Success      201 {object} models.Worker "登録した作業員"
+// @Failure      400 {object} map[string]string "不正な作業員情報"
+// @Router       /workers [post]
+func (h *WorkerHandler) CreateWorker(c *fiber.Ctx) error {
+	var req models.Worker
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	worker, err := h.koujiService.CreateWorker(req)
+	if err != nil {
+		return workerErrorResponse(c, "Failed to create worker", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(worker)
+}
+
+// UpdateWorker godoc
+// @Summary      作業員の更新
+// @Description  名前で指定した作業員の情報を置き換えます。
+// @Tags         作業員管理
+// @Accept       json
+// @Produce      json
+// @Param        name path string true "作業員名"
+// @Param        request body models.Worker true "作業員"
+// @Success      200 {object} models.Worker "更新後の作業員"
+// @Failure      400 {object} map[string]string "不正な作業員情報"
+// @Failure      404 {object} map[string]string "作業員が登録されていない"
+// @Router       /workers/{name} [put]
+func (h *WorkerHandler) UpdateWorker(c *fiber.Ctx) error {
+	var req models.Worker
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+	}
+
+	worker, err := h.koujiService.UpdateWorker(pathParam(c, "name"), req)
+	if err != nil {
+		return workerErrorResponse(c, "Failed to update worker", err)
+	}
+	return c.JSON(worker)
+}
+
+// DeleteWorker godoc
+// @Summary      作業員の削除
+// @Description  名前で指定した作業員を削除します。工事の割り当ては変更しません。
+// @Tags         作業員管理
+// @Param        name path string true "作業員名"
+// @Success      204 "削除しました"
+// @Failure      404 {object} map[string]string "作業員が登録されていない"
+// @Router       /workers/{name} [delete]
+func (h *WorkerHandler) DeleteWorker(c *fiber.Ctx) error {
+	if err := h.koujiService.DeleteWorker(pathParam(c, "name")); err != nil {
+		return workerErrorResponse(c, "Failed to delete worker", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetQualificationExpiries godoc
+// @Summary      期限が近い資格・安全書類の一覧
+// @Description  有効期限が指定した日数以内の資格・安全書類を期限の昇順で返します。期限切れのものも含みます。
+// @Tags         作業員管理
+// @Produce      json
+// @Param        days query int false "今日から何日以内の期限を返すか" default(60)
+// @Success      200 {object} models.QualificationExpiriesResponse "期限が近い資格・安全書類"
+// @Failure      400 {object} map[string]string "不正なパラメータ"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /workers/expiries [get]
+func (h *WorkerHandler) GetQualificationExpiries(c *fiber.Ctx) error {
+	days := c.QueryInt("days", defaultExpiryDays)
+	if days < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid days",
+			"message": "days must not be negative",
+		})
+	}
+
+	expiries, err := h.koujiService.GetQualificationExpiries(days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to list expiries",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(models.QualificationExpiriesResponse{
+		Days:     days,
+		Expiries: expiries,
+		Count:    len(expiries),
+	})
+}
+
+// CheckKoujiQualifications godoc
+// @Summary      割り当てた作業員の資格・安全書類の確認
+// @Description  工事に割り当てた作業員の資格・安全書類のうち、割り当ての終了日（省略時は工事の終了日）より前に期限が切れるものを返します。
+// @Description  作業員として登録されていない割り当ても含みます。
+// @Tags         作業員管理
+// @Produce      json
+// @Param        id path string true "工事ID"
+// @Success      200 {object} models.QualificationIssuesResponse "確認結果"
+// @Failure      404 {object} map[string]string "工事がない"
+// @Router       /kouji-entries/{id}/qualification-check [get]
+func (h *WorkerHandler) CheckKoujiQualifications(c *fiber.Ctx) error {
+	return h.qualificationIssuesResponse(c, c.Params("id"))
+}
+
+// CheckAllQualifications godoc
+// @Summary      すべての工事の資格・安全書類の確認
+// @Description  完了していないすべての工事について、割り当てた作業員の資格・安全書類を確認します。
+// @Tags         作業員管理
+// @Produce      json
+// @Success      200 {object} models.QualificationIssuesResponse "確認結果"
+// @Failure      500 {object} map[string]string "サーバーエラー"
+// @Router       /workers/qualification-check [get]
+func (h *WorkerHandler) CheckAllQualifications(c *fiber.Ctx) error {
+	return h.qualificationIssuesResponse(c, "")
+}
+
+func (h *WorkerHandler) qualificationIssuesResponse(c *fiber.Ctx, koujiId string) error {
+	issues, err := h.koujiService.CheckKoujiQualifications(koujiId)
+	if err != nil {
+		return workerErrorResponse(c, "Failed to check qualifications", err)
+	}
+	return c.JSON(models.QualificationIssuesResponse{
+		Issues: issues,
+		Count:  len(issues),
+	})
+}
+
+// workerErrorResponse は作業員または工事が見つからない場合は404、それ以外は400を返す
+func workerErrorResponse(c *fiber.Ctx, message string, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, services.ErrWorkerNotFound) || errors.Is(err, services.ErrKoujiNotFound) {
+		status = fiber.StatusNotFound
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
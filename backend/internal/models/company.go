@@ -0,0 +1,33 @@
+package models
+
+// Company は取引先会社のマスターデータを表す
+// @Description Client company master data
+type Company struct {
+	// 正式な会社名（工事一覧の会社名はこの名前に統一される）
+	Name string `json:"name" yaml:"name" example:"豊田築炉"`
+	// フォルダー名で使われる別名（例: 豊田築炉(株), トヨタ築炉）
+	Aliases []string `json:"aliases,omitempty" yaml:"aliases,omitempty" example:"['トヨタ築炉']"`
+	// 会社名の読み（カナ）
+	Kana    string `json:"kana,omitempty" yaml:"kana,omitempty" example:"トヨタチクロ"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty" example:"愛知県名古屋市"`
+	// 担当者の連絡先
+	Contacts []CompanyContact `json:"contacts,omitempty" yaml:"contacts,omitempty"`
+	Notes    string           `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// CompanyContact は取引先の担当者を表す
+// @Description Contact person at a client company
+type CompanyContact struct {
+	Name  string `json:"name" yaml:"name" example:"山田 太郎"`
+	Role  string `json:"role,omitempty" yaml:"role,omitempty" example:"工務課長"`
+	Phone string `json:"phone,omitempty" yaml:"phone,omitempty" example:"052-000-0000"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty" example:"yamada@example.com"`
+}
+
+// CompanyKoujiEntriesResponse は会社ごとの工事一覧のレスポンスを表す
+// @Description Client company with its kouji entries
+type CompanyKoujiEntriesResponse struct {
+	Company      Company      `json:"company"`
+	KoujiEntries []KoujiEntry `json:"kouji_entries"`
+	Count        int          `json:"count" example:"10"`
+}
@@ -0,0 +1,62 @@
+package models
+
+// カスタムフィールドの型
+const (
+	CustomFieldTypeString  = "string"
+	CustomFieldTypeNumber  = "number"
+	CustomFieldTypeInteger = "integer"
+	CustomFieldTypeDate    = "date"
+	CustomFieldTypeBool    = "bool"
+	CustomFieldTypeEnum    = "enum"
+)
+
+// CustomFieldDefinition は工事のカスタムフィールドの定義を表す
+// @Description Definition of a custom field stored with each kouji entry
+type CustomFieldDefinition struct {
+	// フィールドのキー（custom_fields のキー）
+	Key string `json:"key" yaml:"key" example:"受注金額"`
+	// 表示名（エクスポートの列名）。省略時はキー
+	Label string `json:"label,omitempty" yaml:"label,omitempty" example:"受注金額（税抜）"`
+	// 型（string, number, integer, date, bool, enum）
+	Type string `json:"type" yaml:"type" example:"integer"`
+	// 必須かどうか
+	Required bool `json:"required,omitempty" yaml:"required,omitempty" example:"false"`
+	// enum型の選択肢
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty" example:"['溶解炉', '加熱炉', '焼鈍炉']"`
+	// 数値の最小値、または文字列の最小文字数
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty" example:"0"`
+	// 数値の最大値、または文字列の最大文字数
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	// 文字列の正規表現
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" example:"^[A-Z]{2}-\\d{4}$"`
+	// 対象とする会社名（空の場合はすべての会社）
+	Companies []string `json:"companies,omitempty" yaml:"companies,omitempty" example:"['豊田築炉']"`
+}
+
+// DisplayLabel は表示名を返す（未設定の場合はキー）
+func (d CustomFieldDefinition) DisplayLabel() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.Key
+}
+
+// AppliesTo はフィールドが会社に適用されるかを判定する
+func (d CustomFieldDefinition) AppliesTo(companyName string) bool {
+	if len(d.Companies) == 0 {
+		return true
+	}
+	for _, company := range d.Companies {
+		if company == companyName {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomFieldsUpdateRequest はカスタムフィールド更新のリクエストを表す
+// @Description Request body for updating custom fields of a kouji entry
+type CustomFieldsUpdateRequest struct {
+	// 設定する値（nullを指定したキーは削除）
+	CustomFields map[string]any `json:"custom_fields"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// DailyReport は工事の日報を表す
+// @Description Daily site report of a kouji
+type DailyReport struct {
+	// 日付（YYYY-MM-DD、1日1件）
+	Date    string `json:"date" yaml:"date" example:"2024-06-18"`
+	Weather string `json:"weather,omitempty" yaml:"weather,omitempty" example:"晴れ"`
+	// 出勤した作業員と作業時間
+	Workers []DailyReportWorker `json:"workers,omitempty" yaml:"workers,omitempty"`
+	// 作業内容
+	WorkDone string `json:"work_done,omitempty" yaml:"work_done,omitempty" example:"炉壁の解体"`
+	// 問題点・連絡事項
+	Issues string `json:"issues,omitempty" yaml:"issues,omitempty" example:"耐火物の搬入が1日遅れ"`
+	// 添付写真（工事フォルダーからの相対パス）
+	Photos []string `json:"photos,omitempty" yaml:"photos,omitempty" example:"['写真/0618_01.jpg']"`
+	// 延べ作業時間（作業員の作業時間の合計、保存はしない）
+	ManHours  float64   `json:"man_hours" yaml:"-" example:"32"`
+	UpdatedAt Timestamp `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+}
+
+// DailyReportWorker は日報の作業員ごとの作業時間を表す
+// @Description Worker present on the day and hours worked
+type DailyReportWorker struct {
+	Name  string  `json:"name" yaml:"name" example:"山田 太郎"`
+	Hours float64 `json:"hours" yaml:"hours" example:"8"`
+}
+
+// DailyReportsResponse は日報一覧のレスポンスを表す
+// @Description Daily reports of a kouji in date order
+type DailyReportsResponse struct {
+	KoujiId       string        `json:"kouji_id" example:"ABCDE"`
+	Reports       []DailyReport `json:"reports"`
+	Count         int           `json:"count" example:"12"`
+	TotalManHours float64       `json:"total_man_hours" example:"384"`
+}
+
+// ManHoursSummary は工事の日報から集計した延べ作業時間を表す
+// @Description Man-hours aggregated from the daily reports of a kouji
+type ManHoursSummary struct {
+	KoujiId       string  `json:"kouji_id" example:"ABCDE"`
+	ReportCount   int     `json:"report_count" example:"12"`
+	TotalManHours float64 `json:"total_man_hours" example:"384"`
+	// 作業員ごとの作業時間
+	ByWorker map[string]float64 `json:"by_worker"`
+	// 月（YYYY-MM）ごとの延べ作業時間
+	ByMonth   map[string]float64 `json:"by_month"`
+	FirstDate string             `json:"first_date,omitempty" example:"2024-06-01"`
+	LastDate  string             `json:"last_date,omitempty" example:"2024-06-30"`
+}
@@ -0,0 +1,74 @@
+package models
+
+// 帳票の種類
+const (
+	DocumentKindEstimate = "estimate"
+	DocumentKindInvoice  = "invoice"
+)
+
+// DocumentIssuer は帳票の発行元（自社）を表す
+// @Description Issuer printed on estimates and invoices
+type DocumentIssuer struct {
+	Name    string `json:"name" yaml:"name" example:"ペンギン工業株式会社"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty" example:"〒455-0000 愛知県名古屋市港区..."`
+	Phone   string `json:"phone,omitempty" yaml:"phone,omitempty" example:"052-000-0000"`
+	// 適格請求書発行事業者の登録番号
+	RegistrationNumber string `json:"registration_number,omitempty" yaml:"registration_number,omitempty" example:"T1234567890123"`
+	// 印影の画像（PNGまたはJPEG、工事フォルダーからの相対パスまたは絶対パス）
+	SealImage string `json:"seal_image,omitempty" yaml:"seal_image,omitempty" example:"帳票/印影.png"`
+}
+
+// DocumentTemplate は帳票の種類ごとの文面を表す
+// @Description Wording of an estimate or invoice
+type DocumentTemplate struct {
+	// 表題
+	Title string `json:"title" yaml:"title" example:"御見積書"`
+	// 宛名の下に表示する文面
+	Message string `json:"message" yaml:"message" example:"下記の通り御見積申し上げます。"`
+	// 備考の既定値
+	Notes string `json:"notes,omitempty" yaml:"notes,omitempty" example:"諸経費を含みます。"`
+	// 振込先を表示するかどうか
+	ShowBankDetails bool `json:"show_bank_details" yaml:"show_bank_details" example:"false"`
+	// 見積の有効期限（発行日からの日数、0の場合は表示しない）
+	ValidDays int `json:"valid_days,omitempty" yaml:"valid_days,omitempty" example:"30"`
+	// ファイル名の接頭辞
+	FilePrefix string `json:"file_prefix" yaml:"file_prefix" example:"見積書"`
+}
+
+// DocumentSettings は見積書・請求書の設定を表す
+// @Description Settings for rendering estimates and invoices
+type DocumentSettings struct {
+	// 埋め込むTrueTypeフォント（.ttf/.ttc、工事フォルダーからの相対パスまたは絶対パス、省略時は同梱のフォント）
+	FontPath string         `json:"font_path,omitempty" yaml:"font_path,omitempty" example:"帳票/ipaexg.ttf"`
+	Issuer   DocumentIssuer `json:"issuer" yaml:"issuer"`
+	// 振込先（複数行）
+	BankDetails string           `json:"bank_details,omitempty" yaml:"bank_details,omitempty" example:"○○銀行 名古屋支店 普通 1234567 ペンギンコウギョウ（カ"`
+	Estimate    DocumentTemplate `json:"estimate" yaml:"estimate"`
+	Invoice     DocumentTemplate `json:"invoice" yaml:"invoice"`
+}
+
+// DocumentLineItem は帳票の明細行を表す
+// @Description One line item of an estimate or invoice
+type DocumentLineItem struct {
+	Name     string  `json:"name" example:"耐火物解体工事"`
+	Quantity float64 `json:"quantity,omitempty" example:"1"`
+	Unit     string  `json:"unit,omitempty" example:"式"`
+	// 単価・金額（税抜、円）。金額を省略した場合は数量×単価
+	UnitPrice int64 `json:"unit_price,omitempty" example:"500000"`
+	Amount    int64 `json:"amount,omitempty" example:"500000"`
+}
+
+// DocumentRequest は見積書・請求書の作成内容を表す
+// @Description Contents of an estimate or invoice to render
+type DocumentRequest struct {
+	// 発行日（YYYY-MM-DD、省略時は見積書は今日、請求書は請求日）
+	IssueDate string `json:"issue_date,omitempty" example:"2024-06-01"`
+	// 見積番号（請求書は請求書番号を使用する）
+	Number string `json:"number,omitempty" example:"M-2024-015"`
+	// 件名（省略時は現場名）
+	Subject string `json:"subject,omitempty" example:"名和工場 溶解炉 耐火物補修工事"`
+	// 明細（省略時は見積金額・請求金額の一式）
+	LineItems []DocumentLineItem `json:"line_items,omitempty"`
+	// 備考（省略時はテンプレートの備考）
+	Notes string `json:"notes,omitempty" example:"工期: 2024年6月18日〜7月1日"`
+}
@@ -14,6 +14,8 @@ type FileEntry struct {
 	Size int64 `json:"size" yaml:"size" example:"4096"`
 	// Last modification time
 	ModifiedTime Timestamp `json:"modified_time" yaml:"modified_time"`
+	// Photo は写真のEXIFから読み取った撮影情報（photo=trueで取得した一覧のEXIFのあるJPEGのみ、保存はしない）
+	Photo *PhotoMetadata `json:"photo,omitempty" yaml:"-"`
 }
 
 // FileEntriesListResponse はファイルエントリ一覧のレスポンスを表す
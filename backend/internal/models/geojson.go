@@ -0,0 +1,24 @@
+package models
+
+// GeoJSONFeatureCollection はGeoJSON (RFC 7946) のFeatureCollectionを表す
+// @Description GeoJSON FeatureCollection of kouji entries or sites
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type" example:"FeatureCollection"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature はGeoJSONのFeatureを表す
+// @Description GeoJSON Feature with a point geometry
+type GeoJSONFeature struct {
+	Type       string          `json:"type" example:"Feature"`
+	Id         string          `json:"id,omitempty" example:"ABCDE"`
+	Geometry   GeoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+// GeoJSONGeometry はGeoJSONのPointジオメトリを表す（座標は経度・緯度の順）
+// @Description GeoJSON point geometry ([longitude, latitude])
+type GeoJSONGeometry struct {
+	Type        string    `json:"type" example:"Point"`
+	Coordinates []float64 `json:"coordinates" example:"136.9,35.0436"`
+}
@@ -12,6 +12,19 @@ type KoujiEntry struct {
 	EndDate      Timestamp `json:"end_date,omitempty" yaml:"end_date"`
 	Description  string    `json:"description,omitempty" yaml:"description" example:"工事関連の資料とドキュメント"`
 	Tags         []string  `json:"tags,omitempty" yaml:"tags" example:"['工事', '豊田築炉', '名和工場']"`
+	// FiscalYear は開始日が属する年度（設定された開始月に基づいて計算し、保存はしない）
+	FiscalYear int `json:"fiscal_year,omitempty" yaml:"-" example:"2024"`
+	// Assignments は工事に割り当てた作業員・協力会社・機材
+	Assignments []KoujiAssignment `json:"assignments,omitempty" yaml:"assignments,omitempty"`
+	// Materials は資材の予定数量
+	Materials []KoujiMaterial `json:"materials,omitempty" yaml:"materials,omitempty"`
+	// 見積金額・受注金額（税抜、円）
+	EstimateAmount int64 `json:"estimate_amount,omitempty" yaml:"estimate_amount,omitempty" example:"1200000"`
+	OrderAmount    int64 `json:"order_amount,omitempty" yaml:"order_amount,omitempty" example:"1000000"`
+	// Invoices は請求と入金
+	Invoices []KoujiInvoice `json:"invoices,omitempty" yaml:"invoices,omitempty"`
+	// CustomFields はカスタムフィールドの値（定義はCustomFieldDefinition）
+	CustomFields map[string]any `json:"custom_fields,omitempty" yaml:"custom_fields,omitempty"`
 	// Embed the base FileEntry struct
 	FileEntry
 }
@@ -0,0 +1,64 @@
+package models
+
+// 割り当ての種類
+const (
+	AssignmentKindWorker        = "worker"
+	AssignmentKindSubcontractor = "subcontractor"
+	AssignmentKindEquipment     = "equipment"
+)
+
+// KoujiAssignment は工事に割り当てた作業員・協力会社・機材を表す
+// @Description Worker, subcontractor or equipment assigned to a kouji for a date range
+type KoujiAssignment struct {
+	// 割り当てのID（工事ID・名前・種類・期間から生成）
+	Id string `json:"id,omitempty" yaml:"id,omitempty" example:"K7M2P"`
+	// 作業員名・協力会社名・機材名
+	Name string `json:"name" yaml:"name" example:"山田 太郎"`
+	// 種類（worker, subcontractor, equipment）
+	Kind string `json:"kind" yaml:"kind" example:"worker" enums:"worker,subcontractor,equipment"`
+	// 割り当て期間（省略時は工事の開始日・終了日）
+	From Timestamp `json:"from,omitempty" yaml:"from,omitempty"`
+	To   Timestamp `json:"to,omitempty" yaml:"to,omitempty"`
+	Note string    `json:"note,omitempty" yaml:"note,omitempty" example:"耐火物搬入のみ"`
+}
+
+// KoujiAssignmentResponse は割り当ての登録・更新結果を表す
+// @Description Saved assignment with double-booking warnings
+type KoujiAssignmentResponse struct {
+	Assignment KoujiAssignment `json:"assignment"`
+	// 同じ人・機材が期間の重複する別の割り当てを持つ場合などの警告
+	Warnings []string `json:"warnings"`
+}
+
+// ScheduleItem は作業員などの予定（1件の割り当て）を表す
+// @Description One assignment in a worker's schedule
+type ScheduleItem struct {
+	KoujiId      string          `json:"kouji_id" example:"ABCDE"`
+	CompanyName  string          `json:"company_name" example:"豊田築炉"`
+	LocationName string          `json:"location_name" example:"名和工場"`
+	Assignment   KoujiAssignment `json:"assignment"`
+	// 割り当ての実際の期間（省略時は工事の期間）
+	From Timestamp `json:"from"`
+	To   Timestamp `json:"to"`
+}
+
+// WorkerScheduleResponse は作業員などの予定のレスポンスを表す
+// @Description Schedule of a worker, subcontractor or equipment
+type WorkerScheduleResponse struct {
+	Name  string         `json:"name" example:"山田 太郎"`
+	Items []ScheduleItem `json:"items"`
+	// 期間が重複している割り当てがある場合の警告
+	Warnings []string `json:"warnings"`
+}
+
+// DailyLoad は1日の割り当て状況を表す
+// @Description Assignments across all kouji on one day
+type DailyLoad struct {
+	Date string `json:"date" example:"2024-06-18"`
+	// 割り当てられている作業員・協力会社・機材の延べ数
+	Count int `json:"count" example:"8"`
+	// 工事IDごとの割り当て名
+	ByKouji map[string][]string `json:"by_kouji"`
+	// 同じ日に複数の工事に割り当てられている名前
+	DoubleBooked []string `json:"double_booked,omitempty"`
+}
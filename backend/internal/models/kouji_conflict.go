@@ -0,0 +1,30 @@
+package models
+
+// 工程の競合の種類
+const (
+	ConflictTypeLocationOverlap = "location_overlap"
+	ConflictTypeResourceOverlap = "resource_overlap"
+	ConflictTypeInvalidDates    = "invalid_dates"
+)
+
+// KoujiConflict は工程の競合を表す
+// @Description Scheduling conflict between kouji entries
+type KoujiConflict struct {
+	// 競合の種類（location_overlap, resource_overlap, invalid_dates）
+	Type string `json:"type" example:"location_overlap"`
+	// 競合している工事のID
+	KoujiIds []string `json:"kouji_ids" example:"['ABCDE', 'FGHIJ']"`
+	// 重複している現場・作業員・機材の名前
+	Subject string `json:"subject,omitempty" example:"豊田築炉 名和工場"`
+	// 重複している期間（invalid_datesの場合は工事の開始日・終了日）
+	From    Timestamp `json:"from"`
+	To      Timestamp `json:"to"`
+	Message string    `json:"message" example:"同じ現場で工期が重複しています"`
+}
+
+// KoujiConflictsResponse は工程の競合一覧のレスポンスを表す
+// @Description List of scheduling conflicts
+type KoujiConflictsResponse struct {
+	Conflicts []KoujiConflict `json:"conflicts"`
+	Count     int             `json:"count" example:"2"`
+}
@@ -0,0 +1,98 @@
+package models
+
+// ConsumptionTaxRate は消費税率とその適用開始日を表す
+// @Description Consumption tax rate effective from a date
+type ConsumptionTaxRate struct {
+	// 適用開始日（YYYY-MM-DD）
+	From string `json:"from" yaml:"from" example:"2019-10-01"`
+	// 税率（%）
+	Rate int `json:"rate" yaml:"rate" example:"10"`
+}
+
+// KoujiInvoice は工事の請求（1回分の請求書と入金）を表す
+// 金額は円単位の整数
+// @Description One invoice of a kouji with its payment
+type KoujiInvoice struct {
+	// 請求のID（登録時に生成）
+	Id string `json:"id,omitempty" yaml:"id" example:"K7M2P"`
+	// 請求書番号
+	Number string `json:"number,omitempty" yaml:"number,omitempty" example:"2024-0123"`
+	// 請求日・支払期日（YYYY-MM-DD）
+	IssueDate string `json:"issue_date" yaml:"issue_date" example:"2024-07-31"`
+	DueDate   string `json:"due_date,omitempty" yaml:"due_date,omitempty" example:"2024-08-31"`
+	// 請求金額（税抜）
+	Amount int64 `json:"amount" yaml:"amount" example:"1000000"`
+	// 消費税率（%、省略時は請求日の税率）
+	TaxRate *int `json:"tax_rate,omitempty" yaml:"tax_rate" example:"10"`
+	// 消費税額（1円未満切り捨て）と税込金額
+	Tax   int64 `json:"tax" yaml:"tax" example:"100000"`
+	Total int64 `json:"total" yaml:"total" example:"1100000"`
+	// 入金日（YYYY-MM-DD）と入金額
+	PaidDate   string `json:"paid_date,omitempty" yaml:"paid_date,omitempty" example:"2024-08-30"`
+	PaidAmount int64  `json:"paid_amount,omitempty" yaml:"paid_amount,omitempty" example:"1100000"`
+	Note       string `json:"note,omitempty" yaml:"note,omitempty" example:"出来高 第1回"`
+}
+
+// KoujiFinanceUpdateRequest は工事の見積金額・受注金額の更新内容を表す
+// @Description Estimate and order amounts of a kouji
+type KoujiFinanceUpdateRequest struct {
+	// 見積金額・受注金額（税抜）
+	EstimateAmount int64 `json:"estimate_amount" example:"1200000"`
+	OrderAmount    int64 `json:"order_amount" example:"1000000"`
+}
+
+// KoujiFinanceSummary は工事の金額の集計を表す
+// @Description Estimate, order, invoiced and received amounts of a kouji
+type KoujiFinanceSummary struct {
+	KoujiId        string `json:"kouji_id" example:"ABCDE"`
+	CompanyName    string `json:"company_name" example:"豊田築炉"`
+	LocationName   string `json:"location_name" example:"名和工場"`
+	EstimateAmount int64  `json:"estimate_amount" example:"1200000"`
+	OrderAmount    int64  `json:"order_amount" example:"1000000"`
+	// 請求金額の合計（税抜）・消費税・税込
+	InvoicedAmount int64 `json:"invoiced_amount" example:"1000000"`
+	InvoicedTax    int64 `json:"invoiced_tax" example:"100000"`
+	InvoicedTotal  int64 `json:"invoiced_total" example:"1100000"`
+	// 入金額の合計
+	PaidAmount int64 `json:"paid_amount" example:"550000"`
+	// 未入金額（税込請求額 - 入金額）
+	Outstanding int64 `json:"outstanding" example:"550000"`
+	// 未請求額（受注金額 - 請求金額、税抜）
+	Uninvoiced int64          `json:"uninvoiced" example:"0"`
+	Invoices   []KoujiInvoice `json:"invoices"`
+}
+
+// ReceivableInvoice は未入金の請求を表す
+// @Description Invoice with an outstanding balance
+type ReceivableInvoice struct {
+	KoujiId      string       `json:"kouji_id" example:"ABCDE"`
+	LocationName string       `json:"location_name" example:"名和工場"`
+	Invoice      KoujiInvoice `json:"invoice"`
+	Outstanding  int64        `json:"outstanding" example:"550000"`
+	// 支払期日を過ぎた日数（期日前・期日なしは0）
+	DaysOverdue int `json:"days_overdue" example:"12"`
+}
+
+// CompanyReceivable は会社ごとの売掛金（未入金の請求）を表す
+// @Description Outstanding receivables of one company
+type CompanyReceivable struct {
+	CompanyName string `json:"company_name" example:"豊田築炉"`
+	Outstanding int64  `json:"outstanding" example:"550000"`
+	// 支払期日を過ぎている未入金額
+	Overdue  int64               `json:"overdue" example:"550000"`
+	Invoices []ReceivableInvoice `json:"invoices"`
+}
+
+// MonthlyRevenue は月ごとの売上（請求）と入金を表す
+// @Description Invoiced and received amounts in one month
+type MonthlyRevenue struct {
+	Month string `json:"month" example:"2024-07"`
+	// 請求日がその月の請求金額（税抜）・消費税・税込
+	InvoicedAmount int64 `json:"invoiced_amount" example:"1000000"`
+	InvoicedTax    int64 `json:"invoiced_tax" example:"100000"`
+	InvoicedTotal  int64 `json:"invoiced_total" example:"1100000"`
+	// 入金日がその月の入金額
+	PaidAmount int64 `json:"paid_amount" example:"550000"`
+	// 会社ごとの請求金額（税抜）
+	ByCompany map[string]int64 `json:"by_company"`
+}
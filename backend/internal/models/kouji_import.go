@@ -0,0 +1,60 @@
+package models
+
+// KoujiImportResult は工事メタデータ一括取り込みの結果（プレビューを含む）を表す
+// @Description Result of a bulk kouji metadata import (preview or applied)
+type KoujiImportResult struct {
+	// 反映を行ったかどうか（falseの場合はプレビュー）
+	Applied bool `json:"applied" example:"false"`
+	// データ行の数（ヘッダーを除く）
+	TotalRows int `json:"total_rows" example:"120"`
+	// 工事に一致した行と変更内容
+	Matched []KoujiImportMatch `json:"matched"`
+	// 一致する工事がなかった行
+	Unmatched []KoujiImportRow `json:"unmatched"`
+	// 複数の工事に一致した行
+	Ambiguous []KoujiImportAmbiguous `json:"ambiguous"`
+	// 値が不正な行
+	Errors []KoujiImportError `json:"errors"`
+	// 取り込み対象外として無視した列
+	IgnoredColumns []string `json:"ignored_columns"`
+	// 変更があった工事の数
+	UpdatedCount int `json:"updated_count" example:"42"`
+}
+
+// KoujiImportRow は取り込みファイルの1行を表す
+type KoujiImportRow struct {
+	// 行番号（ヘッダーを1行目とする）
+	Row int `json:"row" example:"2"`
+	// 列名と値
+	Values map[string]string `json:"values"`
+}
+
+// KoujiImportMatch は工事に一致した行と、その行による変更内容を表す
+type KoujiImportMatch struct {
+	Row     int    `json:"row" example:"2"`
+	KoujiId string `json:"kouji_id" example:"B3PXU"`
+	// 一致方法（id または date_company_location）
+	MatchedBy string             `json:"matched_by" example:"id"`
+	Changes   []KoujiFieldChange `json:"changes"`
+}
+
+// KoujiFieldChange はフィールド単位の変更前後の値を表す
+type KoujiFieldChange struct {
+	Field string `json:"field" example:"end_date"`
+	Old   string `json:"old" example:"2024-06-18"`
+	New   string `json:"new" example:"2024-07-01"`
+}
+
+// KoujiImportAmbiguous は複数の工事に一致した行を表す
+type KoujiImportAmbiguous struct {
+	KoujiImportRow
+	// 一致した工事のID
+	Candidates []string `json:"candidates"`
+}
+
+// KoujiImportError は値が不正な行を表す
+type KoujiImportError struct {
+	Row     int    `json:"row" example:"5"`
+	Column  string `json:"column,omitempty" example:"終了日"`
+	Message string `json:"message" example:"日付を解析できません"`
+}
@@ -0,0 +1,61 @@
+package models
+
+// KoujiRule は工事の自動タグ付けルールを表す
+// 条件はすべて AND で評価し、空の条件は常に一致する
+// @Description Rule that adds tags or sets fields on matching kouji entries
+type KoujiRule struct {
+	// ルール名
+	Name string `json:"name" yaml:"name" example:"図面あり"`
+	// trueの場合は評価しない
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty" example:"false"`
+	// 一致条件
+	Conditions KoujiRuleConditions `json:"conditions" yaml:"conditions"`
+	// 追加するタグ（{company}, {location}, {year}, {fiscal_year}, {name} を置換）
+	AddTags []string `json:"add_tags,omitempty" yaml:"add_tags,omitempty" example:"['図面あり']"`
+	// 設定するフィールド（description, end_date, custom.<キー>）
+	SetFields map[string]string `json:"set_fields,omitempty" yaml:"set_fields,omitempty"`
+	// trueの場合、ルールの一括適用で既存の工事のフィールドも上書きする（falseの場合は新しく見つかった工事のみ）
+	Overwrite bool `json:"overwrite,omitempty" yaml:"overwrite,omitempty" example:"false"`
+}
+
+// KoujiRuleConditions はルールの一致条件を表す
+type KoujiRuleConditions struct {
+	// 会社名（完全一致）
+	Company string `json:"company,omitempty" yaml:"company,omitempty" example:"豊田築炉"`
+	// 現場名（部分一致）
+	Location string `json:"location,omitempty" yaml:"location,omitempty" example:"名和"`
+	// フォルダー名の正規表現
+	NamePattern string `json:"name_pattern,omitempty" yaml:"name_pattern,omitempty" example:"見積"`
+	// 開始日の下限（この日を含む）
+	From string `json:"from,omitempty" yaml:"from,omitempty" example:"2024-04-01"`
+	// 開始日の上限（この日を含む）
+	To string `json:"to,omitempty" yaml:"to,omitempty" example:"2025-03-31"`
+	// フォルダー内に存在するファイルのパターン（例: *.dwg, 図面/*.pdf）
+	FileGlob string `json:"file_glob,omitempty" yaml:"file_glob,omitempty" example:"*.dwg"`
+}
+
+// KoujiRulePreview はルールごとの適用対象を表す
+// @Description Kouji entries affected by a rule
+type KoujiRulePreview struct {
+	Name string `json:"name" example:"図面あり"`
+	// 一致した工事
+	Matches []KoujiRuleMatch `json:"matches"`
+}
+
+// KoujiRuleMatch はルールに一致した工事と適用内容を表す
+type KoujiRuleMatch struct {
+	KoujiId      string `json:"kouji_id" example:"B3PXU"`
+	CompanyName  string `json:"company_name" example:"豊田築炉"`
+	LocationName string `json:"location_name" example:"名和工場"`
+	// ルールが付与するタグ
+	Tags []string `json:"tags"`
+	// 現在の値から変更されるフィールド
+	Changes []KoujiFieldChange `json:"changes"`
+}
+
+// KoujiRuleApplyResponse はルールの一括適用の結果を表す
+// @Description Result of applying the saved rules to every kouji entry
+type KoujiRuleApplyResponse struct {
+	// タグまたはフィールドが変更された工事の数
+	Updated int `json:"updated" example:"3"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// KoujiSettings は工事管理の設定を表す
+// @Description Settings for kouji management
+type KoujiSettings struct {
+	// 年度の開始月（1〜12、既定は4月）
+	FiscalYearStartMonth int `json:"fiscal_year_start_month" yaml:"fiscal_year_start_month" example:"4"`
+	// 工数表を編集できなくなる工事の状態（省略時は完了）
+	TimesheetLockedStatuses []string `json:"timesheet_locked_statuses" yaml:"timesheet_locked_statuses" example:"['完了']"`
+	// 労務費の予算を保持するカスタムフィールドのキー
+	LabourBudgetField string `json:"labour_budget_field" yaml:"labour_budget_field" example:"労務費予算"`
+	// 消費税率の表（適用開始日の昇順）
+	ConsumptionTaxRates []ConsumptionTaxRate `json:"consumption_tax_rates" yaml:"consumption_tax_rates"`
+	// UIのベースURL（カレンダーの各予定から工事の画面へのリンクに使う、空の場合はリンクなし）
+	UIBaseURL string `json:"ui_base_url" yaml:"ui_base_url" example:"https://penguin.example.com"`
+}
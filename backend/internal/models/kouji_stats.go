@@ -0,0 +1,28 @@
+package models
+
+// KoujiStats は工事一覧の集計結果を表す
+// @Description Aggregated statistics of kouji entries for the management dashboard
+type KoujiStats struct {
+	// 集計対象の工事数
+	Count int `json:"count" example:"120"`
+	// 開始年ごとの工事数
+	ByYear map[string]int `json:"by_year"`
+	// 年度ごとの工事数
+	ByFiscalYear map[string]int `json:"by_fiscal_year"`
+	// 会社ごとの工事数
+	ByCompany map[string]int `json:"by_company"`
+	// 状態ごとの工事数
+	ByStatus map[string]int `json:"by_status"`
+	// 工期（開始日から終了日まで）の平均日数
+	AverageDurationDays float64 `json:"average_duration_days" example:"12.5"`
+	// 工期の中央値（日数）
+	MedianDurationDays float64 `json:"median_duration_days" example:"10"`
+	// 工期の集計に使用した工事数（終了日が開始日より後の工事のみ。終了日が開始日と同じ工事は終了日が未入力とみなして除外する）
+	DurationSampleCount int `json:"duration_sample_count" example:"80"`
+	// 現在進行中の工事
+	Running []KoujiEntry `json:"running"`
+	// 会社ごとのディスク使用量（バイト）
+	DiskUsageByCompany map[string]int64 `json:"disk_usage_by_company"`
+	// 全体のディスク使用量（バイト）
+	TotalDiskUsage int64 `json:"total_disk_usage" example:"1073741824"`
+}
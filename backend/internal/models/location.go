@@ -0,0 +1,38 @@
+package models
+
+// Location は現場（会社名と現場名の組）のマスターデータを表す
+// @Description Work site registered by company and location name
+type Location struct {
+	CompanyName  string `json:"company_name" yaml:"company_name" example:"豊田築炉"`
+	LocationName string `json:"location_name" yaml:"location_name" example:"名和工場"`
+	Address      string `json:"address,omitempty" yaml:"address,omitempty" example:"愛知県東海市名和町"`
+	// 緯度・経度（世界測地系）
+	Latitude  *float64 `json:"latitude,omitempty" yaml:"latitude,omitempty" example:"35.0436"`
+	Longitude *float64 `json:"longitude,omitempty" yaml:"longitude,omitempty" example:"136.9000"`
+	Notes     string   `json:"notes,omitempty" yaml:"notes,omitempty" example:"搬入口は北門"`
+}
+
+// LocationJob は現場の履歴に含まれる工事を表す
+// @Description Kouji entry in a site history
+type LocationJob struct {
+	KoujiEntry
+	// 前回の工事の終了日からこの工事の開始日までの日数（最初の工事は省略）
+	DaysSincePrevious *int `json:"days_since_previous,omitempty" example:"1095"`
+}
+
+// LocationHistory は現場で行った工事と予定している工事の履歴を表す
+// @Description Past and planned kouji entries at a site in chronological order
+type LocationHistory struct {
+	Location Location `json:"location"`
+	// 現場が登録されているかどうか（未登録の場合は工事一覧から作成）
+	Registered bool `json:"registered" example:"true"`
+	// 開始日の昇順の工事一覧
+	Jobs  []LocationJob `json:"jobs"`
+	Count int           `json:"count" example:"4"`
+	// 最後に完了した工事の終了日から今日までの日数（完了した工事がない場合は省略）
+	DaysSinceLastJob *int `json:"days_since_last_job,omitempty" example:"400"`
+	// 完了した工事の平均間隔（日数、2件以上ある場合のみ）
+	AverageIntervalDays *float64 `json:"average_interval_days,omitempty" example:"1080.5"`
+	// 次回工事の予測（工事が2件以上ある場合のみ）
+	Prediction *MaintenancePrediction `json:"prediction,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// MaintenancePrediction は現場の次回工事の予測を表す
+// @Description Predicted next job date at a site based on past intervals
+type MaintenancePrediction struct {
+	CompanyName  string `json:"company_name" example:"豊田築炉"`
+	LocationName string `json:"location_name" example:"名和工場"`
+	// 現場が登録されているかどうか
+	Registered bool `json:"registered" example:"true"`
+	// 予測に使用した工事の数
+	JobCount int `json:"job_count" example:"3"`
+	// 最後の工事の開始日
+	LastStartDate Timestamp `json:"last_start_date"`
+	// 工事の開始日の間隔の中央値（日数）
+	TypicalIntervalDays int `json:"typical_interval_days" example:"1095"`
+	// 次回工事の予測開始日
+	PredictedDate Timestamp `json:"predicted_date"`
+	// 今日から予測開始日までの日数（過ぎている場合は負数）
+	DaysUntil int `json:"days_until" example:"45"`
+	// 既に予定されている次回工事のID（ある場合）
+	ScheduledKoujiId string `json:"scheduled_kouji_id,omitempty" example:"ABCDE"`
+}
+
+// MaintenancePredictionsResponse は次回工事の予測一覧のレスポンスを表す
+// @Description Sites whose next job is predicted within the requested period
+type MaintenancePredictionsResponse struct {
+	Months      int                     `json:"months" example:"6"`
+	Predictions []MaintenancePrediction `json:"predictions"`
+	Count       int                     `json:"count" example:"2"`
+}
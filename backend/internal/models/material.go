@@ -0,0 +1,92 @@
+package models
+
+// 在庫の入出庫の種類
+const (
+	StockMovementReceipt = "receipt" // 入庫
+	StockMovementIssue   = "issue"   // 工事への出庫
+	StockMovementReturn  = "return"  // 工事からの返却
+	StockMovementAdjust  = "adjust"  // 棚卸による調整（数量は正負どちらも可）
+)
+
+// Material は資材マスター（耐火れんが・キャスタブル・モルタルなど）を表す
+// @Description Refractory material in the catalogue
+type Material struct {
+	// 資材コード
+	Code string `json:"code" yaml:"code" example:"SK34-STD"`
+	Name string `json:"name" yaml:"name" example:"SK34 並形れんが"`
+	// 分類（れんが, キャスタブル, モルタル など）
+	Category string `json:"category,omitempty" yaml:"category,omitempty" example:"れんが"`
+	// 単位（個, kg, 袋 など）
+	Unit string `json:"unit" yaml:"unit" example:"個"`
+	// 単価（円）
+	UnitPrice float64 `json:"unit_price,omitempty" yaml:"unit_price,omitempty" example:"450"`
+	Notes     string  `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// KoujiMaterial は工事の資材の予定数量を表す
+// @Description Planned quantity of a material for a kouji
+type KoujiMaterial struct {
+	Code    string  `json:"code" yaml:"code" example:"SK34-STD"`
+	Planned float64 `json:"planned" yaml:"planned" example:"1200"`
+}
+
+// KoujiMaterialUsage は工事の資材の予定数量と実績（出庫 - 返却）を表す
+// @Description Planned vs actual material quantity for a kouji
+type KoujiMaterialUsage struct {
+	Code    string  `json:"code" example:"SK34-STD"`
+	Name    string  `json:"name" example:"SK34 並形れんが"`
+	Unit    string  `json:"unit" example:"個"`
+	Planned float64 `json:"planned" example:"1200"`
+	// 実績（工事への出庫 - 工事からの返却）
+	Actual float64 `json:"actual" example:"1150"`
+	// 実績 - 予定
+	Difference float64 `json:"difference" example:"-50"`
+	// 出庫・返却の数量 × 登録時の単価の合計
+	Cost float64 `json:"cost" example:"517500"`
+}
+
+// StockMovement は倉庫の入出庫を表す
+// @Description Warehouse stock movement, optionally tied to a kouji
+type StockMovement struct {
+	Id   string `json:"id,omitempty" yaml:"id" example:"K7M2P"`
+	Date string `json:"date" yaml:"date" example:"2024-06-18"`
+	Code string `json:"code" yaml:"code" example:"SK34-STD"`
+	// 種類（receipt, issue, return, adjust）
+	Type string `json:"type" yaml:"type" example:"issue" enums:"receipt,issue,return,adjust"`
+	// 数量（adjust以外は正の値）
+	Quantity float64 `json:"quantity" yaml:"quantity" example:"600"`
+	// 出庫・返却先の工事ID（issue, returnでは必須）
+	KoujiId string `json:"kouji_id,omitempty" yaml:"kouji_id,omitempty" example:"ABCDE"`
+	Note    string `json:"note,omitempty" yaml:"note,omitempty"`
+	// 登録時の資材マスターの単価（原価はこの単価で計算するため、後から単価を変更しても変わらない）
+	UnitPrice float64 `json:"unit_price,omitempty" yaml:"unit_price,omitempty" example:"450"`
+}
+
+// StockMovementResponse は入出庫の登録結果を表す
+// @Description Saved stock movement with warnings such as negative stock
+type StockMovementResponse struct {
+	Movement StockMovement `json:"movement"`
+	Warnings []string      `json:"warnings"`
+}
+
+// StockLevel は資材の現在の在庫数を表す
+// @Description Current stock of a material
+type StockLevel struct {
+	Code     string  `json:"code" example:"SK34-STD"`
+	Name     string  `json:"name" example:"SK34 並形れんが"`
+	Unit     string  `json:"unit" example:"個"`
+	Quantity float64 `json:"quantity" example:"3200"`
+}
+
+// MaterialConsumption は資材の消費量（工事ごとまたは月ごと）を表す
+// @Description Material consumption grouped by kouji or month
+type MaterialConsumption struct {
+	// 工事IDまたは月（YYYY-MM）
+	Key      string  `json:"key" example:"ABCDE"`
+	Code     string  `json:"code" example:"SK34-STD"`
+	Name     string  `json:"name" example:"SK34 並形れんが"`
+	Unit     string  `json:"unit" example:"個"`
+	Quantity float64 `json:"quantity" example:"1150"`
+	// 数量 × 入出庫の登録時の単価の合計
+	Cost float64 `json:"cost" example:"517500"`
+}
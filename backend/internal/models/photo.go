@@ -0,0 +1,133 @@
+package models
+
+// PhotoLedgerRequest は工事写真台帳の作成内容を表す
+// @Description Photos and options for a construction photo ledger
+type PhotoLedgerRequest struct {
+	// 写真のフォルダー（工事フォルダーからの相対パス、JPEG・PNGを撮影日時の順に並べる）
+	Folder string `json:"folder,omitempty" example:"写真"`
+	// 写真のファイル（工事フォルダーからの相対パス、指定した場合はフォルダーより優先し、指定した順に並べる）
+	Files []string `json:"files,omitempty" example:"['写真/IMG_0001.JPG', '写真/IMG_0002.JPG']"`
+	// 表題（省略時は工事写真台帳）
+	Title string `json:"title,omitempty" example:"工事写真台帳"`
+	// 撮影場所（省略時は現場名）
+	Location string `json:"location,omitempty" example:"名和工場 第2溶解炉"`
+}
+
+// PhotoMetadata は写真のEXIFから読み取った撮影情報を表す
+// @Description Capture time, camera and position read from the photo's EXIF
+type PhotoMetadata struct {
+	// 撮影日時（EXIFに記録がない場合は空）
+	TakenAt Timestamp `json:"taken_at"`
+	// カメラのメーカー・機種
+	CameraMake  string `json:"camera_make,omitempty" example:"Apple"`
+	CameraModel string `json:"camera_model,omitempty" example:"iPhone 15"`
+	// 画像の向き（EXIFのOrientation、1〜8）
+	Orientation int `json:"orientation,omitempty" example:"6"`
+	// 撮影位置（GPSの記録がない場合は省略）
+	GPS *PhotoGPS `json:"gps,omitempty"`
+}
+
+// PhotoGPS は写真の撮影位置を表す
+// @Description Capture position in decimal degrees
+type PhotoGPS struct {
+	Latitude  float64 `json:"latitude" example:"35.0436"`
+	Longitude float64 `json:"longitude" example:"136.8797"`
+	// 標高（メートル）
+	Altitude *float64 `json:"altitude,omitempty" example:"12.5"`
+}
+
+// PhotoSortRequest は写真の撮影日ごとのフォルダーへの整理内容を表す
+// @Description Options for sorting photos into 写真/YYYY-MM-DD folders
+type PhotoSortRequest struct {
+	// 整理する写真のフォルダー（工事フォルダーからの相対パス、省略時は工事フォルダー直下）
+	Folder string `json:"folder,omitempty" example:"写真"`
+	// ファイル名を撮影日時（20240618_093000.jpg）に変更する
+	Rename bool `json:"rename,omitempty" example:"true"`
+	// EXIFに撮影日時がない写真はファイルの更新日時で振り分ける（既定では対象外）
+	UseModTime bool `json:"use_mod_time,omitempty" example:"false"`
+}
+
+// PhotoSortResult は写真の整理のプレビューまたは実行結果を表す
+// @Description Planned or applied photo moves
+type PhotoSortResult struct {
+	// 実際に移動したか（プレビューではfalse）
+	Applied bool `json:"applied" example:"false"`
+	// 移動する（した）写真
+	Moves []PhotoMove `json:"moves"`
+	// 対象外の写真
+	Skipped []PhotoSortIssue `json:"skipped"`
+	// 移動に失敗した写真
+	Failed []PhotoSortIssue `json:"failed"`
+}
+
+// PhotoMove は写真1枚の移動元と移動先を表す（パスは工事フォルダーからの相対パス）
+// @Description Photo move from its current path to the dated folder
+type PhotoMove struct {
+	From string `json:"from" example:"IMG_0001.JPG"`
+	To   string `json:"to" example:"写真/2024-06-18/20240618_093000.JPG"`
+	// 振り分けに使った撮影日時と、その取得元（exif: EXIF、mtime: ファイルの更新日時）
+	TakenAt    Timestamp `json:"taken_at"`
+	DateSource string    `json:"date_source" example:"exif"`
+	// 写真と一緒に移動する説明のファイル
+	CaptionFrom string `json:"caption_from,omitempty" example:"IMG_0001.txt"`
+	CaptionTo   string `json:"caption_to,omitempty" example:"写真/2024-06-18/20240618_093000.txt"`
+}
+
+// PhotoSortIssue は整理の対象外または失敗した写真と理由を表す
+// @Description Photo that was skipped or could not be moved
+type PhotoSortIssue struct {
+	Path   string `json:"path" example:"IMG_0002.PNG"`
+	Reason string `json:"reason" example:"撮影日時がありません"`
+}
+
+// PhotoHashJobStatus は写真の知覚ハッシュを計算するバックグラウンド処理の状況を表す
+// @Description Progress of the background perceptual hash scan
+type PhotoHashJobStatus struct {
+	Running    bool      `json:"running" example:"true"`
+	StartedAt  Timestamp `json:"started_at"`
+	FinishedAt Timestamp `json:"finished_at"`
+	// 対象の画像の数と、そのうち処理した数
+	Total     int `json:"total" example:"1200"`
+	Processed int `json:"processed" example:"300"`
+	// 新たにハッシュを計算した数（前回から変わっていない画像は計算しない）
+	Hashed int `json:"hashed" example:"40"`
+	// 読み込めなかった画像の数
+	Failed int    `json:"failed" example:"2"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PhotoDuplicate は見た目が重複する写真の1枚を表す
+// @Description Photo belonging to a cluster of visual duplicates
+type PhotoDuplicate struct {
+	Path         string    `json:"path" example:"/home/user/penguin/豊田築炉/2-工事/2024-06-18 豊田築炉 名和工場/写真/IMG_0001.JPG"`
+	Size         int64     `json:"size" example:"3145728"`
+	ModifiedTime Timestamp `json:"modified_time"`
+	// 写真がある工事のID（工事フォルダーの外の場合は空）
+	KoujiId string `json:"kouji_id,omitempty" example:"B3PXU"`
+	// グループの最初の写真とのハッシュの距離（0は同一）
+	Distance int `json:"distance" example:"0"`
+}
+
+// PhotoDuplicateCluster は見た目が重複する写真のグループを表す
+// @Description Cluster of visually duplicate photos, largest first
+type PhotoDuplicateCluster struct {
+	// 最初の写真の知覚ハッシュ（16進数）
+	Hash   string           `json:"hash" example:"f0e4c2d6b8a09183"`
+	Photos []PhotoDuplicate `json:"photos"`
+	// 合計サイズと、最大の写真1枚を残した場合に削減できるサイズ
+	TotalSize       int64 `json:"total_size" example:"9437184"`
+	ReclaimableSize int64 `json:"reclaimable_size" example:"6291456"`
+}
+
+// PhotoDuplicatesResponse は見た目が重複する写真のグループの一覧を表す
+// @Description Clusters of visually duplicate photos from the last scan
+type PhotoDuplicatesResponse struct {
+	// 重複とみなすハッシュの距離の上限
+	Threshold int `json:"threshold" example:"5"`
+	// 最後にハッシュの計算が完了した日時
+	ScannedAt Timestamp               `json:"scanned_at"`
+	Clusters  []PhotoDuplicateCluster `json:"clusters"`
+	Count     int                     `json:"count" example:"12"`
+	// すべてのグループで削減できるサイズの合計
+	ReclaimableSize int64 `json:"reclaimable_size" example:"104857600"`
+}
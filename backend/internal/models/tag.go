@@ -0,0 +1,51 @@
+package models
+
+// TagUsage はタグとその使用数を表す
+// @Description Tag with the number of kouji entries using it
+type TagUsage struct {
+	Tag string `json:"tag" example:"名和工場"`
+	// タグが付いている工事の数
+	Count int `json:"count" example:"12"`
+	// 管理語彙に含まれているかどうか
+	Managed bool `json:"managed" example:"true"`
+}
+
+// TagListResponse はタグ一覧のレスポンスを表す
+// @Description List of tags with usage counts
+type TagListResponse struct {
+	Tags []TagUsage `json:"tags"`
+	// 空白や全角・半角の違いのみで異なるタグのグループ（統合候補）
+	SimilarGroups [][]string `json:"similar_groups"`
+	// 新しいタグを管理語彙に制限しているかどうか
+	Restricted bool `json:"restricted" example:"false"`
+}
+
+// TagVocabulary は管理語彙（使用を許可するタグの一覧）を表す
+// @Description Managed tag vocabulary
+type TagVocabulary struct {
+	// trueの場合、管理語彙にない新しいタグの追加を拒否する
+	Restricted bool `json:"restricted" yaml:"restricted" example:"false"`
+	// 管理語彙のタグ
+	Tags []string `json:"tags" yaml:"tags" example:"['工事', '見積のみ']"`
+}
+
+// RenameTagRequest はタグ名変更のリクエストを表す
+// @Description Request body for renaming a tag across all kouji entries
+type RenameTagRequest struct {
+	From string `json:"from" example:"名和 工場"`
+	To   string `json:"to" example:"名和工場"`
+}
+
+// MergeTagsRequest はタグ統合のリクエストを表す
+// @Description Request body for merging several tags into one
+type MergeTagsRequest struct {
+	Sources []string `json:"sources" example:"['名和 工場', '名和工場 ']"`
+	Target  string   `json:"target" example:"名和工場"`
+}
+
+// TagUpdateResponse はタグ変更のレスポンスを表す
+// @Description Result of a tag rename or merge
+type TagUpdateResponse struct {
+	// タグが変更された工事の数
+	Updated int `json:"updated" example:"3"`
+}
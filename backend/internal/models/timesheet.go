@@ -0,0 +1,85 @@
+package models
+
+// TimesheetEntry は作業員の工事ごと・日ごとの作業時間（工数表の1行）を表す
+// @Description Hours worked by a worker on a kouji on one day
+type TimesheetEntry struct {
+	// 工事ID・日付・作業員名から生成するID
+	Id         string  `json:"id,omitempty" yaml:"id" example:"K7M2P"`
+	KoujiId    string  `json:"kouji_id" yaml:"kouji_id" example:"ABCDE"`
+	Date       string  `json:"date" yaml:"date" example:"2024-06-18"`
+	WorkerName string  `json:"worker_name" yaml:"worker_name" example:"山田 太郎"`
+	Hours      float64 `json:"hours" yaml:"hours" example:"8"`
+	Note       string  `json:"note,omitempty" yaml:"note,omitempty" example:"残業2時間を含む"`
+	// 登録時の作業員名簿の時間単価（労務費はこの単価で計算するため、後から名簿の単価を変更しても変わらない）
+	HourlyRate float64 `json:"hourly_rate,omitempty" yaml:"hourly_rate,omitempty" example:"3000"`
+}
+
+// TimesheetListResponse は工数表一覧のレスポンスを表す
+// @Description Timesheet rows matching the query
+type TimesheetListResponse struct {
+	Entries    []TimesheetEntry `json:"entries"`
+	Count      int              `json:"count" example:"20"`
+	TotalHours float64          `json:"total_hours" example:"160"`
+}
+
+// WorkerLabourCost は作業員ごとの労務費を表す
+// @Description Labour cost of one worker
+type WorkerLabourCost struct {
+	WorkerName string  `json:"worker_name" example:"山田 太郎"`
+	Hours      float64 `json:"hours" example:"40"`
+	// 工数表の行に記録した時間単価（行ごとに異なる場合は平均、登録されていない場合は0）
+	HourlyRate float64 `json:"hourly_rate" example:"3000"`
+	Cost       float64 `json:"cost" example:"120000"`
+}
+
+// LabourCostTotal は作業時間と労務費の合計を表す
+// @Description Total hours and labour cost
+type LabourCostTotal struct {
+	Hours float64 `json:"hours" example:"160"`
+	Cost  float64 `json:"cost" example:"480000"`
+}
+
+// LabourCostSummary は工事の労務費の集計を表す
+// @Description Labour cost roll-up of a kouji with budget comparison
+type LabourCostSummary struct {
+	KoujiId    string             `json:"kouji_id" example:"ABCDE"`
+	TotalHours float64            `json:"total_hours" example:"160"`
+	TotalCost  float64            `json:"total_cost" example:"480000"`
+	ByWorker   []WorkerLabourCost `json:"by_worker"`
+	// 月（YYYY-MM）ごとの合計
+	ByMonth map[string]LabourCostTotal `json:"by_month"`
+	// 予算のカスタムフィールドのキーと値（未設定の場合は省略）
+	BudgetField string   `json:"budget_field,omitempty" example:"労務費予算"`
+	Budget      *float64 `json:"budget,omitempty" example:"600000"`
+	// 予算 - 労務費（負の場合は予算超過）
+	Variance *float64 `json:"variance,omitempty" example:"120000"`
+	// 労務費 / 予算
+	BudgetUsedRatio *float64 `json:"budget_used_ratio,omitempty" example:"0.8"`
+	// 時間単価が登録されていない作業員（労務費は0として計算）
+	UnratedWorkers []string `json:"unrated_workers,omitempty" example:"['鈴木 一郎']"`
+	// 工事が工数表を編集できない状態かどうか
+	Locked bool `json:"locked" example:"false"`
+	// 日報の延べ作業時間（労務費は工数表で計算し、日報は照合にのみ使う）
+	DailyReportHours float64 `json:"daily_report_hours" example:"152"`
+	// 日報がある日のうち、工数表と日報で作業時間が異なる作業員
+	HoursDiscrepancies []HoursDiscrepancy `json:"hours_discrepancies,omitempty"`
+}
+
+// HoursDiscrepancy は工数表と日報で作業時間が異なる日・作業員を表す
+// @Description Worker whose timesheet hours differ from the daily report of the day
+type HoursDiscrepancy struct {
+	Date             string  `json:"date" example:"2024-06-18"`
+	WorkerName       string  `json:"worker_name" example:"山田 太郎"`
+	TimesheetHours   float64 `json:"timesheet_hours" example:"8"`
+	DailyReportHours float64 `json:"daily_report_hours" example:"10"`
+}
+
+// MonthlyLabourCost は全工事の月ごとの労務費を表す
+// @Description Labour cost of all kouji in one month
+type MonthlyLabourCost struct {
+	Month string  `json:"month" example:"2024-06"`
+	Hours float64 `json:"hours" example:"640"`
+	Cost  float64 `json:"cost" example:"1920000"`
+	// 工事IDごとの合計
+	ByKouji map[string]LabourCostTotal `json:"by_kouji"`
+}
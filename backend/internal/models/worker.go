@@ -0,0 +1,70 @@
+package models
+
+// Worker は作業員のマスターデータを表す
+// @Description Worker registered with qualifications and safety documents
+type Worker struct {
+	Name string `json:"name" yaml:"name" example:"山田 太郎"`
+	// 名前の読み（カナ）
+	Kana string `json:"kana,omitempty" yaml:"kana,omitempty" example:"ヤマダ タロウ"`
+	// 所属（自社または協力会社名）
+	Affiliation string `json:"affiliation,omitempty" yaml:"affiliation,omitempty" example:"自社"`
+	Phone       string `json:"phone,omitempty" yaml:"phone,omitempty" example:"090-0000-0000"`
+	// 時間単価（円）。工数表の労務費の計算に使用する
+	HourlyRate float64 `json:"hourly_rate,omitempty" yaml:"hourly_rate,omitempty" example:"3000"`
+	// 資格・安全書類
+	Qualifications []WorkerQualification `json:"qualifications,omitempty" yaml:"qualifications,omitempty"`
+	Notes          string                `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// WorkerQualification は作業員の資格・安全書類を表す
+// @Description Qualification or safety document held by a worker
+type WorkerQualification struct {
+	// 資格・書類の名前
+	Name string `json:"name" yaml:"name" example:"酸素欠乏危険作業"`
+	// 区分（特別教育, 技能講習, 健康診断 など）
+	Category string `json:"category,omitempty" yaml:"category,omitempty" example:"技能講習"`
+	// 証明書番号
+	Number   string    `json:"number,omitempty" yaml:"number,omitempty" example:"第12345号"`
+	IssuedAt Timestamp `json:"issued_at,omitempty" yaml:"issued_at,omitempty"`
+	// 有効期限（期限がない資格は省略）
+	ExpiresAt Timestamp `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+// QualificationExpiry は期限が近い（または切れている）資格・安全書類を表す
+// @Description Upcoming or past expiry of a worker's qualification
+type QualificationExpiry struct {
+	WorkerName    string    `json:"worker_name" example:"山田 太郎"`
+	Qualification string    `json:"qualification" example:"健康診断"`
+	Category      string    `json:"category,omitempty" example:"健康診断"`
+	ExpiresAt     Timestamp `json:"expires_at"`
+	// 今日から有効期限までの日数（切れている場合は負数）
+	DaysLeft int `json:"days_left" example:"30"`
+}
+
+// QualificationIssue は工事に割り当てた作業員の資格・安全書類の問題を表す
+// @Description Assigned worker whose documents expire before the end of the assignment
+type QualificationIssue struct {
+	KoujiId    string `json:"kouji_id" example:"ABCDE"`
+	WorkerName string `json:"worker_name" example:"山田 太郎"`
+	// 問題のある資格・書類（作業員が登録されていない場合は省略）
+	Qualification string    `json:"qualification,omitempty" example:"健康診断"`
+	ExpiresAt     Timestamp `json:"expires_at,omitempty"`
+	// 割り当ての終了日
+	AssignmentTo Timestamp `json:"assignment_to"`
+	Message      string    `json:"message" example:"健康診断 の有効期限が割り当ての終了日より前です"`
+}
+
+// QualificationIssuesResponse は資格・安全書類の確認結果のレスポンスを表す
+// @Description Result of checking assigned workers' documents
+type QualificationIssuesResponse struct {
+	Issues []QualificationIssue `json:"issues"`
+	Count  int                  `json:"count" example:"1"`
+}
+
+// QualificationExpiriesResponse は期限が近い資格・安全書類一覧のレスポンスを表す
+// @Description Qualifications expiring within the requested number of days
+type QualificationExpiriesResponse struct {
+	Days     int                   `json:"days" example:"60"`
+	Expiries []QualificationExpiry `json:"expiries"`
+	Count    int                   `json:"count" example:"3"`
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"penguin-backend/internal/models"
 	"strings"
+	"sync"
 	"syscall"
 )
 
@@ -13,6 +14,13 @@ import (
 type FileSystemService struct {
 	// Root is the root directory of the file system
 	Root string `json:"root" yaml:"root" example:"/home/<user>/penguin"`
+	// ThumbnailCacheSize はサムネイルのキャッシュの合計サイズの上限（バイト、0以下は既定値）
+	ThumbnailCacheSize int64 `json:"thumbnail_cache_size" yaml:"thumbnail_cache_size" example:"268435456"`
+
+	thumbnailMu sync.Mutex
+	// thumbnailCacheTotal はキャッシュの合計サイズ（最初にサムネイルを保存したときに数え、以降は保存・削除のたびに増減する）
+	thumbnailCacheTotal   int64
+	thumbnailCacheCounted bool
 }
 
 // NewFileSystemService creates a new FileSystemService
@@ -33,14 +41,17 @@ func NewFileSystemService(root string) (*FileSystemService, error) {
 	}
 
 	return &FileSystemService{
-		Root: absPath,
+		Root:               absPath,
+		ThumbnailCacheSize: DefaultThumbnailCacheSize,
 	}, nil
 }
 
 // GetFileEntries gets the file entries from the file system
 func (s *FileSystemService) GetFileEntries(fsPath string) (*models.FileEntriesListResponse, error) {
-	// Expand ~ to home directory
-	fsPath = filepath.Join(s.Root, fsPath)
+	// Rootからの相対パスとして解決する（Root配下の絶対パスはそのまま使用）
+	if fsPath != s.Root && !strings.HasPrefix(fsPath, s.Root+string(filepath.Separator)) {
+		fsPath = filepath.Join(s.Root, fsPath)
+	}
 
 	absPath, err := filepath.Abs(fsPath)
 	if err != nil {
@@ -97,3 +108,57 @@ func (s *FileSystemService) GetFileEntries(fsPath string) (*models.FileEntriesLi
 		FileCount:   fileCount,
 	}, nil
 }
+
+// GetFileEntry は1つのファイルまたはディレクトリの情報を返す
+func (s *FileSystemService) GetFileEntry(path string) (*models.FileEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var id uint64
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		id = stat.Ino
+	}
+	return &models.FileEntry{
+		Id:           id,
+		Name:         info.Name(),
+		Path:         path,
+		IsDirectory:  info.IsDir(),
+		Size:         info.Size(),
+		ModifiedTime: models.NewTimestamp(info.ModTime()),
+	}, nil
+}
+
+// AddPhotoMetadata はファイルエントリのうちEXIFのあるJPEGに撮影情報を付ける
+// 写真ごとにファイルを開いて読み取るため、一覧の取得とは分けて必要な場合だけ呼び出す
+func (s *FileSystemService) AddPhotoMetadata(entries []models.FileEntry) {
+	for i := range entries {
+		if !entries[i].IsDirectory {
+			entries[i].Photo = photoMetadata(entries[i].Path)
+		}
+	}
+}
+
+// GetDirectorySize はディレクトリ配下の全ファイルサイズの合計を返す
+// 読み取れないエントリは無視する
+func (s *FileSystemService) GetDirectorySize(dirPath string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == dirPath {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
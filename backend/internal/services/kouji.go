@@ -1,23 +1,67 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"penguin-backend/internal/models"
 	"penguin-backend/internal/utils"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ErrKoujiNotFound は指定したIDの工事がない場合のエラー
+var ErrKoujiNotFound = errors.New("工事情報がデータベースにありません")
+
 // KoujiService は工事情報取得サービスを提供する
 type KoujiService struct {
 	FileSystemService *FileSystemService
 	FileSystemPath    string
 	DatabasePath      string
+	// TagVocabularyPath はタグの管理語彙を保存するYAMLファイルのパス
+	TagVocabularyPath string
+	// RulesPath は自動タグ付けルールを保存するYAMLファイルのパス
+	RulesPath string
+	// FieldSchemaPath はカスタムフィールドの定義を保存するYAMLファイルのパス
+	FieldSchemaPath string
+	// SettingsPath は工事管理の設定を保存するYAMLファイルのパス
+	SettingsPath string
+	// CompaniesPath は取引先会社の一覧を保存するYAMLファイルのパス
+	CompaniesPath string
+	// LocationsPath は現場の一覧を保存するYAMLファイルのパス
+	LocationsPath string
+	// WorkersPath は作業員の一覧を保存するYAMLファイルのパス
+	WorkersPath string
+	// TimesheetsPath は工数表を保存するYAMLファイルのパス
+	TimesheetsPath string
+	// MaterialsPath は資材マスターを保存するYAMLファイルのパス
+	MaterialsPath string
+	// StockPath は倉庫の入出庫を保存するYAMLファイルのパス
+	StockPath string
+	// DocumentsPath は見積書・請求書の設定を保存するYAMLファイルのパス
+	DocumentsPath string
+	// PhotoHashesPath は写真の知覚ハッシュを保存するYAMLファイルのパス
+	PhotoHashesPath string
+
+	// データベースなどのYAMLファイルはハンドラーから並行して更新されるため、読み込みから保存までを storeMu で直列にする
+	// storeMu を保持している間は SaveKoujiEntries ではなく saveKoujiEntries で保存する
+	storeMu sync.Mutex
+
+	// 設定はハンドラーから並行して読み書きされるため settingsMu で保護する
+	settingsMu sync.RWMutex
+	settings   models.KoujiSettings
+
+	// 写真の知覚ハッシュを計算するバックグラウンド処理の状況
+	photoHashMu  sync.Mutex
+	photoHashJob models.PhotoHashJobStatus
+	// 最後に計算したハッシュと、距離ごとに求めた重複のグループ（計算の完了時に作り直す）
+	photoDuplicates *photoDuplicateIndex
 }
 
 // NewKoujiService はKoujiServiceを初期化する
@@ -32,11 +76,27 @@ func NewKoujiService(fsService *FileSystemService, fsPath string) (*KoujiService
 	if err != nil {
 		return nil, err
 	}
-	return &KoujiService{
+	s := &KoujiService{
 		FileSystemService: fsService,
 		FileSystemPath:    fsPath,
 		DatabasePath:      absDbPath,
-	}, nil
+		TagVocabularyPath: filepath.Join(absFsPath, ".inside.tags.yaml"),
+		RulesPath:         filepath.Join(absFsPath, ".inside.rules.yaml"),
+		FieldSchemaPath:   filepath.Join(absFsPath, ".inside.fields.yaml"),
+		SettingsPath:      filepath.Join(absFsPath, ".inside.settings.yaml"),
+		CompaniesPath:     filepath.Join(absFsPath, ".inside.companies.yaml"),
+		LocationsPath:     filepath.Join(absFsPath, ".inside.locations.yaml"),
+		WorkersPath:       filepath.Join(absFsPath, ".inside.workers.yaml"),
+		TimesheetsPath:    filepath.Join(absFsPath, ".inside.timesheets.yaml"),
+		MaterialsPath:     filepath.Join(absFsPath, ".inside.materials.yaml"),
+		StockPath:         filepath.Join(absFsPath, ".inside.stock.yaml"),
+		DocumentsPath:     filepath.Join(absFsPath, ".inside.documents.yaml"),
+		PhotoHashesPath:   filepath.Join(absFsPath, ".inside.photo-hashes.yaml"),
+	}
+	if err := s.loadSettings(); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 func GetKoujiEntry(fileEntry models.FileEntry) (models.KoujiEntry, error) {
@@ -75,7 +135,8 @@ func GetKoujiEntry(fileEntry models.FileEntry) (models.KoujiEntry, error) {
 		EndDate:      startDate,
 		Status:       DetermineKoujiStatus(startDate, startDate),
 		Description:  companyName + "の" + locationName + "における工事プロジェクト",
-		Tags:         []string{"工事", companyName, locationName, startDate.Time.Format("2006")}, // Include year as tag
+		// タグは自動タグ付けルールで付与する
+		Tags: []string{},
 		// FileEntry: ファイルシステムから取得したフォルダー情報
 		FileEntry: fileEntry,
 	}
@@ -84,6 +145,7 @@ func GetKoujiEntry(fileEntry models.FileEntry) (models.KoujiEntry, error) {
 }
 
 // GetKoujiEntries は指定されたパスから工事一覧を取得する（ファイルシステムとデータベースをマージ）
+// 読み込みのみでデータベースには書き込まない。新しく見つかった工事は次に工事を更新したときに保存される
 func (s *KoujiService) GetKoujiEntries() []models.KoujiEntry {
 	// ファイルシステムから工事を取得
 	fsEntries := s.GetKoujiEntriesFromFileSystem()
@@ -109,24 +171,48 @@ func (s *KoujiService) GetKoujiEntries() []models.KoujiEntry {
 	// // Embed the base FileEntry struct
 	// FileEntry
 
+	// 自動タグ付けルールを読み込む（読み込めない場合は既定のルール）
+	rules, err := s.loadCompiledKoujiRules()
+	if err != nil {
+		log.Printf("自動タグ付けルールを読み込めません: %v", err)
+		rules, _ = compileKoujiRules(DefaultKoujiRules, nil)
+	}
+
+	// 会社名の別名を正式名に変換する（読み込めない場合はフォルダー名のまま）
+	companies, err := s.loadCompanyResolver()
+	if err != nil {
+		log.Printf("会社の一覧を読み込めません: %v", err)
+	}
+
 	// ファイルシステムの工事一覧を更新する
 	updatedEntries := make([]models.KoujiEntry, 0)
 	for _, fsEntry := range fsEntries {
-		if dbEntry, exists := dbEntryMap[fsEntry.Id]; exists {
+		fsEntry.CompanyName = companies.resolve(fsEntry.CompanyName)
+		dbEntry, exists := dbEntryMap[fsEntry.Id]
+		if exists {
 			// データベースに情報が存在しているときの処理
 			fsEntry.StartDate = dbEntry.StartDate
 			fsEntry.EndDate = dbEntry.EndDate
 			fsEntry.Description = dbEntry.Description
-			fsEntry.Status = DetermineKoujiStatus(fsEntry.StartDate, fsEntry.EndDate)
 			fsEntry.Tags = dbEntry.Tags
-			updatedEntries = append(updatedEntries, fsEntry)
+			fsEntry.CustomFields = dbEntry.CustomFields
+			fsEntry.Assignments = dbEntry.Assignments
+			fsEntry.Materials = dbEntry.Materials
+			fsEntry.EstimateAmount = dbEntry.EstimateAmount
+			fsEntry.OrderAmount = dbEntry.OrderAmount
+			fsEntry.Invoices = dbEntry.Invoices
 
 			// Remove from map so we don't add it again
 			delete(dbEntryMap, fsEntry.Id)
-		} else {
-			// New project from file system - add it
-			updatedEntries = append(updatedEntries, fsEntry)
 		}
+		fsEntry.FiscalYear = s.FiscalYear(fsEntry.StartDate.Time)
+		// ルールは新しく見つかった工事にのみ適用する（既存の工事はApplyKoujiRulesで明示的に適用する）
+		if !exists {
+			applyKoujiRules(&fsEntry, rules, true)
+			fsEntry.FiscalYear = s.FiscalYear(fsEntry.StartDate.Time)
+		}
+		fsEntry.Status = DetermineKoujiStatus(fsEntry.StartDate, fsEntry.EndDate)
+		updatedEntries = append(updatedEntries, fsEntry)
 	}
 
 	// 開始日の降順でソート（新しい順）
@@ -134,12 +220,19 @@ func (s *KoujiService) GetKoujiEntries() []models.KoujiEntry {
 		return updatedEntries[i].StartDate.Time.After(updatedEntries[j].StartDate.Time)
 	})
 
-	// データベースに保存
-	s.SaveKoujiEntries(updatedEntries)
-
 	return updatedEntries
 }
 
+// GetKoujiEntryByID は指定したIDの工事を返す
+func (s *KoujiService) GetKoujiEntryByID(id string) (models.KoujiEntry, error) {
+	for _, entry := range s.GetKoujiEntries() {
+		if entry.Id == id {
+			return entry, nil
+		}
+	}
+	return models.KoujiEntry{}, fmt.Errorf("%w: %s", ErrKoujiNotFound, id)
+}
+
 // GetKoujiEntriesFromFileSystem はファイルシステムから工事一覧を取得する
 func (s *KoujiService) GetKoujiEntriesFromFileSystem() []models.KoujiEntry {
 	// Get kouji fileEntries from file system
@@ -201,6 +294,9 @@ func DetermineKoujiStatus(startDate models.Timestamp, endDate models.Timestamp)
 
 // UpdateProjectDates はプロジェクトの開始日と終了日を更新する
 func (s *KoujiService) UpdateProjectDates(id string, startDate, endDate models.Timestamp) (models.KoujiEntry, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
 	// データベースから工事一覧を取得
 	dbEntries := s.GetKoujiEntriesFromDatabase()
 
@@ -217,22 +313,33 @@ func (s *KoujiService) UpdateProjectDates(id string, startDate, endDate models.T
 	}
 
 	if foundIndex == -1 {
-		return models.KoujiEntry{}, fmt.Errorf("工事情報がデータベースにありません: %s", id)
+		return models.KoujiEntry{}, fmt.Errorf("%w: %s", ErrKoujiNotFound, id)
 	}
 
 	// Save updated kouji entries to YAML
-	err := s.SaveKoujiEntries(dbEntries)
+	err := s.saveKoujiEntries(dbEntries)
 
 	// 更新した工事を返す
 	return dbEntries[foundIndex], err
 }
 
 // SaveKoujiEntries は引数のkoujiEntriesをデータベースに保存する
+// 一時ファイルに書き込んでから置き換えるため、途中で失敗しても既存のデータベースは壊れない
 func (s *KoujiService) SaveKoujiEntries(koujiEntries []models.KoujiEntry) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	return s.saveKoujiEntries(koujiEntries)
+}
+
+// saveKoujiEntries はstoreMuを保持した状態でデータベースに保存する
+func (s *KoujiService) saveKoujiEntries(koujiEntries []models.KoujiEntry) error {
+	// 開始日・年度の開始月の変更に合わせて、ルールが付与した年・年度のタグを置き換える
+	s.refreshDatedTags(koujiEntries)
+
 	yamlData, err := yaml.Marshal(koujiEntries)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.DatabasePath, yamlData, 0644)
+	return writeFileAtomic(s.DatabasePath, yamlData, 0644)
 }
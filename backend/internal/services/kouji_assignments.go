@@ -0,0 +1,288 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrAssignmentNotFound は指定したIDの割り当てがない場合のエラー
+var ErrAssignmentNotFound = errors.New("割り当てがありません")
+
+// GetKoujiAssignments は工事の割り当て一覧を返す
+func (s *KoujiService) GetKoujiAssignments(koujiId string) ([]models.KoujiAssignment, error) {
+	for _, entry := range s.GetKoujiEntries() {
+		if entry.Id == koujiId {
+			assignments := make([]models.KoujiAssignment, len(entry.Assignments))
+			for i, a := range entry.Assignments {
+				a.Id = assignmentID(koujiId, a)
+				assignments[i] = a
+			}
+			return assignments, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+}
+
+// AddKoujiAssignment は工事に割り当てを追加し、ダブルブッキングや資格・安全書類の期限切れなどの警告を返す
+func (s *KoujiService) AddKoujiAssignment(koujiId string, assignment models.KoujiAssignment) (models.KoujiAssignment, []string, error) {
+	return s.updateKoujiAssignments(koujiId, "", &assignment)
+}
+
+// UpdateKoujiAssignment は工事の割り当てを置き換え、ダブルブッキングなどの警告を返す
+func (s *KoujiService) UpdateKoujiAssignment(koujiId, assignmentId string, assignment models.KoujiAssignment) (models.KoujiAssignment, []string, error) {
+	return s.updateKoujiAssignments(koujiId, assignmentId, &assignment)
+}
+
+// DeleteKoujiAssignment は工事の割り当てを削除する
+func (s *KoujiService) DeleteKoujiAssignment(koujiId, assignmentId string) error {
+	_, _, err := s.updateKoujiAssignments(koujiId, assignmentId, nil)
+	return err
+}
+
+// updateKoujiAssignments は割り当てを追加（assignmentIdが空）・置換・削除（assignmentがnil）して保存する
+func (s *KoujiService) updateKoujiAssignments(koujiId, assignmentId string, assignment *models.KoujiAssignment) (models.KoujiAssignment, []string, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	entries := s.GetKoujiEntries()
+	index := -1
+	for i := range entries {
+		if entries[i].Id == koujiId {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return models.KoujiAssignment{}, nil, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+	}
+	entry := &entries[index]
+
+	assignments := make([]models.KoujiAssignment, 0, len(entry.Assignments)+1)
+	found := assignmentId == ""
+	for _, a := range entry.Assignments {
+		a.Id = assignmentID(koujiId, a)
+		if a.Id == assignmentId {
+			found = true
+			continue
+		}
+		assignments = append(assignments, a)
+	}
+	if !found {
+		return models.KoujiAssignment{}, nil, fmt.Errorf("%w: %s", ErrAssignmentNotFound, assignmentId)
+	}
+
+	var saved models.KoujiAssignment
+	var warnings []string
+	if assignment != nil {
+		if err := normalizeAssignment(assignment); err != nil {
+			return models.KoujiAssignment{}, nil, err
+		}
+		assignment.Id = assignmentID(koujiId, *assignment)
+		for _, a := range assignments {
+			if a.Id == assignment.Id {
+				return models.KoujiAssignment{}, nil, fmt.Errorf("同じ割り当てが既にあります: %s", assignment.Name)
+			}
+		}
+		assignments = append(assignments, *assignment)
+		saved = *assignment
+
+		updated := *entry
+		updated.Assignments = assignments
+		warnings = assignmentWarnings(entries, &updated, saved)
+		if workers, err := s.GetWorkers(); err == nil {
+			for _, issue := range qualificationIssues(&updated, saved, workers) {
+				warnings = append(warnings, issue.Message)
+			}
+		}
+	}
+	entry.Assignments = assignments
+	if len(entry.Assignments) == 0 {
+		entry.Assignments = nil
+	}
+	if err := s.saveKoujiEntries(entries); err != nil {
+		return models.KoujiAssignment{}, nil, err
+	}
+	return saved, warnings, nil
+}
+
+// GetWorkerSchedule は作業員・協力会社・機材の割り当てを期間の昇順で返す
+// from, toがゼロ値でない場合は期間が重なる割り当てのみを返す
+func (s *KoujiService) GetWorkerSchedule(name string, from, to time.Time) *models.WorkerScheduleResponse {
+	schedule := &models.WorkerScheduleResponse{
+		Name:     name,
+		Items:    make([]models.ScheduleItem, 0),
+		Warnings: make([]string, 0),
+	}
+	for _, entry := range s.GetKoujiEntries() {
+		for _, a := range entry.Assignments {
+			if !sameAssignee(a.Name, name) {
+				continue
+			}
+			start, end := AssignmentPeriod(&entry, a)
+			if (!from.IsZero() && end.Before(from)) || (!to.IsZero() && start.After(to)) {
+				continue
+			}
+			a.Id = assignmentID(entry.Id, a)
+			schedule.Items = append(schedule.Items, models.ScheduleItem{
+				KoujiId:      entry.Id,
+				CompanyName:  entry.CompanyName,
+				LocationName: entry.LocationName,
+				Assignment:   a,
+				From:         models.NewTimestamp(start),
+				To:           models.NewTimestamp(end),
+			})
+		}
+	}
+	sort.SliceStable(schedule.Items, func(i, j int) bool {
+		return schedule.Items[i].From.Time.Before(schedule.Items[j].From.Time)
+	})
+	for i := range schedule.Items {
+		for j := i + 1; j < len(schedule.Items); j++ {
+			a, b := schedule.Items[i], schedule.Items[j]
+			if b.From.Time.After(a.To.Time) {
+				break
+			}
+			if a.KoujiId != b.KoujiId {
+				schedule.Warnings = append(schedule.Warnings, doubleBookingMessage(name, b.From.Time, minTime(a.To.Time, b.To.Time), a.KoujiId, b.KoujiId))
+			}
+		}
+	}
+	return schedule
+}
+
+// GetAssignmentLoad はfromからtoまでの日ごとの割り当て状況を返す
+func (s *KoujiService) GetAssignmentLoad(from, to time.Time) ([]models.DailyLoad, error) {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("期間の終了日が開始日より前です")
+	}
+	if days := daysBetween(from, to); days > maxLoadDays {
+		return nil, fmt.Errorf("期間は%d日以内で指定してください", maxLoadDays)
+	}
+
+	entries := s.GetKoujiEntries()
+	loads := make([]models.DailyLoad, 0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		load := models.DailyLoad{Date: day.Format("2006-01-02"), ByKouji: make(map[string][]string)}
+		kouji := make(map[string]map[string]bool)
+		for _, entry := range entries {
+			for _, a := range entry.Assignments {
+				start, end := AssignmentPeriod(&entry, a)
+				if day.Before(truncateDay(start)) || day.After(truncateDay(end)) {
+					continue
+				}
+				load.Count++
+				load.ByKouji[entry.Id] = append(load.ByKouji[entry.Id], a.Name)
+				key := NormalizeTag(a.Name)
+				if kouji[key] == nil {
+					kouji[key] = make(map[string]bool)
+				}
+				kouji[key][entry.Id] = true
+				if len(kouji[key]) == 2 {
+					load.DoubleBooked = append(load.DoubleBooked, a.Name)
+				}
+			}
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
+
+// maxLoadDays は日ごとの割り当て状況で指定できる最大日数
+const maxLoadDays = 366
+
+// AssignmentPeriod は割り当ての実際の期間を返す（省略された端は工事の開始日・終了日）
+func AssignmentPeriod(entry *models.KoujiEntry, a models.KoujiAssignment) (time.Time, time.Time) {
+	from, to := a.From.Time, a.To.Time
+	if from.IsZero() {
+		from = entry.StartDate.Time
+	}
+	if to.IsZero() {
+		to = entry.EndDate.Time
+	}
+	return from, to
+}
+
+// normalizeAssignment は割り当てを検証し、種類の既定値を設定する
+func normalizeAssignment(a *models.KoujiAssignment) error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return fmt.Errorf("割り当てる名前が空です")
+	}
+	switch a.Kind {
+	case "":
+		a.Kind = models.AssignmentKindWorker
+	case models.AssignmentKindWorker, models.AssignmentKindSubcontractor, models.AssignmentKindEquipment:
+	default:
+		return fmt.Errorf("未対応の種類です: %s", a.Kind)
+	}
+	if !a.From.Time.IsZero() && !a.To.Time.IsZero() && a.To.Time.Before(a.From.Time) {
+		return fmt.Errorf("%s: 割り当ての終了日が開始日より前です", a.Name)
+	}
+	return nil
+}
+
+// assignmentWarnings は保存する割り当てについての警告を返す
+// 工事の期間外の割り当てと、同じ名前の期間が重複する他の工事の割り当て（ダブルブッキング）を警告する
+func assignmentWarnings(entries []models.KoujiEntry, entry *models.KoujiEntry, a models.KoujiAssignment) []string {
+	warnings := make([]string, 0)
+	from, to := AssignmentPeriod(entry, a)
+	if from.Before(entry.StartDate.Time) || to.After(entry.EndDate.Time) {
+		warnings = append(warnings, fmt.Sprintf("%s の割り当て期間が工事の期間外です", a.Name))
+	}
+	for i := range entries {
+		other := &entries[i]
+		if other.Id == entry.Id {
+			continue
+		}
+		for _, b := range other.Assignments {
+			if !sameAssignee(a.Name, b.Name) {
+				continue
+			}
+			start, end := AssignmentPeriod(other, b)
+			if start.After(to) || end.Before(from) {
+				continue
+			}
+			warnings = append(warnings, doubleBookingMessage(a.Name, maxTime(from, start), minTime(to, end), entry.Id, other.Id))
+		}
+	}
+	return warnings
+}
+
+// assignmentID は工事ID・名前・種類・期間から割り当てのIDを生成する
+func assignmentID(koujiId string, a models.KoujiAssignment) string {
+	source := strings.Join([]string{koujiId, a.Name, a.Kind, formatImportDate(a.From), formatImportDate(a.To)}, "\x00")
+	return models.NewIDFromString(source).Len5()
+}
+
+func sameAssignee(a, b string) bool {
+	return NormalizeTag(a) == NormalizeTag(b)
+}
+
+func doubleBookingMessage(name string, from, to time.Time, koujiIds ...string) string {
+	return fmt.Sprintf("%s は %s〜%s に工事 %s で重複して割り当てられています",
+		name, from.Format("2006-01-02"), to.Format("2006-01-02"), strings.Join(koujiIds, ", "))
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAssignmentWarnings(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	entries := []models.KoujiEntry{
+		{Id: "A", StartDate: date(2030, 5, 1), EndDate: date(2030, 5, 31)},
+		{Id: "B", StartDate: date(2030, 5, 1), EndDate: date(2030, 5, 31), Assignments: []models.KoujiAssignment{
+			{Name: "山田 太郎", Kind: models.AssignmentKindWorker, From: date(2030, 5, 20), To: date(2030, 5, 25)},
+		}},
+	}
+
+	tests := []struct {
+		assignment models.KoujiAssignment
+		want       int
+	}{
+		// Bの割り当て期間（5/20〜5/25）と重ならない
+		{models.KoujiAssignment{Name: "山田 太郎", From: date(2030, 5, 1), To: date(2030, 5, 19)}, 0},
+		// 工事の期間全体（空白の違いは同じ人として扱う）
+		{models.KoujiAssignment{Name: "山田太郎"}, 1},
+		// 工事の期間外かつ重複
+		{models.KoujiAssignment{Name: "山田 太郎", From: date(2030, 5, 25), To: date(2030, 6, 5)}, 2},
+	}
+	for _, tt := range tests {
+		if got := assignmentWarnings(entries, &entries[0], tt.assignment); len(got) != tt.want {
+			t.Errorf("assignmentWarnings(%s %s..%s) = %v, want %d warnings", tt.assignment.Name,
+				formatImportDate(tt.assignment.From), formatImportDate(tt.assignment.To), got, tt.want)
+		}
+	}
+}
+
+func TestKoujiAssignmentCRUD(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場", "2099-06-18 愛知製鋼 知多工場")
+	entries := s.GetKoujiEntries()
+	if len(entries) != 2 {
+		t.Fatalf("GetKoujiEntries() = %d entries, want 2", len(entries))
+	}
+	a, b := entries[0].Id, entries[1].Id
+	if _, err := s.CreateWorker(models.Worker{Name: "山田 太郎"}); err != nil {
+		t.Fatal(err)
+	}
+
+	added, warnings, err := s.AddKoujiAssignment(a, models.KoujiAssignment{Name: " 山田 太郎 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Name != "山田 太郎" || added.Kind != models.AssignmentKindWorker || added.Id == "" || len(warnings) != 0 {
+		t.Fatalf("AddKoujiAssignment() = %+v, %v", added, warnings)
+	}
+	if _, _, err := s.AddKoujiAssignment(a, models.KoujiAssignment{Name: "山田 太郎"}); err == nil {
+		t.Error("adding the same assignment twice succeeded, want error")
+	}
+	if _, _, err := s.AddKoujiAssignment(a, models.KoujiAssignment{Name: "足場", Kind: "crane"}); err == nil {
+		t.Error("adding an assignment of an unknown kind succeeded, want error")
+	}
+
+	// 空白の違う同じ作業員を同じ日の別の工事に割り当てるとダブルブッキングを警告する
+	_, warnings, err = s.AddKoujiAssignment(b, models.KoujiAssignment{Name: "山田太郎"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %v, want one double booking", warnings)
+	}
+	conflicts, err := s.GetKoujiConflicts(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Type != models.ConflictTypeResourceOverlap {
+		t.Errorf("GetKoujiConflicts() = %+v, want one resource overlap", conflicts)
+	}
+
+	// 置き換えると期間が変わるためIDも変わる
+	from := models.NewTimestamp(time.Date(2099, 6, 18, 0, 0, 0, 0, time.Local))
+	updated, _, err := s.UpdateKoujiAssignment(a, added.Id, models.KoujiAssignment{Name: "山田 太郎", From: from, To: from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Id == added.Id {
+		t.Error("UpdateKoujiAssignment() kept the old id")
+	}
+	assignments, err := s.GetKoujiAssignments(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assignments) != 1 || assignments[0].Id != updated.Id {
+		t.Fatalf("GetKoujiAssignments() = %+v, want the updated assignment", assignments)
+	}
+
+	if err := s.DeleteKoujiAssignment(a, added.Id); !errors.Is(err, ErrAssignmentNotFound) {
+		t.Errorf("deleting a replaced assignment: err = %v, want ErrAssignmentNotFound", err)
+	}
+	if err := s.DeleteKoujiAssignment(a, updated.Id); err != nil {
+		t.Fatal(err)
+	}
+	if assignments, _ := s.GetKoujiAssignments(a); len(assignments) != 0 {
+		t.Errorf("assignments after delete = %+v, want none", assignments)
+	}
+	if _, err := s.GetKoujiAssignments("XXXXX"); !errors.Is(err, ErrKoujiNotFound) {
+		t.Errorf("GetKoujiAssignments(unknown) err = %v, want ErrKoujiNotFound", err)
+	}
+}
+
+func TestKoujiAssignmentConcurrentAdds(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+
+	// 同時に追加しても、どの割り当ても失われない
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := s.AddKoujiAssignment(id, models.KoujiAssignment{Name: fmt.Sprintf("作業員%02d", i)})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := s.GetKoujiAssignments(id); err != nil || len(got) != n {
+		t.Errorf("assignments after concurrent adds = %d, %v; want %d", len(got), err, n)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"penguin-backend/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// KoujiCalendarName はiCalendarフィードのカレンダー名
+const KoujiCalendarName = "工事予定"
+
+// KoujiCalendarTimezone はカレンダーの表示に使うタイムゾーン（予定は日付のみの終日イベント）
+const KoujiCalendarTimezone = "Asia/Tokyo"
+
+// WriteKoujiCalendar は工事一覧をiCalendar(RFC 5545)形式で書き出す
+// 各工事は開始日から終了日までの終日イベントとして出力する
+// uiBaseURL が空でない場合は各イベントにUIへのリンクを付与する
+func WriteKoujiCalendar(w io.Writer, entries []models.KoujiEntry, uiBaseURL string) error {
+	cw := &icalWriter{w: bufio.NewWriter(w)}
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//Penguin//Kouji Calendar//JA")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.line("X-WR-CALNAME:" + icalEscape(KoujiCalendarName))
+	cw.line("X-WR-TIMEZONE:" + KoujiCalendarTimezone)
+
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		if entry.StartDate.Time.IsZero() {
+			continue
+		}
+		start := entry.StartDate.Time
+		end := entry.EndDate.Time
+		if end.Before(start) {
+			end = start
+		}
+
+		stamp := entry.ModifiedTime.Time
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		summary := strings.TrimSpace(entry.CompanyName + " " + entry.LocationName)
+		description := []string{
+			"会社: " + entry.CompanyName,
+			"現場: " + entry.LocationName,
+			"状態: " + entry.Status,
+		}
+		if entry.Description != "" {
+			description = append(description, entry.Description)
+		}
+
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + icalEscape(KoujiCalendarUID(entry.Id)))
+		cw.line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		// 終日イベントのDTENDは翌日（排他的）
+		cw.line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		cw.line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format("20060102"))
+		cw.line("SUMMARY:" + icalEscape(summary))
+		cw.line("LOCATION:" + icalEscape(entry.LocationName))
+		if uiBaseURL != "" {
+			link := strings.TrimRight(uiBaseURL, "/") + "/kouji?id=" + url.QueryEscape(entry.Id)
+			description = append(description, link)
+			cw.line("URL:" + link)
+		}
+		cw.line("DESCRIPTION:" + icalEscape(strings.Join(description, "\n")))
+		if len(entry.Tags) > 0 {
+			categories := make([]string, len(entry.Tags))
+			for i, tag := range entry.Tags {
+				categories[i] = icalEscape(tag)
+			}
+			cw.line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		cw.line("STATUS:" + icalStatus(entry.Status))
+		cw.line("TRANSP:TRANSPARENT")
+		cw.line("END:VEVENT")
+	}
+
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// KoujiCalendarUID は工事IDから安定したイベントUIDを生成する
+func KoujiCalendarUID(id string) string {
+	return fmt.Sprintf("kouji-%s@penguin", id)
+}
+
+// icalStatus は工事の状態をVEVENTのSTATUSに変換する
+func icalStatus(status string) string {
+	switch status {
+	case "予定":
+		return "TENTATIVE"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// icalEscape はTEXT値の特殊文字をエスケープする
+func icalEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// icalWriter はCRLF改行と75オクテットでの行折り返しを行う
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *icalWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	const limit = 75
+	width := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		if width+size > limit {
+			// 継続行は先頭の空白1文字分を含めて75オクテット
+			if _, cw.err = cw.w.WriteString("\r\n "); cw.err != nil {
+				return
+			}
+			width = 1
+		}
+		if _, cw.err = cw.w.WriteString(s[:size]); cw.err != nil {
+			return
+		}
+		width += size
+		s = s[size:]
+	}
+	_, cw.err = cw.w.WriteString("\r\n")
+}
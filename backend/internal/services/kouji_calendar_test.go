@@ -0,0 +1,43 @@
+package services
+
+import (
+	"bytes"
+	"penguin-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteKoujiCalendar(t *testing.T) {
+	entries := []models.KoujiEntry{{
+		Id:           "B3PXU",
+		CompanyName:  "豊田築炉",
+		LocationName: "名和工場",
+		StartDate:    models.NewTimestamp(time.Date(2099, 6, 18, 0, 0, 0, 0, time.Local)),
+		EndDate:      models.NewTimestamp(time.Date(2099, 6, 20, 0, 0, 0, 0, time.Local)),
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteKoujiCalendar(&buf, entries, "https://penguin.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	ics := buf.String()
+	for _, want := range []string{
+		"X-WR-TIMEZONE:Asia/Tokyo\r\n",
+		"DTSTART;VALUE=DATE:20990618\r\n",
+		"DTEND;VALUE=DATE:20990621\r\n",
+		"URL:https://penguin.example.com/kouji?id=B3PXU\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar does not contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteKoujiCalendar(&buf, entries, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "URL:") {
+		t.Error("calendar without UI base URL has links")
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"strings"
+)
+
+// ErrCompanyNotFound は会社が登録されていない場合のエラー
+var ErrCompanyNotFound = errors.New("会社が登録されていません")
+
+// companySuffixes は会社名の照合時に無視する法人格の表記
+var companySuffixes = []string{"株式会社", "(株)", "㈱", "有限会社", "(有)", "㈲", "合同会社", "(同)"}
+
+// GetCompanies は登録されている会社の一覧を返す
+func (s *KoujiService) GetCompanies() ([]models.Company, error) {
+	companies := []models.Company{}
+	if err := loadYAMLFile(s.CompaniesPath, &companies); err != nil {
+		return nil, fmt.Errorf("会社の一覧を読み込めません: %w", err)
+	}
+	return companies, nil
+}
+
+// GetCompany は正式名または別名で会社を検索する
+func (s *KoujiService) GetCompany(name string) (models.Company, error) {
+	companies, err := s.GetCompanies()
+	if err != nil {
+		return models.Company{}, err
+	}
+	if i := findCompany(companies, name); i >= 0 {
+		return companies[i], nil
+	}
+	return models.Company{}, fmt.Errorf("%w: %s", ErrCompanyNotFound, name)
+}
+
+// CreateCompany は会社を登録する
+func (s *KoujiService) CreateCompany(company models.Company) (models.Company, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	companies, err := s.GetCompanies()
+	if err != nil {
+		return models.Company{}, err
+	}
+	companies = append(companies, company)
+	if err := s.saveCompanies(companies); err != nil {
+		return models.Company{}, err
+	}
+	return companies[len(companies)-1], nil
+}
+
+// UpdateCompany は正式名または別名で指定した会社を更新する
+func (s *KoujiService) UpdateCompany(name string, company models.Company) (models.Company, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	companies, err := s.GetCompanies()
+	if err != nil {
+		return models.Company{}, err
+	}
+	i := findCompany(companies, name)
+	if i < 0 {
+		return models.Company{}, fmt.Errorf("%w: %s", ErrCompanyNotFound, name)
+	}
+	companies[i] = company
+	if err := s.saveCompanies(companies); err != nil {
+		return models.Company{}, err
+	}
+	return companies[i], nil
+}
+
+// DeleteCompany は正式名または別名で指定した会社を削除する
+func (s *KoujiService) DeleteCompany(name string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	companies, err := s.GetCompanies()
+	if err != nil {
+		return err
+	}
+	i := findCompany(companies, name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrCompanyNotFound, name)
+	}
+	return s.saveCompanies(append(companies[:i], companies[i+1:]...))
+}
+
+// GetCompanyKoujiEntries は会社の工事一覧を返す
+func (s *KoujiService) GetCompanyKoujiEntries(name string) (models.Company, []models.KoujiEntry, error) {
+	company, err := s.GetCompany(name)
+	if err != nil {
+		return models.Company{}, nil, err
+	}
+	entries := make([]models.KoujiEntry, 0)
+	for _, entry := range s.GetKoujiEntries() {
+		if entry.CompanyName == company.Name {
+			entries = append(entries, entry)
+		}
+	}
+	return company, entries, nil
+}
+
+// companyResolver はフォルダー名の会社名を正式名に変換する
+type companyResolver map[string]string
+
+// loadCompanyResolver は登録されている会社の正式名と別名から変換表を作成する
+func (s *KoujiService) loadCompanyResolver() (companyResolver, error) {
+	companies, err := s.GetCompanies()
+	if err != nil {
+		return nil, err
+	}
+	resolver := make(companyResolver)
+	for _, company := range companies {
+		resolver[NormalizeCompanyName(company.Name)] = company.Name
+		for _, alias := range company.Aliases {
+			resolver[NormalizeCompanyName(alias)] = company.Name
+		}
+	}
+	return resolver, nil
+}
+
+// resolve は会社名を正式名に変換する（登録されていない場合はそのまま返す）
+func (r companyResolver) resolve(name string) string {
+	if canonical, ok := r[NormalizeCompanyName(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// NormalizeCompanyName は会社名を照合用に正規化する
+// 空白・全角半角・大文字小文字の違いと法人格の表記（株式会社, (株) など）を無視する
+func NormalizeCompanyName(name string) string {
+	normalized := NormalizeTag(name)
+	for _, suffix := range companySuffixes {
+		normalized = strings.ReplaceAll(normalized, suffix, "")
+	}
+	return normalized
+}
+
+// saveCompanies は会社の一覧を検証して保存する
+// 正式名と別名は正規化した上で会社間で重複してはならない
+func (s *KoujiService) saveCompanies(companies []models.Company) error {
+	owners := make(map[string]string)
+	for i := range companies {
+		company := &companies[i]
+		company.Name = strings.TrimSpace(company.Name)
+		if company.Name == "" {
+			return fmt.Errorf("会社名が空です")
+		}
+		aliases := make([]string, 0, len(company.Aliases))
+		for _, alias := range company.Aliases {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+		company.Aliases = aliases
+
+		for _, name := range append([]string{company.Name}, company.Aliases...) {
+			key := NormalizeCompanyName(name)
+			if owner, ok := owners[key]; ok && owner != company.Name {
+				return fmt.Errorf("%s は %s と重複しています", name, owner)
+			}
+			owners[key] = company.Name
+		}
+	}
+	return saveYAMLFile(s.CompaniesPath, companies)
+}
+
+// findCompany は正式名または別名に一致する会社の位置を返す（見つからない場合は-1）
+func findCompany(companies []models.Company, name string) int {
+	key := NormalizeCompanyName(name)
+	for i, company := range companies {
+		if NormalizeCompanyName(company.Name) == key {
+			return i
+		}
+		for _, alias := range company.Aliases {
+			if NormalizeCompanyName(alias) == key {
+				return i
+			}
+		}
+	}
+	return -1
+}
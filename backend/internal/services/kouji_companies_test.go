@@ -0,0 +1,38 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"testing"
+)
+
+func TestCompanyResolver(t *testing.T) {
+	s := &KoujiService{CompaniesPath: t.TempDir() + "/.inside.companies.yaml"}
+	if _, err := s.CreateCompany(models.Company{Name: "豊田築炉", Aliases: []string{"トヨタ築炉"}}); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := s.loadCompanyResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"豊田築炉", "豊田築炉"},
+		{"豊田築炉(株)", "豊田築炉"},
+		{"株式会社豊田築炉", "豊田築炉"},
+		{"トヨタ築炉", "豊田築炉"},
+		{"ﾄﾖﾀ築炉", "豊田築炉"},
+		{"愛知製鋼", "愛知製鋼"},
+	}
+	for _, tt := range tests {
+		if got := resolver.resolve(tt.name); got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := s.CreateCompany(models.Company{Name: "トヨタ築炉(株)"}); err == nil {
+		t.Errorf("CreateCompany with a duplicated alias should fail")
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"penguin-backend/internal/models"
+	"sort"
+	"time"
+)
+
+// GetKoujiConflicts は絞り込み条件に一致する工事の工程の競合を返す
+// includeCompletedがfalseの場合、完了した工事同士の重複は含めない
+func (s *KoujiService) GetKoujiConflicts(filter *KoujiFilter, includeCompleted bool) ([]models.KoujiConflict, error) {
+	locations, err := s.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+	return DetectKoujiConflicts(filter.Apply(s.GetKoujiEntries()), locations, includeCompleted), nil
+}
+
+// DetectKoujiConflicts は工事の工程の競合を検出する
+//   - invalid_dates: 終了日が開始日より前の工事
+//   - location_overlap: 同じ現場で工期が重複している工事
+//   - resource_overlap: 同じ作業員・協力会社・機材の割り当て期間が重複している工事
+//
+// 工期は開始日と終了日を含む日単位で比較する
+func DetectKoujiConflicts(entries []models.KoujiEntry, locations []models.Location, includeCompleted bool) []models.KoujiConflict {
+	conflicts := make([]models.KoujiConflict, 0)
+
+	valid := make([]models.KoujiEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.StartDate.Time.IsZero() {
+			continue
+		}
+		if entry.EndDate.Time.Before(entry.StartDate.Time) {
+			conflicts = append(conflicts, models.KoujiConflict{
+				Type:     models.ConflictTypeInvalidDates,
+				KoujiIds: []string{entry.Id},
+				From:     entry.StartDate,
+				To:       entry.EndDate,
+				Message:  "終了日が開始日より前です",
+			})
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].StartDate.Time.Before(valid[j].StartDate.Time)
+	})
+
+	// 同じ現場の工事同士を比較する
+	for _, site := range groupKoujiBySite(valid, locations) {
+		subject := site.location.CompanyName + " " + site.location.LocationName
+		spans := make([]scheduleSpan, 0, len(site.entries))
+		for _, entry := range site.entries {
+			spans = append(spans, scheduleSpan{entry: entry, from: entry.StartDate.Time, to: entry.EndDate.Time})
+		}
+		forEachOverlap(spans, includeCompleted, func(a, b models.KoujiEntry, from, to time.Time) {
+			conflicts = append(conflicts, models.KoujiConflict{
+				Type:     models.ConflictTypeLocationOverlap,
+				KoujiIds: []string{a.Id, b.Id},
+				Subject:  subject,
+				From:     models.NewTimestamp(from),
+				To:       models.NewTimestamp(to),
+				Message:  "同じ現場で工期が重複しています",
+			})
+		})
+	}
+
+	// 同じ作業員・協力会社・機材が割り当てられた工事同士を割り当て期間で比較する
+	// 名前は空白などの表記の違いを除いて比較し、最初に見つかった表記で表示する
+	byResource := make(map[string][]scheduleSpan)
+	var resources []string
+	names := make(map[string]string)
+	for _, entry := range valid {
+		for _, assignment := range entry.Assignments {
+			key := NormalizeTag(assignment.Name)
+			if key == "" {
+				continue
+			}
+			if _, ok := byResource[key]; !ok {
+				resources = append(resources, key)
+				names[key] = assignment.Name
+			}
+			from, to := AssignmentPeriod(&entry, assignment)
+			byResource[key] = append(byResource[key], scheduleSpan{entry: entry, from: from, to: to})
+		}
+	}
+	for _, key := range resources {
+		spans := byResource[key]
+		resource := names[key]
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].from.Before(spans[j].from) })
+		forEachOverlap(spans, includeCompleted, func(a, b models.KoujiEntry, from, to time.Time) {
+			conflicts = append(conflicts, models.KoujiConflict{
+				Type:     models.ConflictTypeResourceOverlap,
+				KoujiIds: []string{a.Id, b.Id},
+				Subject:  resource,
+				From:     models.NewTimestamp(from),
+				To:       models.NewTimestamp(to),
+				Message:  fmt.Sprintf("%s が工期の重複する工事に割り当てられています", resource),
+			})
+		})
+	}
+	return conflicts
+}
+
+// scheduleSpan は工事の期間または割り当ての期間
+type scheduleSpan struct {
+	entry    models.KoujiEntry
+	from, to time.Time
+}
+
+// forEachOverlap は開始日の昇順に並んだ期間のうち、別の工事同士で重複する組ごとにfnを呼ぶ
+func forEachOverlap(spans []scheduleSpan, includeCompleted bool, fn func(a, b models.KoujiEntry, from, to time.Time)) {
+	for i := range spans {
+		for j := i + 1; j < len(spans); j++ {
+			a, b := spans[i], spans[j]
+			if b.from.After(a.to) {
+				// 以降の期間はさらに開始日が遅いため、aとは重複しない
+				break
+			}
+			if a.entry.Id == b.entry.Id {
+				continue
+			}
+			if !includeCompleted && a.entry.Status == "完了" && b.entry.Status == "完了" {
+				continue
+			}
+			fn(a.entry, b.entry, b.from, minTime(a.to, b.to))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestDetectKoujiConflicts(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	crew := func(names ...string) []models.KoujiAssignment {
+		var assignments []models.KoujiAssignment
+		for _, name := range names {
+			assignments = append(assignments, models.KoujiAssignment{Name: name, Kind: models.AssignmentKindWorker})
+		}
+		return assignments
+	}
+	entries := []models.KoujiEntry{
+		{Id: "A", CompanyName: "豊田築炉", LocationName: "名和工場", Status: "予定",
+			StartDate: date(2030, 5, 1), EndDate: date(2030, 5, 10), Assignments: crew("山田")},
+		{Id: "B", CompanyName: "豊田築炉", LocationName: "名和工場 3号炉", Status: "予定",
+			StartDate: date(2030, 5, 10), EndDate: date(2030, 5, 20)},
+		{Id: "C", CompanyName: "愛知製鋼", LocationName: "知多工場", Status: "予定",
+			StartDate: date(2030, 5, 5), EndDate: date(2030, 5, 6), Assignments: crew("山田", "鈴木")},
+		{Id: "D", CompanyName: "愛知製鋼", LocationName: "刈谷工場", Status: "予定",
+			StartDate: date(2030, 6, 5), EndDate: date(2030, 6, 1)},
+		{Id: "E", CompanyName: "愛知製鋼", LocationName: "知多工場", Status: "完了",
+			StartDate: date(2020, 5, 5), EndDate: date(2020, 5, 6), Assignments: crew("鈴木")},
+		{Id: "F", CompanyName: "愛知製鋼", LocationName: "知多工場", Status: "完了",
+			StartDate: date(2020, 5, 6), EndDate: date(2020, 5, 7), Assignments: crew("鈴木")},
+	}
+
+	counts := func(conflicts []models.KoujiConflict) map[string]int {
+		m := make(map[string]int)
+		for _, c := range conflicts {
+			m[c.Type]++
+		}
+		return m
+	}
+
+	got := counts(DetectKoujiConflicts(entries, nil, false))
+	want := map[string]int{
+		models.ConflictTypeInvalidDates:    1,
+		models.ConflictTypeLocationOverlap: 1,
+		models.ConflictTypeResourceOverlap: 1,
+	}
+	for typ, n := range want {
+		if got[typ] != n {
+			t.Errorf("%s conflicts = %d, want %d", typ, got[typ], n)
+		}
+	}
+
+	got = counts(DetectKoujiConflicts(entries, nil, true))
+	if got[models.ConflictTypeLocationOverlap] != 2 || got[models.ConflictTypeResourceOverlap] != 2 {
+		t.Errorf("with completed: %v, want 2 location and 2 resource overlaps", got)
+	}
+}
+
+func TestDetectKoujiConflictsNormalizesNames(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	entries := []models.KoujiEntry{
+		{Id: "A", CompanyName: "豊田築炉", LocationName: "名和工場", Status: "予定",
+			StartDate: date(2030, 5, 1), EndDate: date(2030, 5, 10),
+			Assignments: []models.KoujiAssignment{{Name: "山田 太郎", Kind: models.AssignmentKindWorker}}},
+		{Id: "B", CompanyName: "愛知製鋼", LocationName: "知多工場", Status: "予定",
+			StartDate: date(2030, 5, 8), EndDate: date(2030, 5, 12),
+			Assignments: []models.KoujiAssignment{{Name: "山田太郎", Kind: models.AssignmentKindWorker}}},
+	}
+
+	conflicts := DetectKoujiConflicts(entries, nil, false)
+	if len(conflicts) != 1 || conflicts[0].Type != models.ConflictTypeResourceOverlap {
+		t.Fatalf("DetectKoujiConflicts() = %+v, want one resource overlap", conflicts)
+	}
+	c := conflicts[0]
+	if c.Subject != "山田 太郎" || !c.From.Time.Equal(date(2030, 5, 8).Time) || !c.To.Time.Equal(date(2030, 5, 10).Time) {
+		t.Errorf("conflict = %s %s..%s, want 山田 太郎 2030-05-08..2030-05-10",
+			c.Subject, formatImportDate(c.From), formatImportDate(c.To))
+	}
+}
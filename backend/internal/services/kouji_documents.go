@@ -0,0 +1,561 @@
+package services
+
+import (
+	"cmp"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bundledFonts はビルド時に同梱する帳票用フォント（fonts/README.md を参照）
+//
+//go:embed fonts
+var bundledFonts embed.FS
+
+// ErrDocumentFontMissing は帳票に埋め込む日本語フォントがない場合のエラー
+var ErrDocumentFontMissing = errors.New("帳票用のフォントがありません。services/fonts にTrueTypeフォントを同梱するか、帳票の設定の font_path を指定してください")
+
+// DefaultDocumentSettings は設定ファイルがない場合の帳票の設定
+var DefaultDocumentSettings = models.DocumentSettings{
+	Estimate: models.DocumentTemplate{
+		Title:      "御見積書",
+		Message:    "下記の通り御見積申し上げます。",
+		ValidDays:  30,
+		FilePrefix: "見積書",
+	},
+	Invoice: models.DocumentTemplate{
+		Title:           "御請求書",
+		Message:         "下記の通り御請求申し上げます。",
+		ShowBankDetails: true,
+		FilePrefix:      "請求書",
+	},
+}
+
+// GetDocumentSettings は見積書・請求書の設定を返す
+func (s *KoujiService) GetDocumentSettings() (models.DocumentSettings, error) {
+	settings := DefaultDocumentSettings
+	if err := loadYAMLFile(s.DocumentsPath, &settings); err != nil {
+		return models.DocumentSettings{}, fmt.Errorf("帳票の設定を読み込めません: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveDocumentSettings は見積書・請求書の設定を検証して保存する
+func (s *KoujiService) SaveDocumentSettings(settings models.DocumentSettings) error {
+	for _, t := range []*models.DocumentTemplate{&settings.Estimate, &settings.Invoice} {
+		if t.ValidDays < 0 {
+			return fmt.Errorf("有効期限の日数は0以上で指定してください: %d", t.ValidDays)
+		}
+		if strings.ContainsAny(t.FilePrefix, `/\`) {
+			return fmt.Errorf("ファイル名の接頭辞に使用できない文字が含まれています: %s", t.FilePrefix)
+		}
+	}
+	if settings.Estimate.Title == "" {
+		settings.Estimate.Title = DefaultDocumentSettings.Estimate.Title
+	}
+	if settings.Estimate.FilePrefix == "" {
+		settings.Estimate.FilePrefix = DefaultDocumentSettings.Estimate.FilePrefix
+	}
+	if settings.Invoice.Title == "" {
+		settings.Invoice.Title = DefaultDocumentSettings.Invoice.Title
+	}
+	if settings.Invoice.FilePrefix == "" {
+		settings.Invoice.FilePrefix = DefaultDocumentSettings.Invoice.FilePrefix
+	}
+	if settings.FontPath != "" {
+		data, err := os.ReadFile(s.documentAssetPath(settings.FontPath))
+		if err != nil {
+			return fmt.Errorf("フォントを読み込めません: %w", err)
+		}
+		if _, err := utils.ParseTrueTypeFont(data); err != nil {
+			return err
+		}
+	}
+	if settings.Issuer.SealImage != "" {
+		if _, err := os.Stat(s.documentAssetPath(settings.Issuer.SealImage)); err != nil {
+			return fmt.Errorf("印影の画像を読み込めません: %w", err)
+		}
+	}
+	return saveYAMLFile(s.DocumentsPath, settings)
+}
+
+// CreateEstimateDocument は工事の見積書のPDFを作成して工事フォルダーに保存する
+// 明細を省略した場合は見積金額の一式とする
+func (s *KoujiService) CreateEstimateDocument(koujiId string, req models.DocumentRequest) (*models.FileEntry, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.GetDocumentSettings()
+	if err != nil {
+		return nil, err
+	}
+	if req.IssueDate == "" {
+		req.IssueDate = time.Now().Format("2006-01-02")
+	}
+	if len(req.LineItems) == 0 {
+		if entry.EstimateAmount == 0 {
+			return nil, fmt.Errorf("見積金額が未設定のため明細を指定してください")
+		}
+		req.LineItems = []models.DocumentLineItem{{Name: "工事一式", Quantity: 1, Unit: "式", UnitPrice: entry.EstimateAmount}}
+	}
+
+	doc := documentContent{
+		kind:     models.DocumentKindEstimate,
+		template: settings.Estimate,
+		entry:    &entry,
+		req:      req,
+		taxRate:  s.TaxRateOn(req.IssueDate),
+	}
+	if settings.Estimate.ValidDays > 0 {
+		if issued, err := time.Parse("2006-01-02", req.IssueDate); err == nil {
+			doc.dueLabel = "有効期限"
+			doc.dueDate = issued.AddDate(0, 0, settings.Estimate.ValidDays).Format("2006-01-02")
+		}
+	}
+	base := joinFileNameParts(settings.Estimate.FilePrefix, req.Number, entry.CompanyName, entry.LocationName)
+	return s.writeDocument(&entry, settings, doc, base)
+}
+
+// CreateInvoiceDocument は工事の請求のPDFを作成して工事フォルダーに保存する
+// 明細の合計は請求金額（税抜）と一致する必要がある
+func (s *KoujiService) CreateInvoiceDocument(koujiId, invoiceId string, req models.DocumentRequest) (*models.FileEntry, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(entry.Invoices, func(inv models.KoujiInvoice) bool { return inv.Id == invoiceId })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceId)
+	}
+	invoice := entry.Invoices[i]
+	settings, err := s.GetDocumentSettings()
+	if err != nil {
+		return nil, err
+	}
+	if req.IssueDate == "" {
+		req.IssueDate = invoice.IssueDate
+	}
+	if invoice.Number != "" {
+		req.Number = invoice.Number
+	}
+	if len(req.LineItems) == 0 {
+		req.LineItems = []models.DocumentLineItem{{Name: "工事一式", Quantity: 1, Unit: "式", UnitPrice: invoice.Amount}}
+		if invoice.Note != "" {
+			req.LineItems[0].Name = "工事一式（" + invoice.Note + "）"
+		}
+	}
+
+	taxRate := s.TaxRateOn(invoice.IssueDate)
+	if invoice.TaxRate != nil {
+		taxRate = *invoice.TaxRate
+	}
+	doc := documentContent{
+		kind:     models.DocumentKindInvoice,
+		template: settings.Invoice,
+		entry:    &entry,
+		req:      req,
+		taxRate:  taxRate,
+		dueLabel: "お支払期限",
+		dueDate:  invoice.DueDate,
+	}
+	if subtotal, err := doc.subtotal(); err == nil && subtotal != invoice.Amount {
+		return nil, fmt.Errorf("明細の合計（%s）が請求金額（%s）と一致しません", formatYen(subtotal), formatYen(invoice.Amount))
+	}
+	base := joinFileNameParts(settings.Invoice.FilePrefix, cmp.Or(invoice.Number, invoice.Id), entry.CompanyName, entry.LocationName)
+	return s.writeDocument(&entry, settings, doc, base)
+}
+
+// writeDocument は帳票を描画し、工事フォルダーに版番号付きのファイル名で保存する
+func (s *KoujiService) writeDocument(entry *models.KoujiEntry, settings models.DocumentSettings, doc documentContent, base string) (*models.FileEntry, error) {
+	if _, err := time.Parse("2006-01-02", doc.req.IssueDate); err != nil {
+		return nil, fmt.Errorf("発行日はYYYY-MM-DDで指定してください: %s", doc.req.IssueDate)
+	}
+	fontData, err := s.documentFont(settings)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := utils.NewPDFDocument(doc.template.Title, fontData)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Issuer.SealImage != "" {
+		data, err := os.ReadFile(s.documentAssetPath(settings.Issuer.SealImage))
+		if err != nil {
+			return nil, fmt.Errorf("印影の画像を読み込めません: %w", err)
+		}
+		if doc.seal, err = pdf.AddImage(data); err != nil {
+			return nil, err
+		}
+	}
+	doc.issuer = settings.Issuer
+	if doc.template.ShowBankDetails {
+		doc.bankDetails = settings.BankDetails
+	}
+	if err := doc.render(pdf); err != nil {
+		return nil, err
+	}
+
+	filePath, err := nextVersionedPath(entry.Path, base, ".pdf")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filePath, pdf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return s.FileSystemService.GetFileEntry(filePath)
+}
+
+// documentFont は設定のフォント、なければ同梱のフォントを返す
+// どちらもない場合は、埋め込まないフォントで出力せずにErrDocumentFontMissingを返す
+func (s *KoujiService) documentFont(settings models.DocumentSettings) ([]byte, error) {
+	if settings.FontPath != "" {
+		data, err := os.ReadFile(s.documentAssetPath(settings.FontPath))
+		if err != nil {
+			return nil, fmt.Errorf("フォントを読み込めません: %w", err)
+		}
+		return data, nil
+	}
+	return firstTrueTypeFont(bundledFonts, "fonts")
+}
+
+// firstTrueTypeFont はdirにあるTrueTypeフォントのうち、ファイル名の順で最初のものを返す
+func firstTrueTypeFont(fsys fs.FS, dir string) ([]byte, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, ErrDocumentFontMissing
+	}
+	for _, e := range entries {
+		switch strings.ToLower(path.Ext(e.Name())) {
+		case ".ttf", ".ttc":
+			return fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		}
+	}
+	return nil, ErrDocumentFontMissing
+}
+
+// documentAssetPath は工事フォルダーからの相対パスを絶対パスにする
+func (s *KoujiService) documentAssetPath(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.FileSystemPath, p)
+}
+
+// versionSuffixPattern は版番号付きのファイル名の末尾（_v2.pdfなど）
+var versionSuffixPattern = regexp.MustCompile(`^_v(\d+)$`)
+
+// nextVersionedPath はディレクトリ内の既存の版の次の版番号を付けたパス（base_v1.pdf, base_v2.pdf, ...）を返す
+func nextVersionedPath(dir, base, ext string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	version := 1
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || !strings.EqualFold(filepath.Ext(name), ext) {
+			continue
+		}
+		m := versionSuffixPattern.FindStringSubmatch(strings.TrimSuffix(name[len(base):], filepath.Ext(name)))
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= version {
+			version = n + 1
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s_v%d%s", base, version, ext)), nil
+}
+
+// joinFileNameParts は空でない部分を_で連結し、ファイル名に使用できない文字を置き換える
+func joinFileNameParts(parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+				return '_'
+			}
+			return r
+		}, strings.TrimSpace(p))
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "_")
+}
+
+// documentContent は1枚の見積書・請求書の内容を表す
+type documentContent struct {
+	kind        string
+	template    models.DocumentTemplate
+	issuer      models.DocumentIssuer
+	bankDetails string
+	entry       *models.KoujiEntry
+	req         models.DocumentRequest
+	taxRate     int
+	// 有効期限・支払期限
+	dueLabel, dueDate string
+	seal              *utils.PDFImage
+}
+
+// lineAmount は明細行の金額（省略時は数量×単価）を返す
+func lineAmount(item models.DocumentLineItem) int64 {
+	if item.Amount != 0 {
+		return item.Amount
+	}
+	return int64(math.Round(item.Quantity * float64(item.UnitPrice)))
+}
+
+// subtotal は明細を検証して合計（税抜）を返す
+func (d *documentContent) subtotal() (int64, error) {
+	var total int64
+	for i, item := range d.req.LineItems {
+		if strings.TrimSpace(item.Name) == "" {
+			return 0, fmt.Errorf("%d行目: 品名が空です", i+1)
+		}
+		total += lineAmount(item)
+	}
+	return total, nil
+}
+
+// 帳票のレイアウト（ポイント）
+const (
+	docMarginX      = 45.0
+	docRight        = utils.PDFPageWidth - docMarginX
+	docTableBottom  = 770.0
+	docRowLeading   = 12.0
+	docMinTableRows = 10
+)
+
+// docColumns は明細表の列（左端と幅）
+var docColumns = []struct {
+	label string
+	x, w  float64
+}{
+	{"品名", docMarginX, 235},
+	{"数量", 280, 55},
+	{"単位", 335, 40},
+	{"単価", 375, 80},
+	{"金額", 455, docRight - 455},
+}
+
+// render は帳票をPDFに描画する
+func (d *documentContent) render(pdf *utils.PDFDocument) error {
+	subtotal, err := d.subtotal()
+	if err != nil {
+		return err
+	}
+	tax := ConsumptionTax(subtotal, d.taxRate)
+	total := subtotal + tax
+
+	page := pdf.AddPage()
+	pages := []*utils.PDFPage{page}
+
+	// 表題
+	page.TextCenter(utils.PDFPageWidth/2, 65, 22, d.template.Title)
+	titleWidth := pdf.TextWidth(d.template.Title, 22)
+	page.Line(utils.PDFPageWidth/2-titleWidth/2-10, 72, utils.PDFPageWidth/2+titleWidth/2+10, 72, 0.8)
+
+	// 番号・発行日
+	y := 95.0
+	if d.req.Number != "" {
+		page.TextRight(docRight, y, 9, "No. "+d.req.Number)
+		y += 13
+	}
+	page.TextRight(docRight, y, 9, "発行日 "+formatJapaneseDate(d.req.IssueDate))
+
+	// 宛名・件名・文面
+	addressee := d.entry.CompanyName + " 御中"
+	page.Text(docMarginX, 128, 15, addressee)
+	page.Line(docMarginX, 133, max(docMarginX+220, docMarginX+pdf.TextWidth(addressee, 15)+10), 133, 0.8)
+	subject := d.req.Subject
+	if subject == "" {
+		subject = d.entry.LocationName
+	}
+	page.Text(docMarginX, 153, 10.5, "件名　"+subject)
+	page.Text(docMarginX, 172, 10, d.template.Message)
+
+	// 合計金額
+	totalLabel := "御見積金額"
+	if d.kind == models.DocumentKindInvoice {
+		totalLabel = "ご請求金額"
+	}
+	page.FillRect(docMarginX, 182, 255, 32, 0.92)
+	page.Rect(docMarginX, 182, 255, 32, 0.8)
+	page.Text(docMarginX+8, 203, 11, totalLabel)
+	page.TextRight(docMarginX+245, 205, 17, formatYen(total)+"-")
+	page.Text(docMarginX, 228, 8, "（消費税込）")
+	if d.dueLabel != "" && d.dueDate != "" {
+		page.Text(docMarginX+90, 228, 9, d.dueLabel+"　"+formatJapaneseDate(d.dueDate))
+	}
+
+	// 発行元と印影
+	issuerX := 330.0
+	if d.seal != nil {
+		page.ImageFit(d.seal, docRight-62, 118, 62, 62)
+	}
+	iy := 140.0
+	page.Text(issuerX, iy, 12, d.issuer.Name)
+	iy += 16
+	if d.issuer.Address != "" {
+		iy += float64(page.TextBox(issuerX, iy, docRight-issuerX, 8.5, 11, d.issuer.Address)) * 11
+	}
+	if d.issuer.Phone != "" {
+		page.Text(issuerX, iy, 8.5, "TEL "+d.issuer.Phone)
+		iy += 11
+	}
+	if d.issuer.RegistrationNumber != "" {
+		page.Text(issuerX, iy, 8.5, "登録番号 "+d.issuer.RegistrationNumber)
+	}
+
+	// 明細表
+	y = 250.0
+	drawHeader := func(p *utils.PDFPage, y float64) float64 {
+		p.FillRect(docMarginX, y, docRight-docMarginX, 18, 0.85)
+		for _, col := range docColumns {
+			p.Rect(col.x, y, col.w, 18, 0.5)
+			p.TextCenter(col.x+col.w/2, y+12.5, 9, col.label)
+		}
+		return y + 18
+	}
+	y = drawHeader(page, y)
+	rows := len(d.req.LineItems)
+	for i := 0; i < max(rows, docMinTableRows); i++ {
+		var item models.DocumentLineItem
+		var lines []string
+		if i < rows {
+			item = d.req.LineItems[i]
+			lines = pdf.WrapText(item.Name, docColumns[0].w-8, 9)
+		}
+		height := 6 + docRowLeading*float64(max(len(lines), 1))
+		if y+height > docTableBottom {
+			if i >= rows {
+				break
+			}
+			page = pdf.AddPage()
+			pages = append(pages, page)
+			y = drawHeader(page, 50)
+		}
+		for _, col := range docColumns {
+			page.Rect(col.x, y, col.w, height, 0.5)
+		}
+		if i < rows {
+			for j, line := range lines {
+				page.Text(docColumns[0].x+4, y+13+float64(j)*docRowLeading, 9, line)
+			}
+			if item.Quantity != 0 {
+				page.TextRight(docColumns[1].x+docColumns[1].w-4, y+13, 9, strconv.FormatFloat(item.Quantity, 'f', -1, 64))
+			}
+			page.TextCenter(docColumns[2].x+docColumns[2].w/2, y+13, 9, item.Unit)
+			if item.UnitPrice != 0 {
+				page.TextRight(docColumns[3].x+docColumns[3].w-4, y+13, 9, formatAmount(item.UnitPrice))
+			}
+			page.TextRight(docColumns[4].x+docColumns[4].w-4, y+13, 9, formatAmount(lineAmount(item)))
+		}
+		y += height
+	}
+
+	// 小計・消費税・合計
+	summary := []struct {
+		label  string
+		amount int64
+	}{
+		{"小計", subtotal},
+		{fmt.Sprintf("消費税（%d%%対象）", d.taxRate), tax},
+		{"合計", total},
+	}
+	if y+18*float64(len(summary)) > docTableBottom {
+		page = pdf.AddPage()
+		pages = append(pages, page)
+		y = 50
+	}
+	labelX := docColumns[3].x - 60
+	for _, row := range summary {
+		page.Rect(labelX, y, docColumns[4].x-labelX, 18, 0.5)
+		page.Rect(docColumns[4].x, y, docColumns[4].w, 18, 0.5)
+		page.Text(labelX+4, y+12.5, 9, row.label)
+		page.TextRight(docRight-4, y+12.5, 9, formatAmount(row.amount))
+		y += 18
+	}
+	if d.taxRate > 0 {
+		page.Text(docMarginX, y-4, 8, fmt.Sprintf("%d%%対象 %s　消費税 %s", d.taxRate, formatYen(subtotal), formatYen(tax)))
+	}
+
+	// 振込先・備考
+	y += 20
+	notes := d.req.Notes
+	if notes == "" {
+		notes = d.template.Notes
+	}
+	for _, block := range []struct{ label, text string }{
+		{"お振込先", d.bankDetails},
+		{"備考", notes},
+	} {
+		if strings.TrimSpace(block.text) == "" {
+			continue
+		}
+		lines := pdf.WrapText(block.text, docRight-docMarginX-16, 9)
+		height := 22 + float64(len(lines))*docRowLeading
+		if y+height > docTableBottom+40 {
+			page = pdf.AddPage()
+			pages = append(pages, page)
+			y = 50
+		}
+		page.Rect(docMarginX, y, docRight-docMarginX, height, 0.5)
+		page.Text(docMarginX+6, y+13, 9, block.label)
+		page.TextBox(docMarginX+8, y+28, docRight-docMarginX-16, 9, docRowLeading, block.text)
+		y += height + 10
+	}
+
+	if len(pages) > 1 {
+		for i, p := range pages {
+			p.TextCenter(utils.PDFPageWidth/2, 825, 8, fmt.Sprintf("%d / %d", i+1, len(pages)))
+		}
+	}
+	return nil
+}
+
+// formatAmount は金額を3桁区切りにする
+func formatAmount(amount int64) string {
+	s := strconv.FormatInt(amount, 10)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if negative {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+// formatYen は金額を円記号付きの3桁区切りにする
+func formatYen(amount int64) string {
+	return "¥" + formatAmount(amount)
+}
+
+// formatJapaneseDate はYYYY-MM-DDを2024年6月18日の形式にする
+func formatJapaneseDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return fmt.Sprintf("%d年%d月%d日", t.Year(), t.Month(), t.Day())
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCreateEstimateDocumentVersions(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+
+	// 印影（半透明のPNG）
+	seal := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 8; i++ {
+		seal.Set(i, i, color.NRGBA{R: 200, A: 128})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, seal); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.FileSystemPath, "印影.png"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := s.GetDocumentSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Issuer = models.DocumentIssuer{Name: "ペンギン工業株式会社", SealImage: "印影.png"}
+	if err := s.SaveDocumentSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.CreateEstimateDocument(id, models.DocumentRequest{}); err == nil {
+		t.Error("estimate without amount or line items was created")
+	}
+	req := models.DocumentRequest{
+		IssueDate: "2099-05-01",
+		LineItems: []models.DocumentLineItem{
+			{Name: "耐火物解体工事", Quantity: 1, Unit: "式", UnitPrice: 500000},
+			{Name: "キャスタブル施工", Quantity: 2.5, Unit: "t", UnitPrice: 120000},
+		},
+	}
+	for _, want := range []string{"見積書_豊田築炉_名和工場_v1.pdf", "見積書_豊田築炉_名和工場_v2.pdf"} {
+		entry, err := s.CreateEstimateDocument(id, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Name != want {
+			t.Errorf("CreateEstimateDocument() name = %s, want %s", entry.Name, want)
+		}
+		data, err := os.ReadFile(entry.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/SMask")) || entry.Size != int64(len(data)) {
+			t.Errorf("%s is not a PDF with the seal image", entry.Name)
+		}
+		if !bytes.Contains(data, []byte("/FontFile2")) {
+			t.Errorf("%s does not embed the font", entry.Name)
+		}
+	}
+}
+
+func TestCreateInvoiceDocumentAmountMismatch(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+	invoice, err := s.AddKoujiInvoice(id, models.KoujiInvoice{Number: "A-1", IssueDate: "2099-07-31", Amount: 300000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.CreateInvoiceDocument(id, invoice.Id, models.DocumentRequest{
+		LineItems: []models.DocumentLineItem{{Name: "耐火物解体工事", Amount: 200000}},
+	})
+	if err == nil {
+		t.Error("invoice document with mismatched line items was created")
+	}
+	entry, err := s.CreateInvoiceDocument(id, invoice.Id, models.DocumentRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Name != "請求書_A-1_豊田築炉_名和工場_v1.pdf" {
+		t.Errorf("CreateInvoiceDocument() name = %s", entry.Name)
+	}
+}
+
+func TestDocumentFontMissing(t *testing.T) {
+	fsys := fstest.MapFS{"fonts/README.md": {Data: []byte("# 帳票用フォント")}}
+	if _, err := firstTrueTypeFont(fsys, "fonts"); !errors.Is(err, ErrDocumentFontMissing) {
+		t.Errorf("firstTrueTypeFont() without a font: err = %v, want ErrDocumentFontMissing", err)
+	}
+	fsys["fonts/b.ttf"] = &fstest.MapFile{Data: []byte("b")}
+	fsys["fonts/a.TTF"] = &fstest.MapFile{Data: []byte("a")}
+	if data, err := firstTrueTypeFont(fsys, "fonts"); err != nil || string(data) != "a" {
+		t.Errorf("firstTrueTypeFont() = %q, %v; want the first font by name", data, err)
+	}
+}
+
+func TestBundledDocumentFont(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+	fontData, err := s.documentFont(models.DocumentSettings{})
+	if err != nil {
+		t.Fatalf("no bundled font: %v", err)
+	}
+	font, err := utils.ParseTrueTypeFont(fontData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := s.CreateEstimateDocument(id, models.DocumentRequest{
+		IssueDate: "2099-05-01",
+		LineItems: []models.DocumentLineItem{{Name: "耐火物解体工事", Quantity: 1, Unit: "式", UnitPrice: 500000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 日本語の文字が同梱のフォントのグリフで描かれ、ToUnicodeで元の文字に戻せる
+	var toUnicode string
+	for _, stream := range pdfStreams(t, data) {
+		if strings.Contains(stream, "begincmap") {
+			toUnicode = stream
+		}
+	}
+	for _, r := range "見積書耐火物解体工事豊田築炉" {
+		gid := font.GlyphID(r)
+		if gid == 0 {
+			t.Errorf("bundled font has no glyph for %q", r)
+			continue
+		}
+		if want := fmt.Sprintf("<%04X> <%04X>", gid, r); !strings.Contains(toUnicode, want) {
+			t.Errorf("%q is not drawn with the bundled font (no %s in ToUnicode)", r, want)
+		}
+	}
+}
+
+// pdfStreams はPDFのストリームを展開した内容を返す
+func pdfStreams(t *testing.T, data []byte) []string {
+	t.Helper()
+	var streams []string
+	for {
+		i := bytes.Index(data, []byte(">>\nstream\n"))
+		if i < 0 {
+			return streams
+		}
+		i += len(">>\n")
+		dict := data[bytes.LastIndex(data[:i], []byte("obj\n")):i]
+		m := regexp.MustCompile(`/Length (\d+) >>`).FindSubmatch(dict)
+		if m == nil {
+			t.Fatalf("stream without length: %s", dict)
+		}
+		n, _ := strconv.Atoi(string(m[1]))
+		body := data[i+len("stream\n") : i+len("stream\n")+n]
+		if bytes.Contains(dict, []byte("/FlateDecode")) && !bytes.Contains(dict, []byte("/Subtype /Image")) {
+			zr, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			inflated, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			streams = append(streams, string(inflated))
+		}
+		data = data[i+len("stream\n")+n:]
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -1500: "-1,500"}
+	for amount, want := range tests {
+		if got := formatAmount(amount); got != want {
+			t.Errorf("formatAmount(%d) = %s, want %s", amount, got, want)
+		}
+	}
+}
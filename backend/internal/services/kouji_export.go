@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// CSVエンコーディングの種類
+const (
+	CSVEncodingUTF8    = "utf8"
+	CSVEncodingUTF8BOM = "utf8bom"
+	CSVEncodingSJIS    = "sjis"
+)
+
+// KoujiLedgerColumns は工事台帳のエクスポート列（ヘッダー）
+var KoujiLedgerColumns = []string{
+	"ID",
+	"会社名",
+	"現場名",
+	"状態",
+	"開始日",
+	"終了日",
+	"年度",
+	"説明",
+	"タグ",
+	"見積金額",
+	"受注金額",
+	"請求金額",
+	"入金額",
+	"フォルダー名",
+	"パス",
+	"サイズ",
+	"更新日時",
+}
+
+// BuildKoujiLedger は工事一覧をエクスポート用の表（1行目はヘッダー）に変換する
+// サイズはフォルダー配下の合計サイズを計算して出力する
+// カスタムフィールドは定義順に表示名を列名として末尾に追加する
+func (s *KoujiService) BuildKoujiLedger(entries []models.KoujiEntry) ([][]any, error) {
+	schema, err := s.GetCustomFieldSchema()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]any, 0, len(entries)+1)
+
+	header := make([]any, 0, len(KoujiLedgerColumns)+len(schema))
+	for _, column := range KoujiLedgerColumns {
+		header = append(header, column)
+	}
+	for _, def := range schema {
+		header = append(header, def.DisplayLabel())
+	}
+	rows = append(rows, header)
+
+	for _, entry := range entries {
+		size, err := s.FileSystemService.GetDirectorySize(entry.Path)
+		if err != nil {
+			size = entry.Size
+		}
+		finance := koujiFinanceSummary(&entry)
+		row := []any{
+			entry.Id,
+			entry.CompanyName,
+			entry.LocationName,
+			entry.Status,
+			entry.StartDate.Time,
+			entry.EndDate.Time,
+			entry.FiscalYear,
+			entry.Description,
+			strings.Join(entry.Tags, ","),
+			finance.EstimateAmount,
+			finance.OrderAmount,
+			finance.InvoicedAmount,
+			finance.PaidAmount,
+			entry.Name,
+			entry.Path,
+			size,
+			entry.ModifiedTime.Time.Format("2006-01-02 15:04:05"),
+		}
+		for _, def := range schema {
+			row = append(row, ledgerCustomFieldValue(def, entry.CustomFields[def.Key]))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ledgerCustomFieldValue はカスタムフィールドの値をExcelで扱いやすい型に変換する
+func ledgerCustomFieldValue(def models.CustomFieldDefinition, value any) any {
+	if value == nil {
+		return nil
+	}
+	switch def.Type {
+	case models.CustomFieldTypeNumber, models.CustomFieldTypeInteger:
+		if n, err := toFloat(value); err == nil {
+			return n
+		}
+	case models.CustomFieldTypeDate:
+		if ts, err := parseImportDate(FormatCustomFieldValue(value)); err == nil {
+			return ts.Time
+		}
+	}
+	return FormatCustomFieldValue(value)
+}
+
+// WriteLedgerCSV は表をCSVとして書き出す
+// encodingはutf8, utf8bom（Excel向け）, sjis（Shift_JIS）のいずれか
+// Shift_JISで表現できない文字は「?」に置き換える
+func WriteLedgerCSV(w io.Writer, rows [][]any, enc string) error {
+	switch enc {
+	case "", CSVEncodingUTF8:
+	case CSVEncodingUTF8BOM:
+		if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return err
+		}
+	case CSVEncodingSJIS:
+		w = encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder()).Writer(w)
+	default:
+		return fmt.Errorf("未対応のエンコーディングです: %s", enc)
+	}
+
+	cw := csv.NewWriter(w)
+	// Excelで開くためCRLF改行にする
+	cw.UseCRLF = true
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatLedgerValue(value)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteLedgerXLSX は表をExcelブックとして書き出す
+func WriteLedgerXLSX(w io.Writer, rows [][]any) error {
+	return utils.WriteXLSX(w, "工事台帳", rows)
+}
+
+func formatLedgerValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
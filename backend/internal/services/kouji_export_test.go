@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestBuildKoujiLedger(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場", "2099-07-01 愛知製鋼 知多工場")
+	schema := []models.CustomFieldDefinition{
+		{Key: "受注金額", Label: "受注金額（税抜）", Type: models.CustomFieldTypeInteger},
+		{Key: "検収日", Type: models.CustomFieldTypeDate},
+		{Key: "炉の種類", Type: models.CustomFieldTypeEnum, Enum: []string{"溶解炉", "加熱炉"}},
+	}
+	if err := s.SaveCustomFieldSchema(schema); err != nil {
+		t.Fatal(err)
+	}
+	entries := s.GetKoujiEntries()
+	i := slices.IndexFunc(entries, func(e models.KoujiEntry) bool { return e.LocationName == "名和工場" })
+	if _, err := s.UpdateKoujiCustomFields(entries[i].Id, map[string]any{"受注金額": "1,500,000", "検収日": "2099/07/01", "炉の種類": "溶解炉"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := s.BuildKoujiLedger(s.GetKoujiEntries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("BuildKoujiLedger() = %d rows, want header and 2 entries", len(rows))
+	}
+	// カスタムフィールドは定義順に表示名を列名として末尾に追加する
+	header := rows[0][len(KoujiLedgerColumns):]
+	if want := []any{"受注金額（税抜）", "検収日", "炉の種類"}; !slices.Equal(header, want) {
+		t.Errorf("custom field columns = %v, want %v", header, want)
+	}
+	var nawa, chita []any
+	for _, row := range rows[1:] {
+		switch row[2] {
+		case "名和工場":
+			nawa = row[len(KoujiLedgerColumns):]
+		case "知多工場":
+			chita = row[len(KoujiLedgerColumns):]
+		}
+	}
+	// 数値・日付はExcelで計算できる型にする
+	wantDate := time.Date(2099, 7, 1, 0, 0, 0, 0, time.Local)
+	if len(nawa) != 3 || nawa[0] != 1500000.0 || !nawa[1].(time.Time).Equal(wantDate) || nawa[2] != "溶解炉" {
+		t.Errorf("名和工場 custom fields = %#v", nawa)
+	}
+	if len(chita) != 3 || chita[0] != nil || chita[1] != nil || chita[2] != nil {
+		t.Errorf("知多工場 custom fields = %#v, want empty", chita)
+	}
+}
+
+func TestWriteLedgerCSV(t *testing.T) {
+	rows := [][]any{
+		{"ID", "会社名", "開始日", "受注金額"},
+		{"AAAAA", "豊田築炉", time.Date(2099, 6, 18, 0, 0, 0, 0, time.Local), 1500000.0},
+		{"BBBBB", "髙橋工業🔥", time.Time{}, nil},
+	}
+	const want = "ID,会社名,開始日,受注金額\r\nAAAAA,豊田築炉,2099-06-18,1500000\r\nBBBBB,髙橋工業🔥,,\r\n"
+
+	var buf bytes.Buffer
+	if err := WriteLedgerCSV(&buf, rows, CSVEncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("utf8 CSV = %q, want %q", buf.String(), want)
+	}
+
+	// Excel向けはBOMを付ける
+	buf.Reset()
+	if err := WriteLedgerCSV(&buf, rows, CSVEncodingUTF8BOM); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), append([]byte{0xEF, 0xBB, 0xBF}, want...)) {
+		t.Errorf("utf8bom CSV = %q, want BOM and %q", buf.String(), want)
+	}
+
+	// Shift_JISで表現できない文字は置き換える
+	buf.Reset()
+	if err := WriteLedgerCSV(&buf, rows, CSVEncodingSJIS); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(decoded); !strings.HasPrefix(got, "ID,会社名,開始日,受注金額\r\nAAAAA,豊田築炉,") || strings.Contains(got, "🔥") {
+		t.Errorf("sjis CSV = %q", got)
+	}
+
+	if err := WriteLedgerCSV(&buf, rows, "euc-jp"); err == nil {
+		t.Error("WriteLedgerCSV(euc-jp) succeeded, want error")
+	}
+}
+
+func TestWriteLedgerXLSX(t *testing.T) {
+	rows := [][]any{
+		{"ID", "会社名", "開始日", "受注金額", "検収日"},
+		{"AAAAA", "豊田築炉", time.Date(2099, 6, 18, 0, 0, 0, 0, time.Local), 1500000.0, nil},
+	}
+	var buf bytes.Buffer
+	if err := WriteLedgerXLSX(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// 書き出したブックは取り込みと同じ方法で読み戻せる
+	got, err := utils.ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"ID", "会社名", "開始日", "受注金額", "検収日"},
+		{"AAAAA", "豊田築炉", "2099-06-18", "1500000"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadXLSX() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CustomFieldPrefix はインポート・ルール・検索クエリでカスタムフィールドを指定する接頭辞
+const CustomFieldPrefix = "custom."
+
+// GetCustomFieldSchema はカスタムフィールドの定義一覧を返す
+func (s *KoujiService) GetCustomFieldSchema() ([]models.CustomFieldDefinition, error) {
+	schema := []models.CustomFieldDefinition{}
+	if err := loadYAMLFile(s.FieldSchemaPath, &schema); err != nil {
+		return nil, fmt.Errorf("カスタムフィールドの定義を読み込めません: %w", err)
+	}
+	return schema, nil
+}
+
+// SaveCustomFieldSchema はカスタムフィールドの定義を検証して保存する
+func (s *KoujiService) SaveCustomFieldSchema(schema []models.CustomFieldDefinition) error {
+	seen := make(map[string]bool, len(schema))
+	for _, def := range schema {
+		if strings.TrimSpace(def.Key) == "" {
+			return fmt.Errorf("カスタムフィールドのキーが空です")
+		}
+		if seen[def.Key] {
+			return fmt.Errorf("カスタムフィールドのキーが重複しています: %s", def.Key)
+		}
+		seen[def.Key] = true
+		switch def.Type {
+		case models.CustomFieldTypeString, models.CustomFieldTypeNumber, models.CustomFieldTypeInteger,
+			models.CustomFieldTypeDate, models.CustomFieldTypeBool:
+		case models.CustomFieldTypeEnum:
+			if len(def.Enum) == 0 {
+				return fmt.Errorf("%s: enum型には選択肢が必要です", def.Key)
+			}
+		default:
+			return fmt.Errorf("%s: 未対応の型です: %s", def.Key, def.Type)
+		}
+		if def.Pattern != "" {
+			if _, err := regexp.Compile(def.Pattern); err != nil {
+				return fmt.Errorf("%s: 正規表現が不正です: %w", def.Key, err)
+			}
+		}
+		if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+			return fmt.Errorf("%s: min が max より大きいです", def.Key)
+		}
+	}
+	if schema == nil {
+		schema = []models.CustomFieldDefinition{}
+	}
+	return saveYAMLFile(s.FieldSchemaPath, schema)
+}
+
+// ValidateCustomFields は工事のカスタムフィールドを定義に従って検証し、型を揃えた値に置き換える
+// 定義にないキー、型・範囲・選択肢・正規表現に合わない値、必須フィールドの欠落をエラーとする
+func ValidateCustomFields(entry *models.KoujiEntry, schema []models.CustomFieldDefinition) error {
+	defs := make(map[string]models.CustomFieldDefinition, len(schema))
+	for _, def := range schema {
+		defs[def.Key] = def
+	}
+
+	var errs []error
+	normalized := make(map[string]any, len(entry.CustomFields))
+	for key, value := range entry.CustomFields {
+		def, ok := defs[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: 定義されていないカスタムフィールドです", key))
+			continue
+		}
+		if !def.AppliesTo(entry.CompanyName) {
+			errs = append(errs, fmt.Errorf("%s: %s には使用できないカスタムフィールドです", key, entry.CompanyName))
+			continue
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		v, err := NormalizeCustomFieldValue(def, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		normalized[key] = v
+	}
+	for _, def := range schema {
+		if _, ok := normalized[def.Key]; def.Required && !ok && def.AppliesTo(entry.CompanyName) {
+			errs = append(errs, fmt.Errorf("%s: 必須のカスタムフィールドです", def.Key))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("工事 %s: %w", entry.Id, errors.Join(errs...))
+	}
+
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+	entry.CustomFields = normalized
+	return nil
+}
+
+// ValidateKoujiCustomFields は複数の工事のカスタムフィールドを検証する
+func (s *KoujiService) ValidateKoujiCustomFields(entries []models.KoujiEntry) error {
+	schema, err := s.GetCustomFieldSchema()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range entries {
+		if err := ValidateCustomFields(&entries[i], schema); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UpdateKoujiCustomFields は工事のカスタムフィールドを更新する
+// 値がnullのキーは削除し、それ以外は既存の値を上書きする
+func (s *KoujiService) UpdateKoujiCustomFields(id string, values map[string]any) (models.KoujiEntry, error) {
+	schema, err := s.GetCustomFieldSchema()
+	if err != nil {
+		return models.KoujiEntry{}, err
+	}
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	entries := s.GetKoujiEntries()
+	for i := range entries {
+		if entries[i].Id != id {
+			continue
+		}
+		updated := entries[i]
+		fields := make(map[string]any, len(updated.CustomFields)+len(values))
+		for k, v := range updated.CustomFields {
+			fields[k] = v
+		}
+		for k, v := range values {
+			if v == nil {
+				delete(fields, k)
+			} else {
+				fields[k] = v
+			}
+		}
+		updated.CustomFields = fields
+		if err := ValidateCustomFields(&updated, schema); err != nil {
+			return models.KoujiEntry{}, err
+		}
+		entries[i] = updated
+		return updated, s.saveKoujiEntries(entries)
+	}
+	return models.KoujiEntry{}, fmt.Errorf("%w: %s", ErrKoujiNotFound, id)
+}
+
+// NormalizeCustomFieldValue は値を定義の型に変換して検証する
+// 文字列で渡された値（CSV取り込みなど）も型に合わせて解析する
+func NormalizeCustomFieldValue(def models.CustomFieldDefinition, value any) (any, error) {
+	switch def.Type {
+	case models.CustomFieldTypeNumber, models.CustomFieldTypeInteger:
+		n, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		if def.Min != nil && n < *def.Min {
+			return nil, fmt.Errorf("%v 以上である必要があります", *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return nil, fmt.Errorf("%v 以下である必要があります", *def.Max)
+		}
+		if def.Type == models.CustomFieldTypeInteger {
+			if n != math.Trunc(n) {
+				return nil, fmt.Errorf("整数である必要があります: %v", value)
+			}
+			return int64(n), nil
+		}
+		return n, nil
+	case models.CustomFieldTypeDate:
+		var t time.Time
+		switch v := value.(type) {
+		case time.Time:
+			t = v
+		case string:
+			ts, err := parseImportDate(v)
+			if err != nil {
+				return nil, err
+			}
+			t = ts.Time
+		default:
+			return nil, fmt.Errorf("日付である必要があります: %v", value)
+		}
+		return t.Format("2006-01-02"), nil
+	case models.CustomFieldTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "1", "yes", "はい", "○", "有":
+				return true, nil
+			case "false", "0", "no", "いいえ", "×", "無":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("真偽値である必要があります: %v", value)
+	case models.CustomFieldTypeEnum:
+		s := strings.TrimSpace(fmt.Sprint(value))
+		for _, option := range def.Enum {
+			if s == option {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("選択肢（%s）のいずれかである必要があります: %s", strings.Join(def.Enum, ", "), s)
+	default:
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		length := float64(utf8.RuneCountInString(s))
+		if def.Min != nil && length < *def.Min {
+			return nil, fmt.Errorf("%v 文字以上である必要があります", *def.Min)
+		}
+		if def.Max != nil && length > *def.Max {
+			return nil, fmt.Errorf("%v 文字以下である必要があります", *def.Max)
+		}
+		if def.Pattern != "" {
+			re, err := regexp.Compile(def.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !re.MatchString(s) {
+				return nil, fmt.Errorf("形式が正しくありません: %s", s)
+			}
+		}
+		return s, nil
+	}
+}
+
+// FormatCustomFieldValue はカスタムフィールドの値を表示用の文字列に変換する
+func FormatCustomFieldValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toFloat(value any) (float64, error) {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case float32:
+		n = float64(v)
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case uint64:
+		n = float64(v)
+	case string:
+		// 金額の桁区切りや円記号を許容する
+		cleaned := strings.NewReplacer(",", "", "，", "", "¥", "", "￥", "", "円", "").Replace(strings.TrimSpace(v))
+		parsed, err := strconv.ParseFloat(cleaned, 64)
+		if err != nil {
+			return 0, fmt.Errorf("数値である必要があります: %s", v)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("数値である必要があります: %v", value)
+	}
+	// ParseFloatは"NaN"や"Inf"も受け付けるが、比較や集計ができないため数値として扱わない
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("数値である必要があります: %v", value)
+	}
+	return n, nil
+}
+
+// compareCustomFieldValue は検索クエリの範囲指定用に値を比較する
+// 両方が数値として解釈できれば数値、そうでなければ文字列（日付はYYYY-MM-DD）として比較する
+func compareCustomFieldValue(value any, bound string) int {
+	if n, err := toFloat(value); err == nil {
+		if b, err := toFloat(bound); err == nil {
+			switch {
+			case n < b:
+				return -1
+			case n > b:
+				return 1
+			}
+			return 0
+		}
+	}
+	s := FormatCustomFieldValue(value)
+	if t, err := utils.ParseTime(bound); err == nil {
+		bound = t.Format("2006-01-02")
+	}
+	return strings.Compare(s, bound)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"math"
+	"penguin-backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomFields(t *testing.T) {
+	zero := 0.0
+	schema := []models.CustomFieldDefinition{
+		{Key: "受注金額", Type: models.CustomFieldTypeInteger, Min: &zero},
+		{Key: "炉の種類", Type: models.CustomFieldTypeEnum, Enum: []string{"溶解炉", "加熱炉"}},
+		{Key: "契約番号", Type: models.CustomFieldTypeString, Pattern: `^[A-Z]{2}-\d{4}$`, Required: true, Companies: []string{"豊田築炉"}},
+		{Key: "検収日", Type: models.CustomFieldTypeDate},
+	}
+
+	entry := models.KoujiEntry{
+		Id:          "AAAAA",
+		CompanyName: "豊田築炉",
+		CustomFields: map[string]any{
+			"受注金額": "1,500,000",
+			"炉の種類": "溶解炉",
+			"契約番号": "TC-0123",
+			"検収日":  "2024/07/01",
+		},
+	}
+	if err := ValidateCustomFields(&entry, schema); err != nil {
+		t.Fatalf("ValidateCustomFields returned error: %v", err)
+	}
+	if v := entry.CustomFields["受注金額"]; v != int64(1500000) {
+		t.Errorf("受注金額 = %#v, want int64(1500000)", v)
+	}
+	if v := entry.CustomFields["検収日"]; v != "2024-07-01" {
+		t.Errorf("検収日 = %#v, want 2024-07-01", v)
+	}
+
+	invalid := models.KoujiEntry{
+		Id:          "BBBBB",
+		CompanyName: "豊田築炉",
+		CustomFields: map[string]any{
+			"受注金額": -1,
+			"炉の種類": "焼鈍炉",
+			"未定義":  "x",
+		},
+	}
+	err := ValidateCustomFields(&invalid, schema)
+	if err == nil {
+		t.Fatal("ValidateCustomFields returned nil for invalid values")
+	}
+	for _, key := range []string{"受注金額", "炉の種類", "未定義", "契約番号"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not mention %s", err, key)
+		}
+	}
+
+	// 対象外の会社では必須フィールドを要求しない
+	other := models.KoujiEntry{Id: "CCCCC", CompanyName: "愛知製鋼"}
+	if err := ValidateCustomFields(&other, schema); err != nil {
+		t.Errorf("ValidateCustomFields for other company returned error: %v", err)
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		value   any
+		want    float64
+		wantErr bool
+	}{
+		{"1,500,000円", 1500000, false},
+		{"￥2,000", 2000, false},
+		{int64(42), 42, false},
+		{"abc", 0, true},
+		// 比較も集計もできない値は数値として扱わない
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"-infinity", 0, true},
+		{math.NaN(), 0, true},
+		{math.Inf(1), 0, true},
+	}
+	for _, tt := range tests {
+		got, err := toFloat(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("toFloat(%#v) = %v, %v; want %v, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// 数値フィールドにNaNを保存できない
+	def := models.CustomFieldDefinition{Key: "受注金額", Type: models.CustomFieldTypeNumber}
+	if v, err := NormalizeCustomFieldValue(def, "NaN"); err == nil {
+		t.Errorf("NormalizeCustomFieldValue(NaN) = %v, want error", v)
+	}
+}
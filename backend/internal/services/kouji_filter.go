@@ -0,0 +1,73 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"strings"
+	"time"
+)
+
+// KoujiFilter は工事一覧の絞り込み条件を表す
+// 空のフィールドは条件として扱わない
+type KoujiFilter struct {
+	// Query は検索クエリ（q=）
+	Query *KoujiQuery
+	// Company は会社名の部分一致
+	Company string
+	// Status は状態の完全一致
+	Status string
+	// Tag はタグの完全一致
+	Tag string
+	// FiscalYear は年度の一致（0は条件なし）
+	FiscalYear int
+	// From は開始日の下限（この日を含む）
+	From time.Time
+	// To は開始日の上限（この日を含む）
+	To time.Time
+}
+
+// Match は工事が絞り込み条件に一致するかを判定する
+func (f *KoujiFilter) Match(entry *models.KoujiEntry) bool {
+	if f == nil {
+		return true
+	}
+	if f.Company != "" && !containsFold(entry.CompanyName, f.Company) {
+		return false
+	}
+	if f.Status != "" && entry.Status != f.Status {
+		return false
+	}
+	if f.Tag != "" && !hasTag(entry.Tags, f.Tag) {
+		return false
+	}
+	if f.FiscalYear != 0 && entry.FiscalYear != f.FiscalYear {
+		return false
+	}
+	start := entry.StartDate.Time
+	if !f.From.IsZero() && (start.IsZero() || start.Before(f.From)) {
+		return false
+	}
+	if !f.To.IsZero() && (start.IsZero() || !start.Before(f.To.AddDate(0, 0, 1))) {
+		return false
+	}
+	return f.Query.Match(entry)
+}
+
+// Apply は絞り込み条件に一致する工事のみを返す
+func (f *KoujiFilter) Apply(entries []models.KoujiEntry) []models.KoujiEntry {
+	filtered := make([]models.KoujiEntry, 0, len(entries))
+	for i := range entries {
+		if f.Match(&entries[i]) {
+			filtered = append(filtered, entries[i])
+		}
+	}
+	return filtered
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
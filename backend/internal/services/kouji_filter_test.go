@@ -0,0 +1,50 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKoujiFilterApply(t *testing.T) {
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local) }
+	query := func(s string) *KoujiQuery {
+		q, err := ParseKoujiQuery(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+
+	tests := []struct {
+		name   string
+		filter *KoujiFilter
+		want   []string
+	}{
+		{"nil", nil, []string{"AAAAA", "BBBBB", "CCCCC"}},
+		{"empty", &KoujiFilter{}, []string{"AAAAA", "BBBBB", "CCCCC"}},
+		// 会社名は部分一致
+		{"company", &KoujiFilter{Company: "豊田"}, []string{"AAAAA", "BBBBB"}},
+		{"company and fiscal year", &KoujiFilter{Company: "豊田", FiscalYear: 2024}, []string{"AAAAA", "BBBBB"}},
+		{"company, status and tag", &KoujiFilter{Company: "豊田", Status: "完了", Tag: "見積のみ"}, []string{"BBBBB"}},
+		{"status and fiscal year", &KoujiFilter{Status: "完了", FiscalYear: 2026}, nil},
+		// 1月の工事は前の年度に含まれる
+		{"fiscal year and from", &KoujiFilter{FiscalYear: 2024, From: day(2025, 1, 1)}, []string{"BBBBB"}},
+		// 期間は両端の日を含む
+		{"same from and to", &KoujiFilter{From: day(2024, 6, 18), To: day(2024, 6, 18)}, []string{"AAAAA"}},
+		{"to", &KoujiFilter{To: day(2025, 1, 10)}, []string{"AAAAA", "BBBBB"}},
+		{"from", &KoujiFilter{From: day(2025, 1, 11)}, []string{"CCCCC"}},
+		// 検索クエリは他の条件と組み合わせて絞り込む
+		{"query and company", &KoujiFilter{Query: query("-tag:見積のみ"), Company: "豊田"}, []string{"AAAAA"}},
+		{"query and tag", &KoujiFilter{Query: query("custom.炉の種類:溶解炉"), Tag: "工事"}, []string{"CCCCC"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range tt.filter.Apply(testKoujiEntries()) {
+			got = append(got, e.Id)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Apply() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
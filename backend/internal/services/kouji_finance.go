@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvoiceNotFound は指定したIDの請求がない場合のエラー
+var ErrInvoiceNotFound = errors.New("請求がありません")
+
+// TaxRateOn は設定の消費税率の表から日付（YYYY-MM-DD）に適用される税率（%）を返す
+func (s *KoujiService) TaxRateOn(date string) int {
+	return TaxRateOn(s.GetSettings().ConsumptionTaxRates, date)
+}
+
+// TaxRateOn は適用開始日の昇順に並んだ税率の表から日付に適用される税率を返す
+// 最初の適用開始日より前の日付は0%
+func TaxRateOn(rates []models.ConsumptionTaxRate, date string) int {
+	rate := 0
+	for _, r := range rates {
+		if r.From > date {
+			break
+		}
+		rate = r.Rate
+	}
+	return rate
+}
+
+// ConsumptionTax は税抜金額と税率（%）から消費税額を計算する（1円未満切り捨て）
+func ConsumptionTax(amount int64, rate int) int64 {
+	tax := amount * int64(rate) / 100
+	if amount < 0 && amount*int64(rate)%100 != 0 {
+		// 値引きなどの負の金額も0方向ではなく切り捨てる
+		tax--
+	}
+	return tax
+}
+
+// GetKoujiFinance は工事の見積・受注・請求・入金の金額を集計する
+func (s *KoujiService) GetKoujiFinance(koujiId string) (*models.KoujiFinanceSummary, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	return koujiFinanceSummary(&entry), nil
+}
+
+// UpdateKoujiFinance は工事の見積金額・受注金額を更新する
+func (s *KoujiService) UpdateKoujiFinance(koujiId string, req models.KoujiFinanceUpdateRequest) (*models.KoujiFinanceSummary, error) {
+	if req.EstimateAmount < 0 || req.OrderAmount < 0 {
+		return nil, fmt.Errorf("見積金額・受注金額は0以上で指定してください")
+	}
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	entries := s.GetKoujiEntries()
+	i := slices.IndexFunc(entries, func(e models.KoujiEntry) bool { return e.Id == koujiId })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+	}
+	entries[i].EstimateAmount = req.EstimateAmount
+	entries[i].OrderAmount = req.OrderAmount
+	if err := s.saveKoujiEntries(entries); err != nil {
+		return nil, err
+	}
+	return koujiFinanceSummary(&entries[i]), nil
+}
+
+// AddKoujiInvoice は工事に請求を追加する
+// 税率を省略した場合は請求日の税率を使用し、消費税額と税込金額を計算する
+func (s *KoujiService) AddKoujiInvoice(koujiId string, invoice models.KoujiInvoice) (models.KoujiInvoice, error) {
+	return s.updateKoujiInvoices(koujiId, "", &invoice)
+}
+
+// UpdateKoujiInvoice は工事の請求を置き換える（入金の記録にも使用する）
+func (s *KoujiService) UpdateKoujiInvoice(koujiId, invoiceId string, invoice models.KoujiInvoice) (models.KoujiInvoice, error) {
+	return s.updateKoujiInvoices(koujiId, invoiceId, &invoice)
+}
+
+// DeleteKoujiInvoice は工事の請求を削除する
+func (s *KoujiService) DeleteKoujiInvoice(koujiId, invoiceId string) error {
+	_, err := s.updateKoujiInvoices(koujiId, invoiceId, nil)
+	return err
+}
+
+// updateKoujiInvoices は請求を追加（invoiceIdが空）・置換・削除（invoiceがnil）して保存する
+func (s *KoujiService) updateKoujiInvoices(koujiId, invoiceId string, invoice *models.KoujiInvoice) (models.KoujiInvoice, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	entries := s.GetKoujiEntries()
+	i := slices.IndexFunc(entries, func(e models.KoujiEntry) bool { return e.Id == koujiId })
+	if i < 0 {
+		return models.KoujiInvoice{}, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+	}
+	entry := &entries[i]
+
+	index := len(entry.Invoices)
+	if invoiceId != "" {
+		index = slices.IndexFunc(entry.Invoices, func(inv models.KoujiInvoice) bool { return inv.Id == invoiceId })
+		if index < 0 {
+			return models.KoujiInvoice{}, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceId)
+		}
+	}
+
+	var saved models.KoujiInvoice
+	if invoice == nil {
+		entry.Invoices = slices.Delete(entry.Invoices, index, index+1)
+	} else {
+		if err := s.normalizeInvoice(invoice); err != nil {
+			return models.KoujiInvoice{}, err
+		}
+		if invoice.Number != "" {
+			for j, inv := range entry.Invoices {
+				if j != index && inv.Number == invoice.Number {
+					return models.KoujiInvoice{}, fmt.Errorf("請求書番号が重複しています: %s", invoice.Number)
+				}
+			}
+		}
+		if invoiceId == "" {
+			invoice.Id = models.NewIDFromString(strings.Join([]string{
+				koujiId, invoice.IssueDate, invoice.Number,
+				strconv.FormatInt(invoice.Amount, 10), time.Now().Format(time.RFC3339Nano),
+			}, "\x00")).Len5()
+			entry.Invoices = append(entry.Invoices, *invoice)
+		} else {
+			invoice.Id = invoiceId
+			entry.Invoices[index] = *invoice
+		}
+		saved = *invoice
+	}
+	sort.SliceStable(entry.Invoices, func(a, b int) bool {
+		return entry.Invoices[a].IssueDate < entry.Invoices[b].IssueDate
+	})
+	if len(entry.Invoices) == 0 {
+		entry.Invoices = nil
+	}
+	if err := s.saveKoujiEntries(entries); err != nil {
+		return models.KoujiInvoice{}, err
+	}
+	return saved, nil
+}
+
+// GetReceivables は未入金の請求を会社ごとに集計し、未入金額の多い順に返す
+// asOfは支払期日の超過を判定する日付（YYYY-MM-DD）
+func (s *KoujiService) GetReceivables(asOf string) []models.CompanyReceivable {
+	asOfTime, _ := time.ParseInLocation("2006-01-02", asOf, time.Local)
+
+	byCompany := make(map[string]*models.CompanyReceivable)
+	var companies []string
+	for _, entry := range s.GetKoujiEntries() {
+		for _, inv := range entry.Invoices {
+			outstanding := inv.Total - inv.PaidAmount
+			if outstanding <= 0 {
+				continue
+			}
+			r, ok := byCompany[entry.CompanyName]
+			if !ok {
+				r = &models.CompanyReceivable{CompanyName: entry.CompanyName}
+				byCompany[entry.CompanyName] = r
+				companies = append(companies, entry.CompanyName)
+			}
+			item := models.ReceivableInvoice{
+				KoujiId:      entry.Id,
+				LocationName: entry.LocationName,
+				Invoice:      inv,
+				Outstanding:  outstanding,
+			}
+			if inv.DueDate != "" && inv.DueDate < asOf {
+				due, _ := time.ParseInLocation("2006-01-02", inv.DueDate, time.Local)
+				item.DaysOverdue = daysBetween(due, asOfTime)
+				r.Overdue += outstanding
+			}
+			r.Outstanding += outstanding
+			r.Invoices = append(r.Invoices, item)
+		}
+	}
+
+	receivables := make([]models.CompanyReceivable, 0, len(companies))
+	for _, name := range companies {
+		r := byCompany[name]
+		sort.Slice(r.Invoices, func(i, j int) bool {
+			return r.Invoices[i].Invoice.IssueDate < r.Invoices[j].Invoice.IssueDate
+		})
+		receivables = append(receivables, *r)
+	}
+	sort.SliceStable(receivables, func(i, j int) bool {
+		return receivables[i].Outstanding > receivables[j].Outstanding
+	})
+	return receivables
+}
+
+// GetMonthlyRevenue は全工事の請求（請求日）と入金（入金日）を月ごとに集計する（期間はYYYY-MM、空の場合は制限なし）
+func (s *KoujiService) GetMonthlyRevenue(fromMonth, toMonth string) []models.MonthlyRevenue {
+	byMonth := make(map[string]*models.MonthlyRevenue)
+	month := func(date string) *models.MonthlyRevenue {
+		if len(date) < 7 {
+			return nil
+		}
+		key := date[:7]
+		if (fromMonth != "" && key < fromMonth) || (toMonth != "" && key > toMonth) {
+			return nil
+		}
+		m, ok := byMonth[key]
+		if !ok {
+			m = &models.MonthlyRevenue{Month: key, ByCompany: make(map[string]int64)}
+			byMonth[key] = m
+		}
+		return m
+	}
+
+	for _, entry := range s.GetKoujiEntries() {
+		for _, inv := range entry.Invoices {
+			if m := month(inv.IssueDate); m != nil {
+				m.InvoicedAmount += inv.Amount
+				m.InvoicedTax += inv.Tax
+				m.InvoicedTotal += inv.Total
+				m.ByCompany[entry.CompanyName] += inv.Amount
+			}
+			if inv.PaidAmount == 0 {
+				continue
+			}
+			if m := month(inv.PaidDate); m != nil {
+				m.PaidAmount += inv.PaidAmount
+			}
+		}
+	}
+
+	months := make([]models.MonthlyRevenue, 0, len(byMonth))
+	for _, m := range byMonth {
+		months = append(months, *m)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Month < months[j].Month })
+	return months
+}
+
+// normalizeInvoice は請求を検証し、税率・消費税額・税込金額を設定する
+func (s *KoujiService) normalizeInvoice(invoice *models.KoujiInvoice) error {
+	invoice.Number = strings.TrimSpace(invoice.Number)
+	if invoice.IssueDate == "" {
+		invoice.IssueDate = time.Now().Format("2006-01-02")
+	}
+	for _, date := range []string{invoice.IssueDate, invoice.DueDate, invoice.PaidDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("日付はYYYY-MM-DDで指定してください: %s", date)
+		}
+	}
+	if invoice.DueDate != "" && invoice.DueDate < invoice.IssueDate {
+		return fmt.Errorf("支払期日が請求日より前です: %s", invoice.DueDate)
+	}
+	if invoice.TaxRate == nil {
+		rate := s.TaxRateOn(invoice.IssueDate)
+		invoice.TaxRate = &rate
+	}
+	if *invoice.TaxRate < 0 || *invoice.TaxRate > 100 {
+		return fmt.Errorf("消費税率は0〜100で指定してください: %d", *invoice.TaxRate)
+	}
+	invoice.Tax = ConsumptionTax(invoice.Amount, *invoice.TaxRate)
+	invoice.Total = invoice.Amount + invoice.Tax
+
+	if invoice.PaidAmount < 0 {
+		return fmt.Errorf("入金額は0以上で指定してください: %d", invoice.PaidAmount)
+	}
+	if invoice.PaidAmount > 0 && invoice.PaidDate == "" {
+		return fmt.Errorf("入金額を指定する場合は入金日も指定してください")
+	}
+	if invoice.PaidDate != "" && invoice.PaidAmount == 0 {
+		// 入金日のみの場合は全額入金とみなす
+		invoice.PaidAmount = invoice.Total
+	}
+	return nil
+}
+
+// koujiFinanceSummary は工事の金額を集計する
+func koujiFinanceSummary(entry *models.KoujiEntry) *models.KoujiFinanceSummary {
+	summary := &models.KoujiFinanceSummary{
+		KoujiId:        entry.Id,
+		CompanyName:    entry.CompanyName,
+		LocationName:   entry.LocationName,
+		EstimateAmount: entry.EstimateAmount,
+		OrderAmount:    entry.OrderAmount,
+		Invoices:       make([]models.KoujiInvoice, 0, len(entry.Invoices)),
+	}
+	for _, inv := range entry.Invoices {
+		summary.InvoicedAmount += inv.Amount
+		summary.InvoicedTax += inv.Tax
+		summary.InvoicedTotal += inv.Total
+		summary.PaidAmount += inv.PaidAmount
+		summary.Invoices = append(summary.Invoices, inv)
+	}
+	summary.Outstanding = summary.InvoicedTotal - summary.PaidAmount
+	if summary.OrderAmount > 0 {
+		summary.Uninvoiced = summary.OrderAmount - summary.InvoicedAmount
+	}
+	return summary
+}
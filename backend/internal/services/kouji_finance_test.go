@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"sync"
+	"testing"
+)
+
+func TestTaxRateOn(t *testing.T) {
+	rates := DefaultKoujiSettings.ConsumptionTaxRates
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"1989-03-31", 0},
+		{"1997-04-01", 5},
+		{"2014-03-31", 5},
+		{"2019-09-30", 8},
+		{"2019-10-01", 10},
+		{"2025-01-10", 10},
+	}
+	for _, tt := range tests {
+		if got := TaxRateOn(rates, tt.date); got != tt.want {
+			t.Errorf("TaxRateOn(%s) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+
+	if got := ConsumptionTax(12345, 10); got != 1234 {
+		t.Errorf("ConsumptionTax(12345, 10) = %d, want 1234", got)
+	}
+	if got := ConsumptionTax(-12345, 10); got != -1235 {
+		t.Errorf("ConsumptionTax(-12345, 10) = %d, want -1235", got)
+	}
+}
+
+func TestKoujiInvoicesAndReceivables(t *testing.T) {
+	s := newTestKoujiService(t, "2019-09-02 豊田築炉 名和工場", "2019-11-05 愛知製鋼 知多工場")
+	ids := make(map[string]string)
+	for _, e := range s.GetKoujiEntries() {
+		ids[e.CompanyName] = e.Id
+	}
+
+	if _, err := s.UpdateKoujiFinance(ids["豊田築炉"], models.KoujiFinanceUpdateRequest{EstimateAmount: 1200000, OrderAmount: 1000000}); err != nil {
+		t.Fatal(err)
+	}
+	// 増税前に請求した分は8%、増税後は10%
+	first, err := s.AddKoujiInvoice(ids["豊田築炉"], models.KoujiInvoice{Number: "A-1", IssueDate: "2019-09-30", DueDate: "2019-10-31", Amount: 400000, PaidDate: "2019-10-31"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *first.TaxRate != 8 || first.Total != 432000 || first.PaidAmount != 432000 {
+		t.Errorf("first invoice = rate %d total %d paid %d, want 8, 432000, 432000", *first.TaxRate, first.Total, first.PaidAmount)
+	}
+	second, err := s.AddKoujiInvoice(ids["豊田築炉"], models.KoujiInvoice{Number: "A-2", IssueDate: "2019-10-31", DueDate: "2019-11-30", Amount: 500000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Tax != 50000 {
+		t.Errorf("second invoice tax = %d, want 50000", second.Tax)
+	}
+	if _, err := s.AddKoujiInvoice(ids["豊田築炉"], models.KoujiInvoice{Number: "A-2", IssueDate: "2019-11-01", Amount: 1}); err == nil {
+		t.Error("duplicate invoice number was accepted")
+	}
+	if _, err := s.AddKoujiInvoice(ids["愛知製鋼"], models.KoujiInvoice{IssueDate: "2019-11-30", DueDate: "2019-12-31", Amount: 200000}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := s.GetKoujiFinance(ids["豊田築炉"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.InvoicedAmount != 900000 || summary.InvoicedTotal != 982000 || summary.Outstanding != 550000 || summary.Uninvoiced != 100000 {
+		t.Errorf("summary = invoiced %d total %d outstanding %d uninvoiced %d, want 900000, 982000, 550000, 100000",
+			summary.InvoicedAmount, summary.InvoicedTotal, summary.Outstanding, summary.Uninvoiced)
+	}
+
+	receivables := s.GetReceivables("2019-12-10")
+	if len(receivables) != 2 || receivables[0].CompanyName != "豊田築炉" {
+		t.Fatalf("receivables = %+v, want 豊田築炉 first", receivables)
+	}
+	if receivables[0].Overdue != 550000 || receivables[0].Invoices[0].DaysOverdue != 10 {
+		t.Errorf("豊田築炉 overdue = %d (%d days), want 550000 (10 days)", receivables[0].Overdue, receivables[0].Invoices[0].DaysOverdue)
+	}
+	if receivables[1].Overdue != 0 {
+		t.Errorf("愛知製鋼 overdue = %d, want 0", receivables[1].Overdue)
+	}
+
+	// 入金を記録すると売掛金から外れる
+	second.PaidDate = "2019-12-02"
+	second.PaidAmount = 0
+	if _, err := s.UpdateKoujiInvoice(ids["豊田築炉"], second.Id, second); err != nil {
+		t.Fatal(err)
+	}
+	if receivables := s.GetReceivables("2019-12-10"); len(receivables) != 1 || receivables[0].CompanyName != "愛知製鋼" {
+		t.Errorf("receivables after payment = %+v, want only 愛知製鋼", receivables)
+	}
+
+	months := s.GetMonthlyRevenue("2019-10", "2019-12")
+	if len(months) != 3 {
+		t.Fatalf("GetMonthlyRevenue() = %d months, want 3", len(months))
+	}
+	if months[0].Month != "2019-10" || months[0].InvoicedAmount != 500000 || months[0].PaidAmount != 432000 {
+		t.Errorf("2019-10 = %+v, want invoiced 500000 paid 432000", months[0])
+	}
+	if months[2].Month != "2019-12" || months[2].InvoicedAmount != 0 || months[2].PaidAmount != 550000 {
+		t.Errorf("2019-12 = %+v, want invoiced 0 paid 550000", months[2])
+	}
+
+	if err := s.DeleteKoujiInvoice(ids["豊田築炉"], "ZZZZZ"); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Errorf("DeleteKoujiInvoice(unknown) error = %v, want ErrInvoiceNotFound", err)
+	}
+}
+
+func TestKoujiInvoiceConcurrentAdds(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+
+	// 同時に追加しても、どの請求も失われない
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := s.AddKoujiInvoice(id, models.KoujiInvoice{Number: fmt.Sprintf("INV-%02d", i), IssueDate: "2099-06-30", Amount: 1000})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if finance, err := s.GetKoujiFinance(id); err != nil || len(finance.Invoices) != n {
+		t.Errorf("invoices after concurrent adds = %v, %v; want %d", finance, err, n)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+)
+
+// BuildKoujiGeoJSON は工事をGeoJSONのFeatureCollectionに変換する
+// 座標は登録されている現場から取得し、座標のない工事は含めない
+// bySiteがtrueの場合は工事ではなく現場ごとに1つのFeatureを出力する
+func (s *KoujiService) BuildKoujiGeoJSON(entries []models.KoujiEntry, bySite bool) (*models.GeoJSONFeatureCollection, error) {
+	locations, err := s.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+	located := make([]models.Location, 0, len(locations))
+	for _, location := range locations {
+		if location.Latitude != nil && location.Longitude != nil {
+			located = append(located, location)
+		}
+	}
+
+	collection := &models.GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]models.GeoJSONFeature, 0),
+	}
+	if bySite {
+		for _, site := range groupKoujiBySite(entries, located) {
+			if !site.registered || len(site.entries) == 0 {
+				continue
+			}
+			collection.Features = append(collection.Features, siteFeature(site))
+		}
+		return collection, nil
+	}
+
+	for _, entry := range entries {
+		for _, location := range located {
+			if LocationMatches(&entry, location.CompanyName, location.LocationName) {
+				collection.Features = append(collection.Features, koujiFeature(entry, location))
+				break
+			}
+		}
+	}
+	return collection, nil
+}
+
+func koujiFeature(entry models.KoujiEntry, location models.Location) models.GeoJSONFeature {
+	return models.GeoJSONFeature{
+		Type:     "Feature",
+		Id:       entry.Id,
+		Geometry: pointGeometry(location),
+		Properties: map[string]any{
+			"id":            entry.Id,
+			"company_name":  entry.CompanyName,
+			"location_name": entry.LocationName,
+			"status":        entry.Status,
+			"start_date":    formatImportDate(entry.StartDate),
+			"end_date":      formatImportDate(entry.EndDate),
+			"fiscal_year":   entry.FiscalYear,
+			"address":       location.Address,
+		},
+	}
+}
+
+// siteFeature は現場のFeatureを作成する（状態と日付は最新の工事のもの）
+func siteFeature(site *siteGroup) models.GeoJSONFeature {
+	latest := site.entries[0]
+	ids := make([]string, 0, len(site.entries))
+	for _, entry := range site.entries {
+		ids = append(ids, entry.Id)
+		if entry.StartDate.Time.After(latest.StartDate.Time) {
+			latest = entry
+		}
+	}
+	return models.GeoJSONFeature{
+		Type:     "Feature",
+		Geometry: pointGeometry(site.location),
+		Properties: map[string]any{
+			"company_name":  site.location.CompanyName,
+			"location_name": site.location.LocationName,
+			"address":       site.location.Address,
+			"kouji_count":   len(site.entries),
+			"kouji_ids":     ids,
+			"latest_id":     latest.Id,
+			"status":        latest.Status,
+			"start_date":    formatImportDate(latest.StartDate),
+			"end_date":      formatImportDate(latest.EndDate),
+		},
+	}
+}
+
+func pointGeometry(location models.Location) models.GeoJSONGeometry {
+	return models.GeoJSONGeometry{
+		Type:        "Point",
+		Coordinates: []float64{*location.Longitude, *location.Latitude},
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"testing"
+)
+
+func TestBuildKoujiGeoJSON(t *testing.T) {
+	lat, lon := 35.0436, 136.9
+	s := &KoujiService{LocationsPath: t.TempDir() + "/.inside.locations.yaml"}
+	if _, err := s.CreateLocation(models.Location{CompanyName: "豊田築炉", LocationName: "名和工場", Latitude: &lat, Longitude: &lon}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateLocation(models.Location{CompanyName: "豊田築炉", LocationName: "刈谷工場"}); err != nil {
+		t.Fatal(err)
+	}
+	entries := testKoujiEntries()
+
+	collection, err := s.BuildKoujiGeoJSON(entries, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 1 || collection.Features[0].Id != "AAAAA" {
+		t.Fatalf("Features = %+v, want only AAAAA", collection.Features)
+	}
+	if got := collection.Features[0].Geometry.Coordinates; got[0] != lon || got[1] != lat {
+		t.Errorf("Coordinates = %v, want [%v %v]", got, lon, lat)
+	}
+
+	sites, err := s.BuildKoujiGeoJSON(entries, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites.Features) != 1 || sites.Features[0].Properties["kouji_count"] != 1 {
+		t.Errorf("site Features = %+v, want one site with one kouji", sites.Features)
+	}
+}
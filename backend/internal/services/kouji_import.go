@@ -0,0 +1,452 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// ErrImportHasErrors は不正な行があるため取り込みを反映しなかったことを表す
+var ErrImportHasErrors = errors.New("不正な行があるため取り込みを反映しませんでした")
+
+// koujiImportField は取り込み可能な列の定義
+type koujiImportField struct {
+	// key は変更内容に表示するフィールド名
+	key string
+	// headers は列名として認識する名前
+	headers []string
+	// get は現在値を文字列で返す
+	get func(e *models.KoujiEntry) string
+	// set は値を検証して工事に設定する
+	set func(e *models.KoujiEntry, value string) error
+}
+
+// koujiImportFields は取り込みで更新できるフィールド
+var koujiImportFields = []koujiImportField{
+	{
+		key:     "start_date",
+		headers: []string{"開始日", "start_date"},
+		get:     func(e *models.KoujiEntry) string { return formatImportDate(e.StartDate) },
+		set: func(e *models.KoujiEntry, value string) error {
+			t, err := parseImportDate(value)
+			e.StartDate = t
+			return err
+		},
+	},
+	{
+		key:     "end_date",
+		headers: []string{"終了日", "end_date"},
+		get:     func(e *models.KoujiEntry) string { return formatImportDate(e.EndDate) },
+		set: func(e *models.KoujiEntry, value string) error {
+			t, err := parseImportDate(value)
+			e.EndDate = t
+			return err
+		},
+	},
+	{
+		key:     "description",
+		headers: []string{"説明", "description"},
+		get:     func(e *models.KoujiEntry) string { return e.Description },
+		set: func(e *models.KoujiEntry, value string) error {
+			e.Description = value
+			return nil
+		},
+	},
+	{
+		key:     "tags",
+		headers: []string{"タグ", "tags"},
+		get:     func(e *models.KoujiEntry) string { return strings.Join(e.Tags, ",") },
+		set: func(e *models.KoujiEntry, value string) error {
+			e.Tags = splitImportTags(value)
+			return nil
+		},
+	},
+	{
+		key:     "estimate_amount",
+		headers: []string{"見積金額", "estimate_amount"},
+		get:     func(e *models.KoujiEntry) string { return formatImportAmount(e.EstimateAmount) },
+		set: func(e *models.KoujiEntry, value string) error {
+			amount, err := parseImportAmount(value)
+			e.EstimateAmount = amount
+			return err
+		},
+	},
+	{
+		key:     "order_amount",
+		headers: []string{"受注金額", "order_amount"},
+		get:     func(e *models.KoujiEntry) string { return formatImportAmount(e.OrderAmount) },
+		set: func(e *models.KoujiEntry, value string) error {
+			amount, err := parseImportAmount(value)
+			e.OrderAmount = amount
+			return err
+		},
+	},
+}
+
+// 照合にのみ使用する列
+var (
+	importIdHeaders       = []string{"ID", "id"}
+	importCompanyHeaders  = []string{"会社名", "company_name", "company"}
+	importLocationHeaders = []string{"現場名", "location_name", "location"}
+)
+
+// ReadImportTable は取り込みファイルを文字列の表として読み込む
+// formatはcsvまたはxlsx。CSVはUTF-8（BOM有無）とShift_JISを自動判別する
+func ReadImportTable(data []byte, format string) ([][]string, error) {
+	switch format {
+	case "xlsx":
+		return utils.ReadXLSX(bytes.NewReader(data), int64(len(data)))
+	case "csv":
+		data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+		if !utf8.Valid(data) {
+			decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+			if err != nil {
+				return nil, fmt.Errorf("CSVの文字コードを判別できません: %w", err)
+			}
+			data = decoded
+		}
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		return r.ReadAll()
+	default:
+		return nil, fmt.Errorf("未対応の形式です: %s", format)
+	}
+}
+
+// ImportKoujiEntries は表の各行を既存の工事に照合し、変更内容を返す
+// 照合はID列があればIDで、なければ開始日・会社名・現場名で行う
+// applyがtrueで不正な行がない場合、すべての変更を1回の書き込みでデータベースに反映する
+func (s *KoujiService) ImportKoujiEntries(table [][]string, apply bool) (*models.KoujiImportResult, error) {
+	result := &models.KoujiImportResult{
+		Matched:        make([]models.KoujiImportMatch, 0),
+		Unmatched:      make([]models.KoujiImportRow, 0),
+		Ambiguous:      make([]models.KoujiImportAmbiguous, 0),
+		Errors:         make([]models.KoujiImportError, 0),
+		IgnoredColumns: make([]string, 0),
+	}
+	if len(table) == 0 {
+		return result, fmt.Errorf("ヘッダー行がありません")
+	}
+
+	schema, err := s.GetCustomFieldSchema()
+	if err != nil {
+		return result, err
+	}
+
+	// ヘッダーから列の役割を決定
+	header := table[0]
+	idCol, companyCol, locationCol := -1, -1, -1
+	type fieldColumn struct {
+		col   int
+		field *koujiImportField
+	}
+	var fieldCols []fieldColumn
+	for col, name := range header {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case containsHeader(importIdHeaders, name):
+			idCol = col
+		case containsHeader(importCompanyHeaders, name):
+			companyCol = col
+		case containsHeader(importLocationHeaders, name):
+			locationCol = col
+		default:
+			if field := findImportField(name); field != nil {
+				fieldCols = append(fieldCols, fieldColumn{col, field})
+			} else if def, ok := findCustomFieldColumn(schema, name); ok {
+				fieldCols = append(fieldCols, fieldColumn{col, customImportField(def)})
+			} else {
+				result.IgnoredColumns = append(result.IgnoredColumns, name)
+			}
+		}
+	}
+	startCol := -1
+	for _, fc := range fieldCols {
+		if fc.field.key == "start_date" {
+			startCol = fc.col
+		}
+	}
+	if idCol < 0 && (startCol < 0 || companyCol < 0) {
+		return result, fmt.Errorf("ID列、または開始日・会社名の列が必要です")
+	}
+
+	// 反映する場合は照合から保存までの間に他の更新が入らないようにする
+	if apply {
+		s.storeMu.Lock()
+		defer s.storeMu.Unlock()
+	}
+	entries := s.GetKoujiEntries()
+	byId := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		byId[entry.Id] = i
+	}
+	matchedRows := make(map[string]int)
+
+	for r, record := range table[1:] {
+		rowNum := r + 2
+		cell := func(col int) string {
+			if col < 0 || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		result.TotalRows++
+		row := models.KoujiImportRow{Row: rowNum, Values: make(map[string]string)}
+		for col, value := range record {
+			if col < len(header) && strings.TrimSpace(value) != "" {
+				row.Values[strings.TrimSpace(header[col])] = strings.TrimSpace(value)
+			}
+		}
+
+		// 照合
+		index, matchedBy := -1, ""
+		if id := cell(idCol); id != "" {
+			if i, ok := byId[id]; ok {
+				index, matchedBy = i, "id"
+			}
+		} else if startCol >= 0 && companyCol >= 0 {
+			date, err := parseImportDate(cell(startCol))
+			if err != nil {
+				result.Errors = append(result.Errors, models.KoujiImportError{Row: rowNum, Column: header[startCol], Message: err.Error()})
+				continue
+			}
+			candidates := matchKoujiByDateCompanyLocation(entries, date.Time, cell(companyCol), cell(locationCol))
+			if len(candidates) > 1 {
+				ids := make([]string, len(candidates))
+				for i, c := range candidates {
+					ids[i] = entries[c].Id
+				}
+				result.Ambiguous = append(result.Ambiguous, models.KoujiImportAmbiguous{KoujiImportRow: row, Candidates: ids})
+				continue
+			}
+			if len(candidates) == 1 {
+				index, matchedBy = candidates[0], "date_company_location"
+			}
+		}
+		if index < 0 {
+			result.Unmatched = append(result.Unmatched, row)
+			continue
+		}
+
+		entry := &entries[index]
+		if prev, ok := matchedRows[entry.Id]; ok {
+			result.Errors = append(result.Errors, models.KoujiImportError{
+				Row:     rowNum,
+				Message: fmt.Sprintf("%d行目と同じ工事(%s)に一致しました", prev, entry.Id),
+			})
+			continue
+		}
+		matchedRows[entry.Id] = rowNum
+
+		// 変更内容の適用（空欄は変更しない）
+		match := models.KoujiImportMatch{Row: rowNum, KoujiId: entry.Id, MatchedBy: matchedBy, Changes: make([]models.KoujiFieldChange, 0)}
+		for _, fc := range fieldCols {
+			col, field := fc.col, fc.field
+			value := cell(col)
+			if value == "" || (field.key == "start_date" && matchedBy != "id") {
+				continue
+			}
+			old := field.get(entry)
+			updated := *entry
+			if err := field.set(&updated, value); err != nil {
+				result.Errors = append(result.Errors, models.KoujiImportError{Row: rowNum, Column: header[col], Message: err.Error()})
+				continue
+			}
+			if next := field.get(&updated); next != old {
+				*entry = updated
+				match.Changes = append(match.Changes, models.KoujiFieldChange{Field: field.key, Old: old, New: next})
+			}
+		}
+		if len(match.Changes) > 0 {
+			if hasCustomFieldChange(match.Changes) {
+				if err := ValidateCustomFields(entry, schema); err != nil {
+					result.Errors = append(result.Errors, models.KoujiImportError{Row: rowNum, Message: err.Error()})
+				}
+			}
+			if err := s.ValidateKoujiTags([]models.KoujiEntry{*entry}); err != nil {
+				result.Errors = append(result.Errors, models.KoujiImportError{Row: rowNum, Message: err.Error()})
+			}
+			entry.Status = DetermineKoujiStatus(entry.StartDate, entry.EndDate)
+			result.UpdatedCount++
+		}
+		result.Matched = append(result.Matched, match)
+	}
+
+	if !apply {
+		return result, nil
+	}
+	if len(result.Errors) > 0 {
+		return result, ErrImportHasErrors
+	}
+	if result.UpdatedCount > 0 {
+		if err := s.saveKoujiEntries(entries); err != nil {
+			return result, err
+		}
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// matchKoujiByDateCompanyLocation は開始日・会社名・現場名で工事を照合し、候補のインデックスを返す
+// 現場名は完全一致を優先し、なければ部分一致で探す。現場名が空の場合は開始日と会社名のみで照合する
+func matchKoujiByDateCompanyLocation(entries []models.KoujiEntry, date time.Time, company, location string) []int {
+	var exact, partial []int
+	for i, entry := range entries {
+		if !sameDate(entry.StartDate.Time, date) || entry.CompanyName != company {
+			continue
+		}
+		switch {
+		case location == "" || entry.LocationName == location:
+			exact = append(exact, i)
+		case strings.Contains(entry.LocationName, location):
+			partial = append(partial, i)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return partial
+}
+
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// parseImportDate は日付文字列を解析する
+// Excelの日付セルはReadXLSXが表示形式から日付の文字列に変換するため、数字だけの値をシリアル値とはみなさない
+func parseImportDate(value string) (models.Timestamp, error) {
+	if t, err := utils.ParseTime(value); err == nil {
+		return models.NewTimestamp(t), nil
+	}
+	// Excelで入力されがちなゼロ埋めなしの日付（例: 2024/6/8）
+	for _, layout := range []string{"2006/1/2", "2006-1-2", "2006.1.2"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return models.NewTimestamp(t), nil
+		}
+	}
+	return models.Timestamp{}, fmt.Errorf("日付を解析できません: %s", value)
+}
+
+func formatImportDate(ts models.Timestamp) string {
+	if ts.Time.IsZero() {
+		return ""
+	}
+	return ts.Time.Format("2006-01-02")
+}
+
+// parseImportAmount は桁区切りや円記号を含む金額を円単位の整数に変換する
+func parseImportAmount(value string) (int64, error) {
+	n, err := toFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("金額は0以上で指定してください: %s", value)
+	}
+	return int64(math.Round(n)), nil
+}
+
+func formatImportAmount(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatInt(amount, 10)
+}
+
+// splitImportTags はカンマ・読点・空白区切りのタグを分割する
+func splitImportTags(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '、' || r == '，'
+	})
+	tags := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			tags = append(tags, f)
+		}
+	}
+	return tags
+}
+
+// findCustomFieldColumn は列名（custom.<キー>、キー、表示名）に対応するカスタムフィールドを探す
+func findCustomFieldColumn(schema []models.CustomFieldDefinition, header string) (models.CustomFieldDefinition, bool) {
+	key := strings.TrimPrefix(header, CustomFieldPrefix)
+	for _, def := range schema {
+		if def.Key == key || def.Label == header {
+			return def, true
+		}
+	}
+	return models.CustomFieldDefinition{}, false
+}
+
+// customImportField はカスタムフィールドを取り込むための列定義を作成する
+func customImportField(def models.CustomFieldDefinition) *koujiImportField {
+	return &koujiImportField{
+		key: CustomFieldPrefix + def.Key,
+		get: func(e *models.KoujiEntry) string { return FormatCustomFieldValue(e.CustomFields[def.Key]) },
+		set: func(e *models.KoujiEntry, value string) error {
+			v, err := NormalizeCustomFieldValue(def, value)
+			if err != nil {
+				return err
+			}
+			fields := make(map[string]any, len(e.CustomFields)+1)
+			for k, existing := range e.CustomFields {
+				fields[k] = existing
+			}
+			fields[def.Key] = v
+			e.CustomFields = fields
+			return nil
+		},
+	}
+}
+
+func hasCustomFieldChange(changes []models.KoujiFieldChange) bool {
+	for _, change := range changes {
+		if strings.HasPrefix(change.Field, CustomFieldPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func findImportField(header string) *koujiImportField {
+	for i := range koujiImportFields {
+		if containsHeader(koujiImportFields[i].headers, header) {
+			return &koujiImportFields[i]
+		}
+	}
+	return nil
+}
+
+func containsHeader(headers []string, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
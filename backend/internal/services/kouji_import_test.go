@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestParseImportDate(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  string
+	}{
+		{"2024-06-18", "2024-06-18"},
+		{"2024/6/8", "2024-06-08"},
+		{"20240618", "2024-06-18"},
+	} {
+		got, err := parseImportDate(tt.value)
+		if err != nil {
+			t.Errorf("parseImportDate(%q): %v", tt.value, err)
+			continue
+		}
+		if d := got.Time.Format("2006-01-02"); d != tt.want {
+			t.Errorf("parseImportDate(%q) = %s, want %s", tt.value, d, tt.want)
+		}
+	}
+	// 数字だけの値をExcelのシリアル値とみなさない
+	for _, value := range []string{"2024", "45461"} {
+		if got, err := parseImportDate(value); err == nil {
+			t.Errorf("parseImportDate(%q) = %s, want error", value, got.Time.Format("2006-01-02"))
+		}
+	}
+}
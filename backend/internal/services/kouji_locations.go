@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrLocationNotFound は現場が登録されておらず、工事もない場合のエラー
+var ErrLocationNotFound = errors.New("現場が見つかりません")
+
+// GetLocations は登録されている現場の一覧を返す
+func (s *KoujiService) GetLocations() ([]models.Location, error) {
+	locations := []models.Location{}
+	if err := loadYAMLFile(s.LocationsPath, &locations); err != nil {
+		return nil, fmt.Errorf("現場の一覧を読み込めません: %w", err)
+	}
+	return locations, nil
+}
+
+// GetLocation は会社名と現場名で現場を検索する
+func (s *KoujiService) GetLocation(company, location string) (models.Location, error) {
+	locations, err := s.GetLocations()
+	if err != nil {
+		return models.Location{}, err
+	}
+	if i := findLocation(locations, company, location); i >= 0 {
+		return locations[i], nil
+	}
+	return models.Location{}, fmt.Errorf("%w: %s %s", ErrLocationNotFound, company, location)
+}
+
+// CreateLocation は現場を登録する
+func (s *KoujiService) CreateLocation(location models.Location) (models.Location, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	locations, err := s.GetLocations()
+	if err != nil {
+		return models.Location{}, err
+	}
+	locations = append(locations, location)
+	if err := s.saveLocations(locations); err != nil {
+		return models.Location{}, err
+	}
+	return locations[len(locations)-1], nil
+}
+
+// UpdateLocation は会社名と現場名で指定した現場を更新する
+func (s *KoujiService) UpdateLocation(company, location string, updated models.Location) (models.Location, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	locations, err := s.GetLocations()
+	if err != nil {
+		return models.Location{}, err
+	}
+	i := findLocation(locations, company, location)
+	if i < 0 {
+		return models.Location{}, fmt.Errorf("%w: %s %s", ErrLocationNotFound, company, location)
+	}
+	locations[i] = updated
+	if err := s.saveLocations(locations); err != nil {
+		return models.Location{}, err
+	}
+	return locations[i], nil
+}
+
+// DeleteLocation は会社名と現場名で指定した現場を削除する
+func (s *KoujiService) DeleteLocation(company, location string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	locations, err := s.GetLocations()
+	if err != nil {
+		return err
+	}
+	i := findLocation(locations, company, location)
+	if i < 0 {
+		return fmt.Errorf("%w: %s %s", ErrLocationNotFound, company, location)
+	}
+	return s.saveLocations(append(locations[:i], locations[i+1:]...))
+}
+
+// GetLocationHistory は現場で行った工事と予定している工事を開始日の昇順で返す
+// 現場が登録されていない場合も、該当する工事があれば履歴を返す
+func (s *KoujiService) GetLocationHistory(company, location string) (*models.LocationHistory, error) {
+	history := &models.LocationHistory{Jobs: make([]models.LocationJob, 0)}
+	registered, err := s.GetLocation(company, location)
+	switch {
+	case err == nil:
+		history.Location = registered
+		history.Registered = true
+	case errors.Is(err, ErrLocationNotFound):
+		history.Location = models.Location{CompanyName: company, LocationName: location}
+	default:
+		return nil, err
+	}
+
+	var entries []models.KoujiEntry
+	for _, entry := range s.GetKoujiEntries() {
+		if LocationMatches(&entry, history.Location.CompanyName, history.Location.LocationName) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 && !history.Registered {
+		return nil, fmt.Errorf("%w: %s %s", ErrLocationNotFound, company, location)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartDate.Time.Before(entries[j].StartDate.Time)
+	})
+	now := time.Now()
+	buildLocationHistory(history, entries, now)
+	history.Prediction = PredictNextJob(history.Location, history.Registered, entries, now)
+	return history, nil
+}
+
+// buildLocationHistory は工事の間隔と前回の工事からの経過日数を計算する
+func buildLocationHistory(history *models.LocationHistory, entries []models.KoujiEntry, now time.Time) {
+	var lastEnd time.Time
+	var intervals []int
+	for _, entry := range entries {
+		job := models.LocationJob{KoujiEntry: entry}
+		if !lastEnd.IsZero() && !entry.StartDate.Time.IsZero() {
+			days := daysBetween(lastEnd, entry.StartDate.Time)
+			job.DaysSincePrevious = &days
+			if entry.Status == "完了" {
+				intervals = append(intervals, days)
+			}
+		}
+		if end := entry.EndDate.Time; end.After(lastEnd) {
+			lastEnd = end
+		}
+		history.Jobs = append(history.Jobs, job)
+	}
+	history.Count = len(history.Jobs)
+
+	var lastCompleted time.Time
+	for _, entry := range entries {
+		if entry.Status == "完了" && entry.EndDate.Time.After(lastCompleted) {
+			lastCompleted = entry.EndDate.Time
+		}
+	}
+	if !lastCompleted.IsZero() {
+		days := daysBetween(lastCompleted, now)
+		history.DaysSinceLastJob = &days
+	}
+	if len(intervals) > 0 {
+		sum := 0
+		for _, d := range intervals {
+			sum += d
+		}
+		average := float64(sum) / float64(len(intervals))
+		history.AverageIntervalDays = &average
+	}
+}
+
+// LocationMatches は工事が現場に該当するかを判定する
+// 会社名は正規化して比較し、工事の現場名は登録名と一致するか「登録名 + 空白」で始まる場合に該当とする
+// （例: 「名和工場 3号炉」は「名和工場」に該当する）
+func LocationMatches(entry *models.KoujiEntry, company, location string) bool {
+	if NormalizeCompanyName(entry.CompanyName) != NormalizeCompanyName(company) {
+		return false
+	}
+	name := strings.TrimSpace(entry.LocationName)
+	location = strings.TrimSpace(location)
+	if name == location {
+		return true
+	}
+	rest, ok := strings.CutPrefix(name, location)
+	return ok && location != "" && strings.IndexAny(rest, " 　") == 0
+}
+
+// saveLocations は現場の一覧を検証して保存する
+func (s *KoujiService) saveLocations(locations []models.Location) error {
+	seen := make(map[string]bool, len(locations))
+	for i := range locations {
+		location := &locations[i]
+		location.CompanyName = strings.TrimSpace(location.CompanyName)
+		location.LocationName = strings.TrimSpace(location.LocationName)
+		if location.CompanyName == "" || location.LocationName == "" {
+			return fmt.Errorf("会社名と現場名は必須です")
+		}
+		if location.Latitude != nil && (*location.Latitude < -90 || *location.Latitude > 90) {
+			return fmt.Errorf("%s %s: 緯度は-90〜90で指定してください", location.CompanyName, location.LocationName)
+		}
+		if location.Longitude != nil && (*location.Longitude < -180 || *location.Longitude > 180) {
+			return fmt.Errorf("%s %s: 経度は-180〜180で指定してください", location.CompanyName, location.LocationName)
+		}
+		key := locationKey(location.CompanyName, location.LocationName)
+		if seen[key] {
+			return fmt.Errorf("現場が重複しています: %s %s", location.CompanyName, location.LocationName)
+		}
+		seen[key] = true
+	}
+	return saveYAMLFile(s.LocationsPath, locations)
+}
+
+// findLocation は会社名と現場名に一致する現場の位置を返す（見つからない場合は-1）
+func findLocation(locations []models.Location, company, location string) int {
+	key := locationKey(company, location)
+	for i, l := range locations {
+		if locationKey(l.CompanyName, l.LocationName) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func locationKey(company, location string) string {
+	return NormalizeCompanyName(company) + "\x00" + NormalizeTag(location)
+}
+
+// daysBetween は日付の差を日数で返す（時刻は切り捨てる）
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestLocationMatches(t *testing.T) {
+	tests := []struct {
+		entry models.KoujiEntry
+		want  bool
+	}{
+		{models.KoujiEntry{CompanyName: "豊田築炉", LocationName: "名和工場"}, true},
+		{models.KoujiEntry{CompanyName: "豊田築炉", LocationName: "名和工場 3号炉"}, true},
+		{models.KoujiEntry{CompanyName: "豊田築炉(株)", LocationName: "名和工場"}, true},
+		{models.KoujiEntry{CompanyName: "豊田築炉", LocationName: "名和工場第二"}, false},
+		{models.KoujiEntry{CompanyName: "愛知製鋼", LocationName: "名和工場"}, false},
+	}
+	for _, tt := range tests {
+		if got := LocationMatches(&tt.entry, "豊田築炉", "名和工場"); got != tt.want {
+			t.Errorf("LocationMatches(%s %s) = %v, want %v", tt.entry.CompanyName, tt.entry.LocationName, got, tt.want)
+		}
+	}
+}
+
+func TestBuildLocationHistory(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	entries := []models.KoujiEntry{
+		{Id: "A", Status: "完了", StartDate: date(2018, 5, 1), EndDate: date(2018, 5, 31)},
+		{Id: "B", Status: "完了", StartDate: date(2021, 5, 1), EndDate: date(2021, 5, 31)},
+		{Id: "C", Status: "予定", StartDate: date(2027, 5, 1), EndDate: date(2027, 5, 31)},
+	}
+	history := &models.LocationHistory{}
+	buildLocationHistory(history, entries, time.Date(2022, 5, 31, 12, 0, 0, 0, time.Local))
+
+	if history.Count != 3 {
+		t.Fatalf("Count = %d, want 3", history.Count)
+	}
+	if history.Jobs[0].DaysSincePrevious != nil {
+		t.Errorf("first job should have no interval")
+	}
+	if got := *history.Jobs[1].DaysSincePrevious; got != 1066 {
+		t.Errorf("DaysSincePrevious = %d, want 1066", got)
+	}
+	if history.DaysSinceLastJob == nil || *history.DaysSinceLastJob != 365 {
+		t.Errorf("DaysSinceLastJob = %v, want 365", history.DaysSinceLastJob)
+	}
+	if history.AverageIntervalDays == nil || *history.AverageIntervalDays != 1066 {
+		t.Errorf("AverageIntervalDays = %v, want 1066", history.AverageIntervalDays)
+	}
+}
+
+func TestPredictNextJob(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	location := models.Location{CompanyName: "豊田築炉", LocationName: "名和工場"}
+	entries := []models.KoujiEntry{
+		{Id: "A", Status: "完了", StartDate: date(2015, 5, 1)},
+		{Id: "B", Status: "完了", StartDate: date(2018, 5, 1)},
+		{Id: "C", Status: "完了", StartDate: date(2021, 5, 1)},
+	}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+	prediction := PredictNextJob(location, true, entries, now)
+	if prediction == nil {
+		t.Fatal("PredictNextJob returned nil")
+	}
+	if prediction.TypicalIntervalDays != 1096 {
+		t.Errorf("TypicalIntervalDays = %d, want 1096", prediction.TypicalIntervalDays)
+	}
+	if got := prediction.PredictedDate.Time.Format("2006-01-02"); got != "2024-05-01" {
+		t.Errorf("PredictedDate = %s, want 2024-05-01", got)
+	}
+	if prediction.DaysUntil != 61 {
+		t.Errorf("DaysUntil = %d, want 61", prediction.DaysUntil)
+	}
+
+	scheduled := append(entries, models.KoujiEntry{Id: "D", Status: "予定", StartDate: date(2024, 6, 1)})
+	if got := PredictNextJob(location, true, scheduled, now).ScheduledKoujiId; got != "D" {
+		t.Errorf("ScheduledKoujiId = %q, want D", got)
+	}
+	if PredictNextJob(location, true, entries[:1], now) != nil {
+		t.Errorf("a single job should not produce a prediction")
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// siteGroup は現場ごとにまとめた工事
+type siteGroup struct {
+	location   models.Location
+	registered bool
+	entries    []models.KoujiEntry
+}
+
+// GetMaintenancePredictions は次回工事の予測日が今日からmonthsか月以内の現場を予測日の昇順で返す
+// 予測日を過ぎても次回工事が予定されていない現場も含める
+func (s *KoujiService) GetMaintenancePredictions(months int) ([]models.MaintenancePrediction, error) {
+	locations, err := s.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	limit := now.AddDate(0, months, 0)
+	predictions := make([]models.MaintenancePrediction, 0)
+	for _, site := range groupKoujiBySite(s.GetKoujiEntries(), locations) {
+		prediction := PredictNextJob(site.location, site.registered, site.entries, now)
+		if prediction == nil || prediction.ScheduledKoujiId != "" {
+			continue
+		}
+		if prediction.PredictedDate.Time.After(limit) {
+			continue
+		}
+		predictions = append(predictions, *prediction)
+	}
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return predictions[i].PredictedDate.Time.Before(predictions[j].PredictedDate.Time)
+	})
+	return predictions, nil
+}
+
+// PredictNextJob は現場の過去の工事の開始日の間隔（中央値）から次回工事の開始日を予測する
+// 予定の工事は間隔の計算に含めず、最後の工事以降に予定されている工事があればそのIDを設定する
+// 開始済みの工事が2件未満の場合はnilを返す
+func PredictNextJob(location models.Location, registered bool, entries []models.KoujiEntry, now time.Time) *models.MaintenancePrediction {
+	var starts []time.Time
+	for _, entry := range entries {
+		if entry.Status == "予定" || entry.StartDate.Time.IsZero() {
+			continue
+		}
+		starts = append(starts, entry.StartDate.Time)
+	}
+	if len(starts) < 2 {
+		return nil
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	intervals := make([]int, 0, len(starts)-1)
+	for i := 1; i < len(starts); i++ {
+		intervals = append(intervals, daysBetween(starts[i-1], starts[i]))
+	}
+	sort.Ints(intervals)
+	typical := intervals[len(intervals)/2]
+	if len(intervals)%2 == 0 {
+		typical = (intervals[len(intervals)/2-1] + typical) / 2
+	}
+
+	last := starts[len(starts)-1]
+	predicted := last.AddDate(0, 0, typical)
+	prediction := &models.MaintenancePrediction{
+		CompanyName:         location.CompanyName,
+		LocationName:        location.LocationName,
+		Registered:          registered,
+		JobCount:            len(starts),
+		LastStartDate:       models.NewTimestamp(last),
+		TypicalIntervalDays: typical,
+		PredictedDate:       models.NewTimestamp(predicted),
+		DaysUntil:           daysBetween(now, predicted),
+	}
+	for _, entry := range entries {
+		if entry.Status == "予定" && entry.StartDate.Time.After(last) {
+			prediction.ScheduledKoujiId = entry.Id
+			break
+		}
+	}
+	return prediction
+}
+
+// groupKoujiBySite は工事を現場ごとにまとめる
+// 登録されている現場に該当する工事はその現場に、それ以外は会社名と現場名の最初の語でまとめる
+func groupKoujiBySite(entries []models.KoujiEntry, locations []models.Location) []*siteGroup {
+	groups := make([]*siteGroup, 0)
+	byKey := make(map[string]*siteGroup)
+	for _, location := range locations {
+		group := &siteGroup{location: location, registered: true}
+		groups = append(groups, group)
+		byKey[locationKey(location.CompanyName, location.LocationName)] = group
+	}
+
+	for _, entry := range entries {
+		var group *siteGroup
+		for _, g := range groups {
+			if g.registered && LocationMatches(&entry, g.location.CompanyName, g.location.LocationName) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			name := strings.TrimSpace(entry.LocationName)
+			if fields := strings.Fields(name); len(fields) > 0 {
+				name = fields[0]
+			}
+			key := locationKey(entry.CompanyName, name)
+			if group = byKey[key]; group == nil {
+				group = &siteGroup{location: models.Location{CompanyName: entry.CompanyName, LocationName: name}}
+				groups = append(groups, group)
+				byKey[key] = group
+			}
+		}
+		group.entries = append(group.entries, entry)
+	}
+	return groups
+}
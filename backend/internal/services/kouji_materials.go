@@ -0,0 +1,440 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 資材・在庫のエラー
+var (
+	ErrMaterialNotFound      = errors.New("資材が登録されていません")
+	ErrStockMovementNotFound = errors.New("入出庫がありません")
+)
+
+// GetMaterials は資材マスターの一覧を返す
+func (s *KoujiService) GetMaterials() ([]models.Material, error) {
+	materials := []models.Material{}
+	if err := loadYAMLFile(s.MaterialsPath, &materials); err != nil {
+		return nil, fmt.Errorf("資材マスターを読み込めません: %w", err)
+	}
+	return materials, nil
+}
+
+// GetMaterial は資材コードで資材を検索する
+func (s *KoujiService) GetMaterial(code string) (models.Material, error) {
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return models.Material{}, err
+	}
+	if i := findMaterial(materials, code); i >= 0 {
+		return materials[i], nil
+	}
+	return models.Material{}, fmt.Errorf("%w: %s", ErrMaterialNotFound, code)
+}
+
+// CreateMaterial は資材を登録する
+func (s *KoujiService) CreateMaterial(material models.Material) (models.Material, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return models.Material{}, err
+	}
+	materials = append(materials, material)
+	if err := s.saveMaterials(materials); err != nil {
+		return models.Material{}, err
+	}
+	return materials[len(materials)-1], nil
+}
+
+// UpdateMaterial は資材コードで指定した資材を更新する（コードは変更できない）
+func (s *KoujiService) UpdateMaterial(code string, material models.Material) (models.Material, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return models.Material{}, err
+	}
+	i := findMaterial(materials, code)
+	if i < 0 {
+		return models.Material{}, fmt.Errorf("%w: %s", ErrMaterialNotFound, code)
+	}
+	if material.Code == "" {
+		material.Code = materials[i].Code
+	}
+	if material.Code != materials[i].Code {
+		return models.Material{}, fmt.Errorf("資材コードは変更できません: %s", material.Code)
+	}
+	materials[i] = material
+	if err := s.saveMaterials(materials); err != nil {
+		return models.Material{}, err
+	}
+	return materials[i], nil
+}
+
+// DeleteMaterial は資材コードで指定した資材を削除する（入出庫がある資材は削除できない）
+func (s *KoujiService) DeleteMaterial(code string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return err
+	}
+	i := findMaterial(materials, code)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrMaterialNotFound, code)
+	}
+	movements, err := s.loadStockMovements()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(movements, func(m models.StockMovement) bool { return m.Code == code }) {
+		return fmt.Errorf("入出庫がある資材は削除できません: %s", code)
+	}
+	return s.saveMaterials(slices.Delete(materials, i, i+1))
+}
+
+// GetKoujiMaterialUsage は工事の資材の予定数量と実績を返す
+// 予定のない資材でも出庫があれば含める
+func (s *KoujiService) GetKoujiMaterialUsage(koujiId string) ([]models.KoujiMaterialUsage, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.GetStockMovements(StockMovementQuery{KoujiId: koujiId})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]models.KoujiMaterialUsage, 0)
+	index := make(map[string]int)
+	usage := func(code string) *models.KoujiMaterialUsage {
+		if i, ok := index[code]; ok {
+			return &usages[i]
+		}
+		u := models.KoujiMaterialUsage{Code: code}
+		if i := findMaterial(materials, code); i >= 0 {
+			u.Name, u.Unit = materials[i].Name, materials[i].Unit
+		}
+		index[code] = len(usages)
+		usages = append(usages, u)
+		return &usages[len(usages)-1]
+	}
+	for _, m := range entry.Materials {
+		usage(m.Code).Planned += m.Planned
+	}
+	for _, m := range movements {
+		u := usage(m.Code)
+		u.Actual += consumedQuantity(m)
+		u.Cost += consumedQuantity(m) * movementUnitPrice(m, materials)
+	}
+	for i := range usages {
+		usages[i].Difference = usages[i].Actual - usages[i].Planned
+	}
+	return usages, nil
+}
+
+// UpdateKoujiMaterials は工事の資材の予定数量を置き換える
+func (s *KoujiService) UpdateKoujiMaterials(koujiId string, planned []models.KoujiMaterial) ([]models.KoujiMaterialUsage, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(planned))
+	for _, m := range planned {
+		if findMaterial(materials, m.Code) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMaterialNotFound, m.Code)
+		}
+		if m.Planned < 0 {
+			return nil, fmt.Errorf("%s: 予定数量が負の値です", m.Code)
+		}
+		if seen[m.Code] {
+			return nil, fmt.Errorf("資材が重複しています: %s", m.Code)
+		}
+		seen[m.Code] = true
+	}
+
+	entries := s.GetKoujiEntries()
+	i := slices.IndexFunc(entries, func(e models.KoujiEntry) bool { return e.Id == koujiId })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+	}
+	entries[i].Materials = planned
+	if len(planned) == 0 {
+		entries[i].Materials = nil
+	}
+	if err := s.saveKoujiEntries(entries); err != nil {
+		return nil, err
+	}
+	return s.GetKoujiMaterialUsage(koujiId)
+}
+
+// StockMovementQuery は入出庫の絞り込み条件（空のフィールドは条件として扱わない）
+type StockMovementQuery struct {
+	Code    string
+	KoujiId string
+	// 期間（YYYY-MM-DD、両端を含む）
+	From, To string
+}
+
+// GetStockMovements は条件に一致する入出庫を日付順に返す
+func (s *KoujiService) GetStockMovements(query StockMovementQuery) ([]models.StockMovement, error) {
+	movements, err := s.loadStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]models.StockMovement, 0)
+	for _, m := range movements {
+		if (query.Code != "" && m.Code != query.Code) || (query.KoujiId != "" && m.KoujiId != query.KoujiId) {
+			continue
+		}
+		if (query.From != "" && m.Date < query.From) || (query.To != "" && m.Date > query.To) {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered, nil
+}
+
+// AddStockMovement は入出庫を登録し、在庫がマイナスになる場合などの警告を返す
+// 資材マスターの現在の単価を入出庫に記録する
+func (s *KoujiService) AddStockMovement(movement models.StockMovement) (models.StockMovement, []string, error) {
+	// 資材の確認から保存までの間に資材が削除されたり、他の入出庫が登録されたりしないようにする
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return models.StockMovement{}, nil, err
+	}
+	i := findMaterial(materials, movement.Code)
+	if i < 0 {
+		return models.StockMovement{}, nil, fmt.Errorf("%w: %s", ErrMaterialNotFound, movement.Code)
+	}
+	if movement.Date == "" {
+		movement.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", movement.Date); err != nil {
+		return models.StockMovement{}, nil, fmt.Errorf("日付はYYYY-MM-DDで指定してください: %s", movement.Date)
+	}
+	switch movement.Type {
+	case models.StockMovementReceipt, models.StockMovementIssue, models.StockMovementReturn:
+		if movement.Quantity <= 0 {
+			return models.StockMovement{}, nil, fmt.Errorf("数量は正の値で指定してください: %v", movement.Quantity)
+		}
+	case models.StockMovementAdjust:
+		if movement.Quantity == 0 {
+			return models.StockMovement{}, nil, fmt.Errorf("調整の数量が0です")
+		}
+	default:
+		return models.StockMovement{}, nil, fmt.Errorf("未対応の入出庫の種類です: %s", movement.Type)
+	}
+	if movement.Type == models.StockMovementIssue || movement.Type == models.StockMovementReturn {
+		if movement.KoujiId == "" {
+			return models.StockMovement{}, nil, fmt.Errorf("出庫・返却には工事IDが必要です")
+		}
+	}
+	if movement.KoujiId != "" {
+		if _, err := s.GetKoujiEntryByID(movement.KoujiId); err != nil {
+			return models.StockMovement{}, nil, err
+		}
+	}
+
+	movement.UnitPrice = materials[i].UnitPrice
+
+	movements, err := s.loadStockMovements()
+	if err != nil {
+		return models.StockMovement{}, nil, err
+	}
+	movement.Id = models.NewIDFromString(strings.Join([]string{
+		movement.Date, movement.Code, movement.Type, movement.KoujiId,
+		strconv.FormatFloat(movement.Quantity, 'f', -1, 64), time.Now().Format(time.RFC3339Nano),
+	}, "\x00")).Len5()
+	movements = append(movements, movement)
+	if err := s.saveStockMovements(movements); err != nil {
+		return models.StockMovement{}, nil, err
+	}
+
+	warnings := make([]string, 0)
+	if stock := stockQuantity(movements, movement.Code); stock < 0 {
+		warnings = append(warnings, fmt.Sprintf("%s の在庫がマイナスです: %v%s", materials[i].Name, stock, materials[i].Unit))
+	}
+	return movement, warnings, nil
+}
+
+// DeleteStockMovement は入出庫を削除する
+func (s *KoujiService) DeleteStockMovement(id string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	movements, err := s.loadStockMovements()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(movements, func(m models.StockMovement) bool { return m.Id == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrStockMovementNotFound, id)
+	}
+	return s.saveStockMovements(slices.Delete(movements, i, i+1))
+}
+
+// GetStockLevels は資材ごとの現在の在庫数を返す
+func (s *KoujiService) GetStockLevels() ([]models.StockLevel, error) {
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.loadStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	levels := make([]models.StockLevel, 0, len(materials))
+	for _, material := range materials {
+		levels = append(levels, models.StockLevel{
+			Code:     material.Code,
+			Name:     material.Name,
+			Unit:     material.Unit,
+			Quantity: stockQuantity(movements, material.Code),
+		})
+	}
+	return levels, nil
+}
+
+// GetMaterialConsumption は期間中の資材の消費量（出庫 - 返却）を工事ごと（byMonth=false）または月ごとに集計する
+func (s *KoujiService) GetMaterialConsumption(from, to string, byMonth bool) ([]models.MaterialConsumption, error) {
+	materials, err := s.GetMaterials()
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.GetStockMovements(StockMovementQuery{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.MaterialConsumption, 0)
+	index := make(map[string]int)
+	for _, m := range movements {
+		quantity := consumedQuantity(m)
+		if quantity == 0 {
+			continue
+		}
+		key := m.KoujiId
+		if byMonth {
+			key = m.Date[:7]
+		}
+		i, ok := index[key+"\x00"+m.Code]
+		if !ok {
+			row := models.MaterialConsumption{Key: key, Code: m.Code}
+			if j := findMaterial(materials, m.Code); j >= 0 {
+				row.Name, row.Unit = materials[j].Name, materials[j].Unit
+			}
+			i = len(rows)
+			index[key+"\x00"+m.Code] = i
+			rows = append(rows, row)
+		}
+		rows[i].Quantity += quantity
+		rows[i].Cost += quantity * movementUnitPrice(m, materials)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Key != rows[j].Key {
+			return rows[i].Key < rows[j].Key
+		}
+		return rows[i].Code < rows[j].Code
+	})
+	return rows, nil
+}
+
+// consumedQuantity は入出庫が工事で消費した数量を返す（出庫は正、返却は負、それ以外は0）
+func consumedQuantity(m models.StockMovement) float64 {
+	switch m.Type {
+	case models.StockMovementIssue:
+		return m.Quantity
+	case models.StockMovementReturn:
+		return -m.Quantity
+	}
+	return 0
+}
+
+// movementUnitPrice は入出庫の単価を返す
+// 単価を記録する前に登録された入出庫（単価が0）は資材マスターの現在の単価を使う
+func movementUnitPrice(m models.StockMovement, materials []models.Material) float64 {
+	if m.UnitPrice > 0 {
+		return m.UnitPrice
+	}
+	if i := findMaterial(materials, m.Code); i >= 0 {
+		return materials[i].UnitPrice
+	}
+	return 0
+}
+
+// stockQuantity は資材の在庫数を入出庫から計算する
+func stockQuantity(movements []models.StockMovement, code string) float64 {
+	var quantity float64
+	for _, m := range movements {
+		if m.Code != code {
+			continue
+		}
+		switch m.Type {
+		case models.StockMovementReceipt, models.StockMovementReturn, models.StockMovementAdjust:
+			quantity += m.Quantity
+		case models.StockMovementIssue:
+			quantity -= m.Quantity
+		}
+	}
+	return quantity
+}
+
+// saveMaterials は資材マスターを検証して保存する
+func (s *KoujiService) saveMaterials(materials []models.Material) error {
+	seen := make(map[string]bool, len(materials))
+	for i := range materials {
+		material := &materials[i]
+		material.Code = strings.TrimSpace(material.Code)
+		if material.Code == "" || strings.TrimSpace(material.Name) == "" {
+			return fmt.Errorf("資材コードと名前は必須です")
+		}
+		if seen[material.Code] {
+			return fmt.Errorf("資材コードが重複しています: %s", material.Code)
+		}
+		seen[material.Code] = true
+		if material.UnitPrice < 0 {
+			return fmt.Errorf("%s: 単価が負の値です", material.Code)
+		}
+	}
+	return saveYAMLFile(s.MaterialsPath, materials)
+}
+
+func (s *KoujiService) loadStockMovements() ([]models.StockMovement, error) {
+	movements := []models.StockMovement{}
+	if err := loadYAMLFile(s.StockPath, &movements); err != nil {
+		return nil, fmt.Errorf("入出庫を読み込めません: %w", err)
+	}
+	return movements, nil
+}
+
+// saveStockMovements は入出庫を日付順に並べて保存する
+func (s *KoujiService) saveStockMovements(movements []models.StockMovement) error {
+	sort.SliceStable(movements, func(i, j int) bool { return movements[i].Date < movements[j].Date })
+	return saveYAMLFile(s.StockPath, movements)
+}
+
+// findMaterial は資材コードが一致する資材の位置を返す（見つからない場合は-1）
+func findMaterial(materials []models.Material, code string) int {
+	return slices.IndexFunc(materials, func(m models.Material) bool { return m.Code == code })
+}
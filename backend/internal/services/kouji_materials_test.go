@@ -0,0 +1,120 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"sync"
+	"testing"
+)
+
+func TestKoujiMaterialUsage(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+	for _, m := range []models.Material{
+		{Code: "SK34", Name: "SK34 並形れんが", Unit: "個", UnitPrice: 450},
+		{Code: "MORTAR", Name: "耐火モルタル", Unit: "袋", UnitPrice: 2000},
+	} {
+		if _, err := s.CreateMaterial(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.UpdateKoujiMaterials(id, []models.KoujiMaterial{{Code: "SK34", Planned: 1000}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []models.StockMovement{
+		{Date: "2099-06-01", Code: "SK34", Type: models.StockMovementReceipt, Quantity: 2000},
+		{Date: "2099-06-18", Code: "SK34", Type: models.StockMovementIssue, Quantity: 1200, KoujiId: id},
+		{Date: "2099-07-01", Code: "SK34", Type: models.StockMovementReturn, Quantity: 150, KoujiId: id},
+		{Date: "2099-06-18", Code: "MORTAR", Type: models.StockMovementIssue, Quantity: 10, KoujiId: id},
+	} {
+		if _, _, err := s.AddStockMovement(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usages, err := s.GetKoujiMaterialUsage(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]models.KoujiMaterialUsage{
+		"SK34":   {Planned: 1000, Actual: 1050, Difference: 50, Cost: 472500},
+		"MORTAR": {Planned: 0, Actual: 10, Difference: 10, Cost: 20000},
+	}
+	if len(usages) != len(want) {
+		t.Fatalf("usages = %+v, want %d materials", usages, len(want))
+	}
+	for _, u := range usages {
+		w := want[u.Code]
+		if u.Planned != w.Planned || u.Actual != w.Actual || u.Difference != w.Difference || u.Cost != w.Cost {
+			t.Errorf("%s = %+v, want %+v", u.Code, u, w)
+		}
+	}
+
+	levels, err := s.GetStockLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels[0].Code != "SK34" || levels[0].Quantity != 950 {
+		t.Errorf("stock of SK34 = %+v, want 950", levels[0])
+	}
+	// MORTARは入庫なしで出庫したため在庫がマイナスになる
+	if levels[1].Quantity != -10 {
+		t.Errorf("stock of MORTAR = %v, want -10", levels[1].Quantity)
+	}
+
+	// 単価を変更しても登録済みの入出庫の原価は変わらない
+	if _, err := s.UpdateMaterial("SK34", models.Material{Name: "SK34 並形れんが", Unit: "個", UnitPrice: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.AddStockMovement(models.StockMovement{Date: "2099-07-02", Code: "SK34", Type: models.StockMovementIssue, Quantity: 100, KoujiId: id}); err != nil {
+		t.Fatal(err)
+	}
+	if usages, err = s.GetKoujiMaterialUsage(id); err != nil {
+		t.Fatal(err)
+	}
+	if usages[0].Code != "SK34" || usages[0].Actual != 1150 || usages[0].Cost != 472500+60000 {
+		t.Errorf("SK34 after price change = %+v, want 1150 and 532500", usages[0])
+	}
+
+	monthly, err := s.GetMaterialConsumption("2099-07-01", "2099-07-01", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monthly) != 1 || monthly[0].Key != "2099-07" || monthly[0].Quantity != -150 || monthly[0].Cost != -67500 {
+		t.Errorf("consumption on 2099-07-01 = %+v, want SK34 -150 and -67500", monthly)
+	}
+}
+
+func TestStockMovementConcurrentAdds(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	if _, err := s.CreateMaterial(models.Material{Code: "SK34", Name: "SK34 並形れんが", Unit: "個"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同時に登録しても、どの入出庫も失われない
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := s.AddStockMovement(models.StockMovement{Date: "2099-06-01", Code: "SK34", Type: models.StockMovementReceipt, Quantity: 10})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	levels, err := s.GetStockLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 1 || levels[0].Quantity != 10*n {
+		t.Errorf("GetStockLevels() after concurrent adds = %+v, want %d", levels, 10*n)
+	}
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+// photoExtensions は写真として扱う拡張子
+var photoExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// 写真台帳に埋め込む写真の長辺の最大画素数（これを超える写真は縮小する）
+const photoLedgerMaxSide = 1600
+
+// photoInfo は写真台帳に載せる写真の情報を表す
+type photoInfo struct {
+	path string
+	// 工事フォルダーからの相対パス
+	rel         string
+	takenAt     time.Time
+	fromEXIF    bool
+	orientation int
+	caption     string
+}
+
+// CreatePhotoLedger は工事写真台帳（1ページに3枚）のPDFを作成して工事フォルダーに保存する
+// 撮影日時はEXIF（なければファイルの更新日時）、説明は写真と同じ名前の.txtファイルから読み取る
+func (s *KoujiService) CreatePhotoLedger(koujiId string, req models.PhotoLedgerRequest) (*models.FileEntry, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := collectLedgerPhotos(entry.Path, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, fmt.Errorf("写真がありません")
+	}
+
+	settings, err := s.GetDocumentSettings()
+	if err != nil {
+		return nil, err
+	}
+	fontData, err := s.documentFont(settings)
+	if err != nil {
+		return nil, err
+	}
+	title := req.Title
+	if title == "" {
+		title = "工事写真台帳"
+	}
+	pdf, err := utils.NewPDFDocument(title, fontData)
+	if err != nil {
+		return nil, err
+	}
+	location := req.Location
+	if location == "" {
+		location = entry.LocationName
+	}
+	if err := renderPhotoLedger(pdf, &entry, title, location, photos); err != nil {
+		return nil, err
+	}
+
+	filePath, err := nextVersionedPath(entry.Path, joinFileNameParts(title, entry.CompanyName, entry.LocationName), ".pdf")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filePath, pdf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return s.FileSystemService.GetFileEntry(filePath)
+}
+
+// collectLedgerPhotos は指定したファイル、またはフォルダー内の写真を集める
+func collectLedgerPhotos(koujiPath string, req models.PhotoLedgerRequest) ([]photoInfo, error) {
+	var rels []string
+	sortByDate := len(req.Files) == 0
+	if sortByDate {
+		folder, err := koujiRelativePath(koujiPath, req.Folder)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(filepath.Join(koujiPath, folder))
+		if err != nil {
+			return nil, fmt.Errorf("写真のフォルダーを読み込めません: %s", req.Folder)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isPhotoFile(e.Name()) {
+				rels = append(rels, filepath.Join(folder, e.Name()))
+			}
+		}
+	} else {
+		var errs []error
+		for _, file := range req.Files {
+			rel, err := koujiRelativePath(koujiPath, file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !isPhotoFile(rel) {
+				errs = append(errs, fmt.Errorf("JPEG・PNG以外の写真には対応していません: %s", file))
+				continue
+			}
+			rels = append(rels, rel)
+		}
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
+
+	photos := make([]photoInfo, 0, len(rels))
+	for _, rel := range rels {
+		p := photoInfo{path: filepath.Join(koujiPath, rel), rel: filepath.ToSlash(rel), orientation: 1}
+		info, err := os.Stat(p.path)
+		if err != nil || info.IsDir() {
+			return nil, fmt.Errorf("写真がありません: %s", p.rel)
+		}
+		p.takenAt = info.ModTime()
+		if exif, err := readPhotoEXIF(p.path); err == nil {
+			p.orientation = exif.Orientation
+			if !exif.DateTime.IsZero() {
+				p.takenAt, p.fromEXIF = exif.DateTime, true
+			}
+		}
+		p.caption = readPhotoCaption(p.path)
+		photos = append(photos, p)
+	}
+	if sortByDate {
+		sort.SliceStable(photos, func(i, j int) bool {
+			if !photos[i].takenAt.Equal(photos[j].takenAt) {
+				return photos[i].takenAt.Before(photos[j].takenAt)
+			}
+			return photos[i].rel < photos[j].rel
+		})
+	}
+	return photos, nil
+}
+
+// readPhotoEXIF はJPEGの写真のEXIFを読み取る
+func readPhotoEXIF(path string) (*utils.EXIFData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return utils.ReadEXIF(f, time.Local)
+}
+
+// photoMetadata はJPEGの写真のEXIFから撮影情報を読み取る（JPEG以外やEXIFがない場合はnil）
+func photoMetadata(path string) *models.PhotoMetadata {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".jpg" && ext != ".jpeg" {
+		return nil
+	}
+	exif, err := readPhotoEXIF(path)
+	if err != nil {
+		return nil
+	}
+	meta := &models.PhotoMetadata{
+		TakenAt:     models.NewTimestamp(exif.DateTime),
+		CameraMake:  exif.Make,
+		CameraModel: exif.Model,
+		Orientation: exif.Orientation,
+	}
+	if exif.GPS != nil {
+		meta.GPS = &models.PhotoGPS{Latitude: exif.GPS.Latitude, Longitude: exif.GPS.Longitude, Altitude: exif.GPS.Altitude}
+	}
+	return meta
+}
+
+// photoCaptionPath は写真の説明のサイドカー（IMG_0001.JPG.txt または IMG_0001.txt）のパスを返す（ない場合は空）
+func photoCaptionPath(path string) string {
+	for _, sidecar := range []string{path + ".txt", strings.TrimSuffix(path, filepath.Ext(path)) + ".txt"} {
+		if info, err := os.Stat(sidecar); err == nil && !info.IsDir() {
+			return sidecar
+		}
+	}
+	return ""
+}
+
+// readPhotoCaption は写真の説明をサイドカーから読み取る
+func readPhotoCaption(path string) string {
+	sidecar := photoCaptionPath(path)
+	if sidecar == "" {
+		return ""
+	}
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})))
+}
+
+// 撮影日ごとに写真を整理するフォルダー（工事フォルダーからの相対パス）
+const photoSortFolder = "写真"
+
+// SortKoujiPhotos はフォルダー直下の写真を撮影日ごとのフォルダー（写真/YYYY-MM-DD/）に移動する
+// apply=false では移動せずに移動先のプレビューを返す。既存のファイルは上書きせず、名前が重なる場合は _2, _3 を付ける
+func (s *KoujiService) SortKoujiPhotos(koujiId string, req models.PhotoSortRequest, apply bool) (*models.PhotoSortResult, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := koujiRelativePath(entry.Path, req.Folder)
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := os.ReadDir(filepath.Join(entry.Path, folder))
+	if err != nil {
+		return nil, fmt.Errorf("写真のフォルダーを読み込めません: %s", req.Folder)
+	}
+
+	result := &models.PhotoSortResult{
+		Applied: apply,
+		Moves:   []models.PhotoMove{},
+		Skipped: []models.PhotoSortIssue{},
+		Failed:  []models.PhotoSortIssue{},
+	}
+	// 移動先・移動する説明として予約済みのパス（工事フォルダーからの相対パス）
+	reserved := make(map[string]bool)
+	exists := func(rel string) bool {
+		_, err := os.Lstat(filepath.Join(entry.Path, rel))
+		return reserved[rel] || err == nil
+	}
+
+	var moves []models.PhotoMove
+	for _, e := range dirEntries {
+		if e.IsDir() || !isPhotoFile(e.Name()) {
+			continue
+		}
+		rel := filepath.Join(folder, e.Name())
+		path := filepath.Join(entry.Path, rel)
+		move := models.PhotoMove{From: filepath.ToSlash(rel), DateSource: "exif"}
+
+		var takenAt time.Time
+		if exif, err := readPhotoEXIF(path); err == nil {
+			takenAt = exif.DateTime
+		}
+		if takenAt.IsZero() {
+			info, err := e.Info()
+			if !req.UseModTime || err != nil {
+				result.Skipped = append(result.Skipped, models.PhotoSortIssue{Path: move.From, Reason: "撮影日時がありません"})
+				continue
+			}
+			takenAt, move.DateSource = info.ModTime(), "mtime"
+		}
+		move.TakenAt = models.NewTimestamp(takenAt)
+
+		dir := filepath.Join(photoSortFolder, takenAt.Format("2006-01-02"))
+		ext := filepath.Ext(e.Name())
+		base := strings.TrimSuffix(e.Name(), ext)
+		if req.Rename {
+			base = takenAt.Format("20060102_150405")
+		}
+		caption := photoCaptionPath(path)
+		var captionRel string
+		if caption != "" {
+			captionRel, _ = filepath.Rel(entry.Path, caption)
+			if reserved[captionRel] {
+				// 同じ名前の別の写真（IMG_0001.JPG と IMG_0001.PNG など）と共有する説明は最初の写真と移動する
+				captionRel = ""
+			}
+		}
+
+		var target, captionTarget string
+		for n := 1; ; n++ {
+			name := base
+			if n > 1 {
+				name = fmt.Sprintf("%s_%d", base, n)
+			}
+			target = filepath.Join(dir, name+ext)
+			if target == rel {
+				break
+			}
+			if captionRel != "" {
+				if caption == path+".txt" {
+					captionTarget = target + ".txt"
+				} else {
+					captionTarget = filepath.Join(dir, name+".txt")
+				}
+			}
+			if !exists(target) && (captionTarget == "" || !exists(captionTarget)) {
+				break
+			}
+		}
+		if target == rel {
+			result.Skipped = append(result.Skipped, models.PhotoSortIssue{Path: move.From, Reason: "整理済みです"})
+			continue
+		}
+		reserved[target] = true
+		move.To = filepath.ToSlash(target)
+		if captionRel != "" {
+			reserved[captionRel] = true
+			reserved[captionTarget] = true
+			move.CaptionFrom, move.CaptionTo = filepath.ToSlash(captionRel), filepath.ToSlash(captionTarget)
+		}
+		moves = append(moves, move)
+	}
+
+	if !apply {
+		result.Moves = append(result.Moves, moves...)
+		return result, nil
+	}
+	for _, move := range moves {
+		if err := movePhoto(entry.Path, move); err != nil {
+			result.Failed = append(result.Failed, models.PhotoSortIssue{Path: move.From, Reason: err.Error()})
+			continue
+		}
+		result.Moves = append(result.Moves, move)
+	}
+	return result, nil
+}
+
+// movePhoto は写真と説明のファイルを移動する（移動先が既にある場合は上書きしない）
+func movePhoto(koujiPath string, move models.PhotoMove) error {
+	pairs := [][2]string{{move.From, move.To}}
+	if move.CaptionFrom != "" {
+		pairs = append(pairs, [2]string{move.CaptionFrom, move.CaptionTo})
+	}
+	for _, pair := range pairs {
+		from := filepath.Join(koujiPath, filepath.FromSlash(pair[0]))
+		to := filepath.Join(koujiPath, filepath.FromSlash(pair[1]))
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(to); err == nil {
+			return fmt.Errorf("移動先にファイルがあります: %s", pair[1])
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadLedgerImage は写真を読み込み、大きな写真は縮小してPDFに追加する
+func loadLedgerImage(pdf *utils.PDFDocument, path string) (*utils.PDFImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("写真を読み込めません: %s: %w", filepath.Base(path), err)
+	}
+	if cfg.Width <= photoLedgerMaxSide && cfg.Height <= photoLedgerMaxSide {
+		return pdf.AddImage(data)
+	}
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("写真を読み込めません: %s: %w", filepath.Base(path), err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, utils.ResizeToFit(img, photoLedgerMaxSide), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return pdf.AddImage(buf.Bytes())
+}
+
+// 写真台帳のレイアウト（ポイント）
+const (
+	ledgerMarginX   = 40.0
+	ledgerTop       = 72.0
+	ledgerRowHeight = 245.0
+	ledgerPhotoW    = 300.0
+	ledgerPhotoH    = 225.0
+	ledgerPerPage   = 3
+)
+
+// renderPhotoLedger は写真を1ページに3枚、右側に撮影日・場所・説明を並べて描画する
+func renderPhotoLedger(pdf *utils.PDFDocument, entry *models.KoujiEntry, title, location string, photos []photoInfo) error {
+	pageCount := (len(photos) + ledgerPerPage - 1) / ledgerPerPage
+	right := utils.PDFPageWidth - ledgerMarginX
+	captionX := ledgerMarginX + ledgerPhotoW + 10
+	captionW := right - captionX
+	koujiName := strings.TrimSpace(entry.CompanyName + " " + entry.LocationName)
+
+	var page *utils.PDFPage
+	for i, photo := range photos {
+		row := i % ledgerPerPage
+		if row == 0 {
+			page = pdf.AddPage()
+			page.Text(ledgerMarginX, 50, 16, title)
+			page.TextRight(right, 50, 9, koujiName)
+			page.Line(ledgerMarginX, 57, right, 57, 0.8)
+			page.TextCenter(utils.PDFPageWidth/2, 825, 8, fmt.Sprintf("%d / %d", i/ledgerPerPage+1, pageCount))
+		}
+		y := ledgerTop + float64(row)*ledgerRowHeight
+
+		img, err := loadLedgerImage(pdf, photo.path)
+		if err != nil {
+			return err
+		}
+		page.Rect(ledgerMarginX, y, ledgerPhotoW, ledgerPhotoH, 0.5)
+		page.ImageFitOriented(img, ledgerMarginX+2, y+2, ledgerPhotoW-4, ledgerPhotoH-4, photo.orientation)
+
+		taken := photo.takenAt.Format("2006年1月2日 15:04")
+		if !photo.fromEXIF {
+			taken += "（更新日時）"
+		}
+		page.Rect(captionX, y, captionW, ledgerPhotoH, 0.5)
+		cy := y + 16
+		for _, field := range []struct{ label, value string }{
+			{"No.", fmt.Sprint(i + 1)},
+			{"撮影日", taken},
+			{"工事名", koujiName},
+			{"撮影場所", location},
+		} {
+			page.Text(captionX+6, cy, 8, field.label)
+			cy += 12
+			cy += float64(page.TextBox(captionX+12, cy, captionW-18, 9.5, 12, field.value)) * 12
+			cy += 4
+		}
+		if photo.caption != "" {
+			page.Text(captionX+6, cy, 8, "説明")
+			cy += 12
+			lines := pdf.WrapText(photo.caption, captionW-18, 9.5)
+			maxLines := int((y + ledgerPhotoH - 18 - cy) / 12)
+			if len(lines) > maxLines {
+				lines = lines[:max(maxLines, 0)]
+			}
+			for j, line := range lines {
+				page.Text(captionX+12, cy+float64(j)*12, 9.5, line)
+			}
+		}
+		page.Text(captionX+6, y+ledgerPhotoH-6, 7, photo.rel)
+	}
+	return nil
+}
+
+// koujiRelativePath は工事フォルダーからの相対パスを検証し、正規化した相対パスを返す
+func koujiRelativePath(koujiPath, p string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(p))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("工事フォルダーからの相対パスで指定してください: %s", p)
+	}
+	return rel, nil
+}
+
+func isPhotoFile(name string) bool {
+	return photoExtensions[strings.ToLower(filepath.Ext(name))]
+}
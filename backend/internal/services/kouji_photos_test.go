@@ -0,0 +1,309 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTag はテスト用のEXIFの1項目を表す
+type testTag struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+func asciiTag(tag uint16, s string) testTag {
+	return testTag{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortTag(tag uint16, v uint16) testTag {
+	return testTag{tag, 3, 1, binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func rationalTag(tag uint16, values ...[2]uint32) testTag {
+	var b []byte
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint32(b, v[0])
+		b = binary.LittleEndian.AppendUint32(b, v[1])
+	}
+	return testTag{tag, 5, uint32(len(values)), b}
+}
+
+// testTIFF はIFD0と（gpsがnilでなければ）GPS IFDを持つEXIFのTIFFデータを作る
+func testTIFF(ifd0, gps []testTag) []byte {
+	le := binary.LittleEndian
+	ifdSize := func(n int) int { return 2 + n*12 + 4 }
+	if gps != nil {
+		ifd0 = append(ifd0, testTag{0x8825, 4, 1, nil})
+	}
+	gpsOffset := 8 + ifdSize(len(ifd0))
+	dataOffset := gpsOffset + ifdSize(len(gps))
+	if gps != nil {
+		ifd0[len(ifd0)-1].value = le.AppendUint32(nil, uint32(gpsOffset))
+	}
+
+	var data []byte
+	writeIFD := func(out []byte, tags []testTag) []byte {
+		out = le.AppendUint16(out, uint16(len(tags)))
+		for _, tag := range tags {
+			out = le.AppendUint16(out, tag.tag)
+			out = le.AppendUint16(out, tag.typ)
+			out = le.AppendUint32(out, tag.count)
+			if len(tag.value) <= 4 {
+				out = append(out, tag.value...)
+				out = append(out, make([]byte, 4-len(tag.value))...)
+			} else {
+				out = le.AppendUint32(out, uint32(dataOffset+len(data)))
+				data = append(data, tag.value...)
+			}
+		}
+		return le.AppendUint32(out, 0)
+	}
+	tiff := le.AppendUint32([]byte("II*\x00"), 8)
+	tiff = writeIFD(tiff, ifd0)
+	tiff = writeIFD(tiff, gps)
+	return append(tiff, data...)
+}
+
+// exifJPEG は任意のEXIF（tiffがnilならEXIFなし）を付けたJPEGを作る
+func exifJPEG(t *testing.T, tiff []byte) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for x := 0; x < 40; x++ {
+		img.Set(x, x*30/40, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return withEXIF(buf.Bytes(), tiff)
+}
+
+// dateJPEG は撮影日時と向きのEXIFを付けたJPEGを作る
+func dateJPEG(t *testing.T, dateTime string, orientation uint16) []byte {
+	t.Helper()
+	return exifJPEG(t, testTIFF([]testTag{shortTag(0x0112, orientation), asciiTag(0x0132, dateTime)}, nil))
+}
+
+// withEXIF はJPEGのSOIの直後にEXIFのAPP1セグメントを挿入する
+func withEXIF(data, tiff []byte) []byte {
+	if tiff == nil {
+		return data
+	}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestCreatePhotoLedger(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	entry := s.GetKoujiEntries()[0]
+	dir := filepath.Join(entry.Path, "写真")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"IMG_0002.JPG": dateJPEG(t, "2099:06:18 09:00:00", 6),
+		"IMG_0001.JPG": dateJPEG(t, "2099:06:19 10:30:00", 1),
+		"IMG_0003.JPG": exifJPEG(t, nil),
+		"IMG_0001.txt": []byte("\xEF\xBB\xBF炉内 解体後\n"),
+		"memo.pdf":     []byte("%PDF-"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Date(2099, 6, 20, 8, 0, 0, 0, time.Local)
+	if err := os.Chtimes(filepath.Join(dir, "IMG_0003.JPG"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	photos, err := collectLedgerPhotos(entry.Path, models.PhotoLedgerRequest{Folder: "写真"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range photos {
+		names = append(names, filepath.Base(p.rel))
+	}
+	if got := strings.Join(names, ","); got != "IMG_0002.JPG,IMG_0001.JPG,IMG_0003.JPG" {
+		t.Errorf("photos = %s, want sorted by capture time", got)
+	}
+	if photos[0].orientation != 6 || !photos[0].fromEXIF || photos[2].fromEXIF || !photos[2].takenAt.Equal(mtime) {
+		t.Errorf("photo dates or orientation = %+v", photos)
+	}
+	if photos[1].caption != "炉内 解体後" {
+		t.Errorf("caption = %q", photos[1].caption)
+	}
+
+	ledger, err := s.CreatePhotoLedger(entry.Id, models.PhotoLedgerRequest{Folder: "写真"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ledger.Name != "工事写真台帳_豊田築炉_名和工場_v1.pdf" {
+		t.Errorf("CreatePhotoLedger() name = %s", ledger.Name)
+	}
+	data, err := os.ReadFile(ledger.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || bytes.Count(data, []byte("/DCTDecode")) != 3 {
+		t.Errorf("%s is not a PDF with 3 photos", ledger.Name)
+	}
+
+	for _, files := range [][]string{{"../写真/IMG_0001.JPG"}, {"写真/memo.pdf"}, {"写真/IMG_9999.JPG"}} {
+		if _, err := s.CreatePhotoLedger(entry.Id, models.PhotoLedgerRequest{Files: files}); err == nil {
+			t.Errorf("photo ledger with %v was created", files)
+		}
+	}
+}
+
+func TestLoadLedgerImageTooLarge(t *testing.T) {
+	pdf, err := utils.NewPDFDocument("工事写真台帳", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 巨大な大きさを宣言した画像は展開せずにエラーにする
+	path := filepath.Join(t.TempDir(), "huge.png")
+	if err := os.WriteFile(path, hugePNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadLedgerImage(pdf, path); !errors.Is(err, utils.ErrImageTooLarge) {
+		t.Errorf("loadLedgerImage(huge.png) error = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestFileEntryPhotoMetadata(t *testing.T) {
+	dir := t.TempDir()
+	tiff := testTIFF(
+		[]testTag{asciiTag(0x010F, "Apple"), asciiTag(0x0110, "iPhone 15"), asciiTag(0x0132, "2099:06:18 09:00:00")},
+		[]testTag{
+			asciiTag(0x0001, "N"), rationalTag(0x0002, [2]uint32{35, 1}, [2]uint32{2, 1}, [2]uint32{3690, 100}),
+			asciiTag(0x0003, "E"), rationalTag(0x0004, [2]uint32{136, 1}, [2]uint32{52, 1}, [2]uint32{4692, 100}),
+			rationalTag(0x0006, [2]uint32{125, 10}),
+		},
+	)
+	if err := os.WriteFile(filepath.Join(dir, "IMG_0001.JPG"), exifJPEG(t, tiff), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memo.txt"), []byte("memo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsService, err := NewFileSystemService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := fsService.GetFileEntries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 一覧の取得ではEXIFを読み取らない
+	for _, entry := range list.FileEntries {
+		if entry.Photo != nil {
+			t.Fatalf("GetFileEntries() read the EXIF of %s", entry.Name)
+		}
+	}
+	fsService.AddPhotoMetadata(list.FileEntries)
+	for _, entry := range list.FileEntries {
+		if entry.Name == "memo.txt" {
+			if entry.Photo != nil {
+				t.Errorf("memo.txt has photo metadata %+v", entry.Photo)
+			}
+			continue
+		}
+		photo := entry.Photo
+		if photo == nil || photo.CameraModel != "iPhone 15" || photo.CameraMake != "Apple" ||
+			!photo.TakenAt.Equal(time.Date(2099, 6, 18, 9, 0, 0, 0, time.Local)) {
+			t.Fatalf("photo metadata = %+v", photo)
+		}
+		gps := photo.GPS
+		if gps == nil || math.Abs(gps.Latitude-35.0436) > 1e-4 || math.Abs(gps.Longitude-136.8797) > 1e-4 ||
+			gps.Altitude == nil || *gps.Altitude != 12.5 {
+			t.Errorf("photo GPS = %+v", gps)
+		}
+	}
+}
+
+func TestSortKoujiPhotos(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	entry := s.GetKoujiEntries()[0]
+	files := map[string][]byte{
+		"IMG_0001.JPG":     dateJPEG(t, "2099:06:18 09:00:00", 1),
+		"IMG_0001.JPG.txt": []byte("炉内 解体前"),
+		"IMG_0002.JPG":     dateJPEG(t, "2099:06:18 09:00:00", 1),
+		"IMG_0003.JPG":     dateJPEG(t, "2099:06:19 13:15:00", 1),
+		"IMG_0004.JPG":     exifJPEG(t, nil),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(entry.Path, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := models.PhotoSortRequest{Rename: true}
+	preview, err := s.SortKoujiPhotos(entry.Id, req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"IMG_0001.JPG": "写真/2099-06-18/20990618_090000.JPG",
+		"IMG_0002.JPG": "写真/2099-06-18/20990618_090000_2.JPG",
+		"IMG_0003.JPG": "写真/2099-06-19/20990619_131500.JPG",
+	}
+	if len(preview.Moves) != len(want) || len(preview.Skipped) != 1 || preview.Skipped[0].Path != "IMG_0004.JPG" {
+		t.Fatalf("preview = %+v", preview)
+	}
+	for _, move := range preview.Moves {
+		if move.To != want[move.From] {
+			t.Errorf("move %s to %s, want %s", move.From, move.To, want[move.From])
+		}
+	}
+	if preview.Moves[0].CaptionTo != "写真/2099-06-18/20990618_090000.JPG.txt" {
+		t.Errorf("caption moves to %s", preview.Moves[0].CaptionTo)
+	}
+	if _, err := os.Stat(filepath.Join(entry.Path, "IMG_0001.JPG")); err != nil {
+		t.Error("preview moved the photo")
+	}
+
+	req.UseModTime = true
+	result, err := s.SortKoujiPhotos(entry.Id, req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Applied || len(result.Moves) != 4 || len(result.Failed) != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	for _, move := range result.Moves {
+		if _, err := os.Stat(filepath.Join(entry.Path, filepath.FromSlash(move.To))); err != nil {
+			t.Errorf("%s was not moved to %s", move.From, move.To)
+		}
+	}
+	if readPhotoCaption(filepath.Join(entry.Path, "写真", "2099-06-18", "20990618_090000.JPG")) != "炉内 解体前" {
+		t.Error("caption was not moved with the photo")
+	}
+
+	// 整理済みのフォルダーは移動しない
+	again, err := s.SortKoujiPhotos(entry.Id, models.PhotoSortRequest{Folder: "写真/2099-06-18", Rename: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Moves) != 0 || len(again.Skipped) != 2 {
+		t.Errorf("sorting a sorted folder = %+v", again)
+	}
+}
@@ -0,0 +1,565 @@
+package services
+
+import (
+	"fmt"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// KoujiQuery は工事検索クエリの構文木を保持する
+//
+// 対応する構文:
+//
+//	名和                      全文検索（会社名・現場名・説明・タグ・フォルダー名）
+//	company:豊田築炉           フィールド指定
+//	year:2024..2025           範囲指定（片側省略可: 2024.. / ..2025）
+//	fy:2024                   年度（設定された開始月に基づく）
+//	-tag:見積のみ              否定
+//	(status:予定 OR status:進行中)  ORグループ
+//	location:"名和 工場"       空白を含む値は引用符で囲む
+//	custom.炉の種類:溶解炉      カスタムフィールド（範囲指定可: custom.受注金額:1000000..）
+type KoujiQuery struct {
+	Raw  string
+	root queryNode
+}
+
+// QueryParseError はクエリの構文エラーと発生位置（文字単位、0始まり）を表す
+type QueryParseError struct {
+	Pos     int    `json:"position"`
+	Message string `json:"message"`
+}
+
+func (e *QueryParseError) Error() string {
+	return fmt.Sprintf("位置 %d: %s", e.Pos, e.Message)
+}
+
+// queryFields はフィールド名（別名を含む）から正規名への対応表
+var queryFields = map[string]string{
+	"company":  "company",
+	"会社":       "company",
+	"location": "location",
+	"現場":       "location",
+	"status":   "status",
+	"状態":       "status",
+	"tag":      "tag",
+	"タグ":       "tag",
+	"year":     "year",
+	"年":        "year",
+	"fy":       "fiscal_year",
+	"年度":       "fiscal_year",
+	"start":    "start",
+	"開始":       "start",
+	"end":      "end",
+	"終了":       "end",
+	"id":       "id",
+	"name":     "name",
+	"desc":     "desc",
+	"説明":       "desc",
+}
+
+// ParseKoujiQuery はクエリ文字列を解析してKoujiQueryを返す
+// 空文字列の場合はすべての工事に一致するクエリを返す
+func ParseKoujiQuery(s string) (*KoujiQuery, error) {
+	tokens, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, end: len([]rune(s))}
+	if len(tokens) == 0 {
+		return &KoujiQuery{Raw: s, root: andNode{}}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, &QueryParseError{Pos: tok.pos, Message: fmt.Sprintf("予期しない %q があります", tok.text)}
+	}
+	return &KoujiQuery{Raw: s, root: root}, nil
+}
+
+// Match は工事がクエリに一致するかを判定する
+func (q *KoujiQuery) Match(entry *models.KoujiEntry) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+	return q.root.match(entry)
+}
+
+// Filter はクエリに一致する工事のみを返す
+func (q *KoujiQuery) Filter(entries []models.KoujiEntry) []models.KoujiEntry {
+	filtered := make([]models.KoujiEntry, 0, len(entries))
+	for i := range entries {
+		if q.Match(&entries[i]) {
+			filtered = append(filtered, entries[i])
+		}
+	}
+	return filtered
+}
+
+// ---- 字句解析 ----
+
+type queryTokenKind int
+
+const (
+	tokenWord queryTokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenOr
+	tokenMinus
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string // 引用符を除去した値
+	pos  int
+	// field はfield:value形式のときのフィールド名（未加工）
+	field    string
+	fieldPos int
+	valuePos int
+	quoted   bool
+}
+
+func tokenizeQuery(s string) ([]queryToken, error) {
+	runes := []rune(s)
+	var tokens []queryToken
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == '　':
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '-' && (i+1 < len(runes) && !unicode.IsSpace(runes[i+1])):
+			tokens = append(tokens, queryToken{kind: tokenMinus, text: "-", pos: i})
+			i++
+		default:
+			tok, next, err := readWord(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			if tok.field == "" && !tok.quoted && tok.text == "OR" {
+				tok.kind = tokenOr
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// readWord は空白・括弧までを1語として読み取る。field:value と引用符を解釈する
+func readWord(runes []rune, start int) (queryToken, int, error) {
+	tok := queryToken{kind: tokenWord, pos: start, valuePos: start}
+	var b strings.Builder
+	i := start
+	for i < len(runes) {
+		r := runes[i]
+		if unicode.IsSpace(r) || r == '　' || r == '(' || r == ')' {
+			break
+		}
+		if r == '"' {
+			closing := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == '"' {
+					closing = j
+					break
+				}
+			}
+			if closing < 0 {
+				return tok, 0, &QueryParseError{Pos: i, Message: "引用符が閉じられていません"}
+			}
+			b.WriteString(string(runes[i+1 : closing]))
+			tok.quoted = true
+			i = closing + 1
+			continue
+		}
+		if (r == ':' || r == '：') && tok.field == "" && !tok.quoted {
+			tok.field = b.String()
+			tok.fieldPos = start
+			tok.valuePos = i + 1
+			b.Reset()
+			i++
+			continue
+		}
+		b.WriteRune(r)
+		i++
+	}
+	tok.text = b.String()
+	return tok, i, nil
+}
+
+// ---- 構文解析 ----
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	end    int
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+// parseOr: and ( OR and )*
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []queryNode{left}
+	for {
+		tok := p.peek()
+		if tok == nil || tok.kind != tokenOr {
+			break
+		}
+		p.pos++
+		if next := p.peek(); next == nil || next.kind == tokenRParen || next.kind == tokenOr {
+			return nil, &QueryParseError{Pos: p.errPos(next), Message: "OR の後に条件がありません"}
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return orNode(nodes), nil
+}
+
+// parseAnd: unary+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var nodes andNode
+	for {
+		tok := p.peek()
+		if tok == nil || tok.kind == tokenRParen || tok.kind == tokenOr {
+			break
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		tok := p.peek()
+		if tok != nil && tok.kind == tokenOr {
+			return nil, &QueryParseError{Pos: tok.pos, Message: "OR の前に条件がありません"}
+		}
+		return nil, &QueryParseError{Pos: p.errPos(tok), Message: "条件がありません"}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+// parseUnary: '-' unary | primary
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok := p.peek()
+	if tok.kind == tokenMinus {
+		p.pos++
+		next := p.peek()
+		if next == nil || next.kind == tokenRParen || next.kind == tokenOr {
+			return nil, &QueryParseError{Pos: tok.pos, Message: "'-' の後に条件がありません"}
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary: '(' or ')' | term
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tok := p.peek()
+	if tok.kind == tokenLParen {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.peek()
+		if closing == nil || closing.kind != tokenRParen {
+			return nil, &QueryParseError{Pos: tok.pos, Message: "括弧が閉じられていません"}
+		}
+		p.pos++
+		return inner, nil
+	}
+	p.pos++
+	return newTermNode(tok)
+}
+
+func (p *queryParser) errPos(tok *queryToken) int {
+	if tok == nil {
+		return p.end
+	}
+	return tok.pos
+}
+
+// ---- 評価 ----
+
+type queryNode interface {
+	match(entry *models.KoujiEntry) bool
+}
+
+type andNode []queryNode
+
+func (n andNode) match(e *models.KoujiEntry) bool {
+	for _, child := range n {
+		if !child.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []queryNode
+
+func (n orNode) match(e *models.KoujiEntry) bool {
+	for _, child := range n {
+		if child.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	inner queryNode
+}
+
+func (n notNode) match(e *models.KoujiEntry) bool {
+	return !n.inner.match(e)
+}
+
+// textNode は全文検索語
+type textNode struct {
+	text string
+}
+
+func (n textNode) match(e *models.KoujiEntry) bool {
+	if containsFold(e.CompanyName, n.text) ||
+		containsFold(e.LocationName, n.text) ||
+		containsFold(e.Description, n.text) ||
+		containsFold(e.Name, n.text) ||
+		containsFold(e.Id, n.text) {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if containsFold(tag, n.text) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNode は文字列フィールドの一致判定
+type fieldNode struct {
+	field string
+	value string
+}
+
+func (n fieldNode) match(e *models.KoujiEntry) bool {
+	switch n.field {
+	case "company":
+		return containsFold(e.CompanyName, n.value)
+	case "location":
+		return containsFold(e.LocationName, n.value)
+	case "status":
+		return e.Status == n.value
+	case "tag":
+		return hasTag(e.Tags, n.value)
+	case "id":
+		return strings.EqualFold(e.Id, n.value)
+	case "name":
+		return containsFold(e.Name, n.value)
+	case "desc":
+		return containsFold(e.Description, n.value)
+	}
+	return false
+}
+
+// yearRangeNode は開始日の年または年度による範囲判定（両端を含む）
+type yearRangeNode struct {
+	min, max int // 0は制限なし
+	fiscal   bool
+}
+
+func (n yearRangeNode) match(e *models.KoujiEntry) bool {
+	if e.StartDate.Time.IsZero() {
+		return false
+	}
+	year := e.StartDate.Time.Year()
+	if n.fiscal {
+		year = e.FiscalYear
+	}
+	return (n.min == 0 || year >= n.min) && (n.max == 0 || year <= n.max)
+}
+
+// dateRangeNode は開始日・終了日による範囲判定（両端の日を含む）
+type dateRangeNode struct {
+	field    string
+	from, to time.Time // ゼロ値は制限なし。toは翌日0時（排他的）
+}
+
+func (n dateRangeNode) match(e *models.KoujiEntry) bool {
+	t := e.StartDate.Time
+	if n.field == "end" {
+		t = e.EndDate.Time
+	}
+	if t.IsZero() {
+		return false
+	}
+	return (n.from.IsZero() || !t.Before(n.from)) && (n.to.IsZero() || t.Before(n.to))
+}
+
+// customFieldNode はカスタムフィールドの一致・範囲判定
+type customFieldNode struct {
+	key      string
+	value    string
+	isRange  bool
+	min, max string // 空は制限なし
+}
+
+func (n customFieldNode) match(e *models.KoujiEntry) bool {
+	value, ok := e.CustomFields[n.key]
+	if !ok || value == nil {
+		return false
+	}
+	if !n.isRange {
+		return containsFold(FormatCustomFieldValue(value), n.value)
+	}
+	return (n.min == "" || compareCustomFieldValue(value, n.min) >= 0) &&
+		(n.max == "" || compareCustomFieldValue(value, n.max) <= 0)
+}
+
+func newTermNode(tok *queryToken) (queryNode, error) {
+	if tok.field == "" {
+		if tok.text == "" {
+			return nil, &QueryParseError{Pos: tok.pos, Message: "空の検索語です"}
+		}
+		return textNode{text: tok.text}, nil
+	}
+
+	if key, ok := strings.CutPrefix(tok.field, CustomFieldPrefix); ok && key != "" {
+		if tok.text == "" {
+			return nil, &QueryParseError{Pos: tok.valuePos, Message: fmt.Sprintf("%s の値がありません", tok.field)}
+		}
+		node := customFieldNode{key: key, value: tok.text}
+		if !tok.quoted && strings.Contains(tok.text, "..") {
+			lo, hi, err := splitRange(tok)
+			if err != nil {
+				return nil, err
+			}
+			node.isRange, node.min, node.max = true, lo, hi
+		}
+		return node, nil
+	}
+
+	field, ok := queryFields[strings.ToLower(tok.field)]
+	if !ok {
+		return nil, &QueryParseError{Pos: tok.fieldPos, Message: fmt.Sprintf("不明なフィールド %q です", tok.field)}
+	}
+	if tok.text == "" {
+		return nil, &QueryParseError{Pos: tok.valuePos, Message: fmt.Sprintf("%s の値がありません", tok.field)}
+	}
+
+	switch field {
+	case "year", "fiscal_year":
+		lo, hi, err := splitRange(tok)
+		if err != nil {
+			return nil, err
+		}
+		node := yearRangeNode{fiscal: field == "fiscal_year"}
+		if node.min, err = parseYear(lo, tok.valuePos); err != nil {
+			return nil, err
+		}
+		if node.max, err = parseYear(hi, tok.valuePos); err != nil {
+			return nil, err
+		}
+		if node.min != 0 && node.max != 0 && node.min > node.max {
+			return nil, &QueryParseError{Pos: tok.valuePos, Message: "範囲の開始が終了より後です"}
+		}
+		return node, nil
+	case "start", "end":
+		lo, hi, err := splitRange(tok)
+		if err != nil {
+			return nil, err
+		}
+		node := dateRangeNode{field: field}
+		if lo != "" {
+			if node.from, err = parseQueryDate(lo, tok.valuePos); err != nil {
+				return nil, err
+			}
+		}
+		if hi != "" {
+			if node.to, err = parseQueryDate(hi, tok.valuePos); err != nil {
+				return nil, err
+			}
+			// 終了日はその日を含める
+			node.to = node.to.AddDate(0, 0, 1)
+		}
+		if !node.from.IsZero() && !node.to.IsZero() && !node.from.Before(node.to) {
+			return nil, &QueryParseError{Pos: tok.valuePos, Message: "範囲の開始が終了より後です"}
+		}
+		return node, nil
+	}
+
+	if strings.Contains(tok.text, "..") && !tok.quoted {
+		return nil, &QueryParseError{Pos: tok.valuePos, Message: fmt.Sprintf("%s は範囲指定に対応していません", tok.field)}
+	}
+	return fieldNode{field: field, value: tok.text}, nil
+}
+
+// splitRange は "a..b" を分割する。範囲でない場合は lo=hi=値 を返す
+func splitRange(tok *queryToken) (string, string, error) {
+	lo, hi, found := strings.Cut(tok.text, "..")
+	if !found {
+		return tok.text, tok.text, nil
+	}
+	if lo == "" && hi == "" {
+		return "", "", &QueryParseError{Pos: tok.valuePos, Message: "範囲の両端が空です"}
+	}
+	return lo, hi, nil
+}
+
+func parseYear(s string, pos int) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil || year < 1000 || year > 9999 {
+		return 0, &QueryParseError{Pos: pos, Message: fmt.Sprintf("年 %q が不正です", s)}
+	}
+	return year, nil
+}
+
+func parseQueryDate(s string, pos int) (time.Time, error) {
+	t, err := utils.ParseTime(s)
+	if err != nil {
+		return time.Time{}, &QueryParseError{Pos: pos, Message: fmt.Sprintf("日付 %q が不正です", s)}
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local), nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
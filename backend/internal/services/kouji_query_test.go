@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"penguin-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func testKoujiEntries() []models.KoujiEntry {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	return []models.KoujiEntry{
+		{Id: "AAAAA", CompanyName: "豊田築炉", LocationName: "名和工場", Status: "完了",
+			StartDate: date(2024, 6, 18), EndDate: date(2024, 7, 1), FiscalYear: 2024, Tags: []string{"工事", "2024"}},
+		{Id: "BBBBB", CompanyName: "豊田築炉", LocationName: "刈谷工場", Status: "完了",
+			StartDate: date(2025, 1, 10), EndDate: date(2025, 2, 1), FiscalYear: 2024, Tags: []string{"工事", "見積のみ"}},
+		{Id: "CCCCC", CompanyName: "愛知製鋼", LocationName: "知多 第二工場", Status: "予定",
+			StartDate: date(2026, 4, 1), EndDate: date(2026, 5, 1), FiscalYear: 2026, Tags: []string{"工事"},
+			CustomFields: map[string]any{"受注金額": int64(1500000), "炉の種類": "溶解炉"}},
+	}
+}
+
+func TestParseKoujiQueryMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"AAAAA", "BBBBB", "CCCCC"}},
+		{"名和", []string{"AAAAA"}},
+		{"company:豊田築炉", []string{"AAAAA", "BBBBB"}},
+		{"company:豊田築炉 year:2024..2025 status:完了 -tag:見積のみ 名和", []string{"AAAAA"}},
+		{"year:2025..", []string{"BBBBB", "CCCCC"}},
+		{"year:..2024", []string{"AAAAA"}},
+		{"fy:2024", []string{"AAAAA", "BBBBB"}},
+		{"年度:2025..", []string{"CCCCC"}},
+		{"-tag:見積のみ", []string{"AAAAA", "CCCCC"}},
+		{"status:予定 OR location:刈谷", []string{"BBBBB", "CCCCC"}},
+		{"company:豊田築炉 (名和 OR 刈谷)", []string{"AAAAA", "BBBBB"}},
+		{"-(status:完了)", []string{"CCCCC"}},
+		{`location:"知多 第二"`, []string{"CCCCC"}},
+		{"start:2024-06-18", []string{"AAAAA"}},
+		{"start:2024-06-19..2025-01-10", []string{"BBBBB"}},
+		{"会社：愛知製鋼", []string{"CCCCC"}},
+		{"custom.炉の種類:溶解炉", []string{"CCCCC"}},
+		{"custom.受注金額:1000000..", []string{"CCCCC"}},
+		{"custom.受注金額:..1000000", nil},
+	}
+
+	for _, tt := range tests {
+		q, err := ParseKoujiQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseKoujiQuery(%q) returned error: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, e := range q.Filter(testKoujiEntries()) {
+			got = append(got, e.Id)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("query %q matched %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("query %q matched %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseKoujiQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"foo:bar", 0},
+		{"名和 (company:豊田築炉", 3},
+		{"名和 OR", 5},
+		{"OR 名和", 0},
+		{`location:"名和`, 9},
+		{"year:abc", 5},
+		{"year:2025..2024", 5},
+		{"名和 )", 3},
+		{"company:", 8},
+		{"status:予定..完了", 7},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseKoujiQuery(tt.query)
+		var parseErr *QueryParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("ParseKoujiQuery(%q) error = %v, want QueryParseError", tt.query, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos {
+			t.Errorf("ParseKoujiQuery(%q) position = %d, want %d (%s)", tt.query, parseErr.Pos, tt.pos, parseErr.Message)
+		}
+	}
+}
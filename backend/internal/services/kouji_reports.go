@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DailyReportDir は工事フォルダー内で日報を保存するフォルダー名
+const DailyReportDir = ".inside.reports"
+
+// 日報のエラー
+var (
+	ErrDailyReportNotFound = errors.New("日報がありません")
+	ErrDailyReportExists   = errors.New("日報が既にあります")
+)
+
+// GetDailyReports は工事の日報を日付の昇順で返す
+// from, toが空でない場合はその期間（両端を含む、YYYY-MM-DD）の日報のみを返す
+// ファイル名が日付（YYYY-MM-DD.yaml）でないファイルは日報とみなさない
+func (s *KoujiService) GetDailyReports(koujiId, from, to string) ([]models.DailyReport, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(entry.Path, DailyReportDir)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []models.DailyReport{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]models.DailyReport, 0, len(files))
+	for _, file := range files {
+		date, ok := strings.CutSuffix(file.Name(), ".yaml")
+		if !ok || file.IsDir() || (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		report, err := loadDailyReport(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Date < reports[j].Date })
+	return reports, nil
+}
+
+// GetDailyReport は工事の指定した日の日報を返す
+func (s *KoujiService) GetDailyReport(koujiId, date string) (models.DailyReport, error) {
+	path, err := s.dailyReportPath(koujiId, date)
+	if err != nil {
+		return models.DailyReport{}, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return models.DailyReport{}, fmt.Errorf("%w: %s %s", ErrDailyReportNotFound, koujiId, date)
+	}
+	return loadDailyReport(path)
+}
+
+// CreateDailyReport は日報を作成する（同じ日の日報が既にある場合はエラー）
+func (s *KoujiService) CreateDailyReport(koujiId string, report models.DailyReport) (models.DailyReport, error) {
+	return s.saveDailyReport(koujiId, report, false)
+}
+
+// UpdateDailyReport は指定した日の日報を置き換える（日報がない場合はエラー）
+func (s *KoujiService) UpdateDailyReport(koujiId, date string, report models.DailyReport) (models.DailyReport, error) {
+	if report.Date == "" {
+		report.Date = date
+	}
+	if report.Date != date {
+		return models.DailyReport{}, fmt.Errorf("日報の日付は変更できません: %s", report.Date)
+	}
+	return s.saveDailyReport(koujiId, report, true)
+}
+
+// GetManHoursSummary は工事の日報から延べ作業時間を集計する
+func (s *KoujiService) GetManHoursSummary(koujiId string) (*models.ManHoursSummary, error) {
+	reports, err := s.GetDailyReports(koujiId, "", "")
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.ManHoursSummary{
+		KoujiId:     koujiId,
+		ReportCount: len(reports),
+		ByWorker:    make(map[string]float64),
+		ByMonth:     make(map[string]float64),
+	}
+	for _, report := range reports {
+		summary.TotalManHours += report.ManHours
+		summary.ByMonth[report.Date[:7]] += report.ManHours
+		for _, worker := range report.Workers {
+			summary.ByWorker[worker.Name] += worker.Hours
+		}
+	}
+	if len(reports) > 0 {
+		summary.FirstDate = reports[0].Date
+		summary.LastDate = reports[len(reports)-1].Date
+	}
+	return summary, nil
+}
+
+// saveDailyReport は日報を検証して工事フォルダー内に保存する
+func (s *KoujiService) saveDailyReport(koujiId string, report models.DailyReport, update bool) (models.DailyReport, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return models.DailyReport{}, err
+	}
+	if err := validateDailyReport(&entry, &report); err != nil {
+		return models.DailyReport{}, err
+	}
+	path := filepath.Join(entry.Path, DailyReportDir, report.Date+".yaml")
+	_, statErr := os.Stat(path)
+	switch exists := statErr == nil; {
+	case update && !exists:
+		return models.DailyReport{}, fmt.Errorf("%w: %s %s", ErrDailyReportNotFound, koujiId, report.Date)
+	case !update && exists:
+		return models.DailyReport{}, fmt.Errorf("%w: %s %s", ErrDailyReportExists, koujiId, report.Date)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return models.DailyReport{}, err
+	}
+	report.UpdatedAt = models.NewTimestamp(time.Now())
+	if err := saveYAMLFile(path, report); err != nil {
+		return models.DailyReport{}, err
+	}
+	report.ManHours = dailyReportManHours(report)
+	return report, nil
+}
+
+// dailyReportPath は日報ファイルのパスを返す
+func (s *KoujiService) dailyReportPath(koujiId, date string) (string, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", fmt.Errorf("日付はYYYY-MM-DDで指定してください: %s", date)
+	}
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(entry.Path, DailyReportDir, date+".yaml"), nil
+}
+
+// validateDailyReport は日報の日付・作業時間・写真を検証する
+// 写真は工事フォルダー内に存在するファイルでなければならない
+func validateDailyReport(entry *models.KoujiEntry, report *models.DailyReport) error {
+	if _, err := time.Parse("2006-01-02", report.Date); err != nil {
+		return fmt.Errorf("日付はYYYY-MM-DDで指定してください: %s", report.Date)
+	}
+	var errs []error
+	for i := range report.Workers {
+		worker := &report.Workers[i]
+		worker.Name = strings.TrimSpace(worker.Name)
+		if worker.Name == "" {
+			errs = append(errs, fmt.Errorf("作業員名が空です"))
+		}
+		if worker.Hours < 0 || worker.Hours > 24 {
+			errs = append(errs, fmt.Errorf("%s: 作業時間は0〜24時間で指定してください", worker.Name))
+		}
+	}
+	for i, photo := range report.Photos {
+		rel := filepath.Clean(filepath.FromSlash(photo))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			errs = append(errs, fmt.Errorf("写真は工事フォルダーからの相対パスで指定してください: %s", photo))
+			continue
+		}
+		info, err := os.Stat(filepath.Join(entry.Path, rel))
+		if err != nil || info.IsDir() {
+			errs = append(errs, fmt.Errorf("写真がありません: %s", photo))
+			continue
+		}
+		report.Photos[i] = filepath.ToSlash(rel)
+	}
+	return errors.Join(errs...)
+}
+
+func loadDailyReport(path string) (models.DailyReport, error) {
+	var report models.DailyReport
+	if err := loadYAMLFile(path, &report); err != nil {
+		return models.DailyReport{}, fmt.Errorf("日報を読み込めません: %s: %w", filepath.Base(path), err)
+	}
+	date := strings.TrimSuffix(filepath.Base(path), ".yaml")
+	if report.Date == "" {
+		report.Date = date
+	}
+	if report.Date != date {
+		return models.DailyReport{}, fmt.Errorf("日報の日付がファイル名と一致しません: %s: %s", filepath.Base(path), report.Date)
+	}
+	report.ManHours = dailyReportManHours(report)
+	return report, nil
+}
+
+func dailyReportManHours(report models.DailyReport) float64 {
+	var total float64
+	for _, worker := range report.Workers {
+		total += worker.Hours
+	}
+	return total
+}
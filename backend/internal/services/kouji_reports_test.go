@@ -0,0 +1,66 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateDailyReport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "写真"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "写真", "0618.jpg"), []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry := &models.KoujiEntry{FileEntry: models.FileEntry{Path: dir}}
+
+	tests := []struct {
+		name    string
+		report  models.DailyReport
+		wantErr bool
+	}{
+		{"valid", models.DailyReport{Date: "2024-06-18", Workers: []models.DailyReportWorker{{Name: "山田", Hours: 8}}, Photos: []string{"写真/0618.jpg"}}, false},
+		{"bad date", models.DailyReport{Date: "2024/6/18"}, true},
+		{"too many hours", models.DailyReport{Date: "2024-06-18", Workers: []models.DailyReportWorker{{Name: "山田", Hours: 25}}}, true},
+		{"missing photo", models.DailyReport{Date: "2024-06-18", Photos: []string{"写真/none.jpg"}}, true},
+		{"outside folder", models.DailyReport{Date: "2024-06-18", Photos: []string{"../secret.jpg"}}, true},
+	}
+	for _, tt := range tests {
+		if err := validateDailyReport(entry, &tt.report); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateDailyReport() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGetDailyReportsFileNames(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	entry := s.GetKoujiEntries()[0]
+	if _, err := s.CreateDailyReport(entry.Id, models.DailyReport{Date: "2099-06-18", Workers: []models.DailyReportWorker{{Name: "山田", Hours: 8}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 日付でないファイル名のYAMLは日報とみなさない
+	dir := filepath.Join(entry.Path, DailyReportDir)
+	if err := os.WriteFile(filepath.Join(dir, "memo.yaml"), []byte("workers: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := s.GetManHoursSummary(entry.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ReportCount != 1 || summary.ByMonth["2099-06"] != 8 {
+		t.Errorf("summary = %+v, want only the 2099-06-18 report", summary)
+	}
+
+	// ファイル名と中の日付が違う日報はエラーにする
+	if err := os.WriteFile(filepath.Join(dir, "2099-06-19.yaml"), []byte("date: \"2099\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetManHoursSummary(entry.Id); err == nil || !strings.Contains(err.Error(), "2099-06-19.yaml") {
+		t.Errorf("GetManHoursSummary() error = %v, want the mismatched report", err)
+	}
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultKoujiRules はルールファイルがない場合に使用するルール
+// 「工事」・会社名・現場名・年度をタグとして付与する
+var DefaultKoujiRules = []models.KoujiRule{
+	{
+		Name:    "既定のタグ",
+		AddTags: []string{"工事", "{company}", "{location}", "{fiscal_year}年度"},
+	},
+}
+
+// koujiRuleFields はルールで設定できるフィールド
+var koujiRuleFields = map[string]func(e *models.KoujiEntry, value string) error{
+	"description": func(e *models.KoujiEntry, value string) error {
+		e.Description = value
+		return nil
+	},
+	"end_date": func(e *models.KoujiEntry, value string) error {
+		t, err := utils.ParseTime(value)
+		if err != nil {
+			return err
+		}
+		e.EndDate = models.NewTimestamp(t)
+		return nil
+	},
+}
+
+// koujiRuleField はルールで設定するフィールドの設定関数を返す
+// custom.<キー> はカスタムフィールドの定義に従って検証し、型を揃えた値を設定する（空の値は削除）
+// 工事の会社名が空の場合（会社を限定しないルールの保存時）は会社ごとの使用可否を判定しない
+func koujiRuleField(field string, schema []models.CustomFieldDefinition) (func(e *models.KoujiEntry, value string) error, bool) {
+	if key, ok := strings.CutPrefix(field, CustomFieldPrefix); ok && key != "" {
+		return func(e *models.KoujiEntry, value string) error {
+			i := slices.IndexFunc(schema, func(def models.CustomFieldDefinition) bool { return def.Key == key })
+			if i < 0 {
+				return fmt.Errorf("定義されていないカスタムフィールドです")
+			}
+			def := schema[i]
+			if e.CompanyName != "" && !def.AppliesTo(e.CompanyName) {
+				return fmt.Errorf("%s には使用できないカスタムフィールドです", e.CompanyName)
+			}
+			fields := make(map[string]any, len(e.CustomFields)+1)
+			for k, v := range e.CustomFields {
+				fields[k] = v
+			}
+			if value == "" {
+				delete(fields, key)
+			} else {
+				v, err := NormalizeCustomFieldValue(def, value)
+				if err != nil {
+					return err
+				}
+				fields[key] = v
+			}
+			if len(fields) == 0 {
+				fields = nil
+			}
+			e.CustomFields = fields
+			return nil
+		}, true
+	}
+	set, ok := koujiRuleFields[field]
+	return set, ok
+}
+
+// compiledKoujiRule は評価用に正規表現と日付を解析済みのルール
+type compiledKoujiRule struct {
+	models.KoujiRule
+	namePattern *regexp.Regexp
+	from, to    time.Time
+	// custom.<キー> の検証に使うカスタムフィールドの定義
+	schema []models.CustomFieldDefinition
+}
+
+// GetKoujiRules は保存されているルールを返す（ファイルがない場合は既定のルール）
+func (s *KoujiService) GetKoujiRules() ([]models.KoujiRule, error) {
+	var rules []models.KoujiRule
+	if err := loadYAMLFile(s.RulesPath, &rules); err != nil {
+		return nil, fmt.Errorf("ルールを読み込めません: %w", err)
+	}
+	if rules == nil {
+		return DefaultKoujiRules, nil
+	}
+	return rules, nil
+}
+
+// SaveKoujiRules はルールを検証して保存する
+func (s *KoujiService) SaveKoujiRules(rules []models.KoujiRule) error {
+	if _, err := s.compileKoujiRulesWithSchema(rules); err != nil {
+		return err
+	}
+	if rules == nil {
+		rules = []models.KoujiRule{}
+	}
+	return saveYAMLFile(s.RulesPath, rules)
+}
+
+// PreviewKoujiRules はルールごとに一致する工事と適用内容を返す
+// rulesがnilの場合は保存されているルールを使用する
+func (s *KoujiService) PreviewKoujiRules(rules []models.KoujiRule) ([]models.KoujiRulePreview, error) {
+	if rules == nil {
+		var err error
+		if rules, err = s.GetKoujiRules(); err != nil {
+			return nil, err
+		}
+	}
+	compiled, err := s.compileKoujiRulesWithSchema(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := s.GetKoujiEntries()
+	previews := make([]models.KoujiRulePreview, 0, len(compiled))
+	for _, rule := range compiled {
+		preview := models.KoujiRulePreview{Name: rule.Name, Matches: make([]models.KoujiRuleMatch, 0)}
+		for _, entry := range entries {
+			if rule.Disabled || !rule.match(&entry) {
+				continue
+			}
+			updated := entry
+			// 走査済みの工事はoverwriteが指定されたルールのみフィールドを変更する
+			rule.applyFields(&updated, rule.Overwrite)
+			match := models.KoujiRuleMatch{
+				KoujiId:      entry.Id,
+				CompanyName:  entry.CompanyName,
+				LocationName: entry.LocationName,
+				Tags:         rule.tags(&entry),
+				Changes:      make([]models.KoujiFieldChange, 0),
+			}
+			if updated.Description != entry.Description {
+				match.Changes = append(match.Changes, models.KoujiFieldChange{Field: "description", Old: entry.Description, New: updated.Description})
+			}
+			if !updated.EndDate.Time.Equal(entry.EndDate.Time) {
+				match.Changes = append(match.Changes, models.KoujiFieldChange{Field: "end_date", Old: formatImportDate(entry.EndDate), New: formatImportDate(updated.EndDate)})
+			}
+			for _, key := range slices.Sorted(maps.Keys(updated.CustomFields)) {
+				old := FormatCustomFieldValue(entry.CustomFields[key])
+				if next := FormatCustomFieldValue(updated.CustomFields[key]); next != old {
+					match.Changes = append(match.Changes, models.KoujiFieldChange{Field: CustomFieldPrefix + key, Old: old, New: next})
+				}
+			}
+			preview.Matches = append(preview.Matches, match)
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+// ApplyKoujiRules は保存されているルールをすべての工事に適用し、変更した工事の数を返す（変更がない場合は保存しない）
+// タグは追加のみ行い、フィールドはoverwriteが指定されたルールのみ設定する
+func (s *KoujiService) ApplyKoujiRules() (int, error) {
+	rules, err := s.loadCompiledKoujiRules()
+	if err != nil {
+		return 0, err
+	}
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	entries := s.GetKoujiEntries()
+	updated := 0
+	for i := range entries {
+		before := entries[i]
+		applyKoujiRules(&entries[i], rules, false)
+		if !slices.Equal(before.Tags, entries[i].Tags) || koujiRuleFieldsChanged(&before, &entries[i]) {
+			updated++
+		}
+	}
+	if updated > 0 {
+		if err := s.saveKoujiEntries(entries); err != nil {
+			return 0, err
+		}
+	}
+	return updated, nil
+}
+
+// koujiRuleFieldsChanged はルールで設定できるフィールドが変更されたかを判定する
+func koujiRuleFieldsChanged(before, after *models.KoujiEntry) bool {
+	if before.Description != after.Description || !before.EndDate.Time.Equal(after.EndDate.Time) {
+		return true
+	}
+	for key, value := range after.CustomFields {
+		if FormatCustomFieldValue(before.CustomFields[key]) != FormatCustomFieldValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCompiledKoujiRules は保存されているルールを評価用に読み込む
+func (s *KoujiService) loadCompiledKoujiRules() ([]compiledKoujiRule, error) {
+	rules, err := s.GetKoujiRules()
+	if err != nil {
+		return nil, err
+	}
+	return s.compileKoujiRulesWithSchema(rules)
+}
+
+// compileKoujiRulesWithSchema はカスタムフィールドの定義を読み込み、ルールを評価用に変換する
+func (s *KoujiService) compileKoujiRulesWithSchema(rules []models.KoujiRule) ([]compiledKoujiRule, error) {
+	schema, err := s.GetCustomFieldSchema()
+	if err != nil {
+		return nil, err
+	}
+	return compileKoujiRules(rules, schema)
+}
+
+// applyKoujiRules は一致するルールのタグを追加し、フィールドを設定する
+// 新しく見つかった工事（isNew）とApplyKoujiRulesからのみ呼び出す。isNewがfalseの場合、overwriteが指定されたルールのみフィールドを設定する
+func applyKoujiRules(entry *models.KoujiEntry, rules []compiledKoujiRule, isNew bool) {
+	for _, rule := range rules {
+		if rule.Disabled || !rule.match(entry) {
+			continue
+		}
+		entry.Tags = uniqueTags(append(rule.withoutStaleDatedTags(entry.Tags, entry), rule.tags(entry)...))
+		rule.applyFields(entry, isNew || rule.Overwrite)
+	}
+}
+
+// ruleTags は工事に一致するルールが付与するタグを返す
+func ruleTags(entry *models.KoujiEntry, rules []compiledKoujiRule) []string {
+	var tags []string
+	for _, rule := range rules {
+		if !rule.Disabled && rule.match(entry) {
+			tags = append(tags, rule.tags(entry)...)
+		}
+	}
+	return uniqueTags(tags)
+}
+
+func compileKoujiRules(rules []models.KoujiRule, schema []models.CustomFieldDefinition) ([]compiledKoujiRule, error) {
+	compiled := make([]compiledKoujiRule, 0, len(rules))
+	for i, rule := range rules {
+		c := compiledKoujiRule{KoujiRule: rule, schema: schema}
+		label := rule.Name
+		if label == "" {
+			label = fmt.Sprintf("%d番目", i+1)
+		}
+		cond := rule.Conditions
+		if cond.NamePattern != "" {
+			re, err := regexp.Compile(cond.NamePattern)
+			if err != nil {
+				return nil, fmt.Errorf("ルール %s: フォルダー名の正規表現が不正です: %w", label, err)
+			}
+			c.namePattern = re
+		}
+		if cond.From != "" {
+			t, err := utils.ParseTime(cond.From)
+			if err != nil {
+				return nil, fmt.Errorf("ルール %s: from が不正です: %w", label, err)
+			}
+			c.from = t
+		}
+		if cond.To != "" {
+			t, err := utils.ParseTime(cond.To)
+			if err != nil {
+				return nil, fmt.Errorf("ルール %s: to が不正です: %w", label, err)
+			}
+			c.to = t.AddDate(0, 0, 1)
+		}
+		if cond.FileGlob != "" {
+			if _, err := filepath.Match(cond.FileGlob, ""); err != nil {
+				return nil, fmt.Errorf("ルール %s: file_glob が不正です: %w", label, err)
+			}
+		}
+		for field, value := range rule.SetFields {
+			set, ok := koujiRuleField(field, schema)
+			if !ok {
+				return nil, fmt.Errorf("ルール %s: 設定できないフィールドです: %s", label, field)
+			}
+			if err := set(&models.KoujiEntry{CompanyName: cond.Company}, value); err != nil {
+				return nil, fmt.Errorf("ルール %s: %s の値が不正です: %w", label, field, err)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func (r *compiledKoujiRule) match(entry *models.KoujiEntry) bool {
+	cond := r.Conditions
+	if cond.Company != "" && entry.CompanyName != cond.Company {
+		return false
+	}
+	if cond.Location != "" && !strings.Contains(entry.LocationName, cond.Location) {
+		return false
+	}
+	if r.namePattern != nil && !r.namePattern.MatchString(entry.Name) {
+		return false
+	}
+	start := entry.StartDate.Time
+	if !r.from.IsZero() && (start.IsZero() || start.Before(r.from)) {
+		return false
+	}
+	if !r.to.IsZero() && (start.IsZero() || !start.Before(r.to)) {
+		return false
+	}
+	if cond.FileGlob != "" && !containsMatchingFile(entry.Path, cond.FileGlob) {
+		return false
+	}
+	return true
+}
+
+// tags はプレースホルダーを置換したタグを返す
+func (r *compiledKoujiRule) tags(entry *models.KoujiEntry) []string {
+	replacer := koujiTagReplacer(entry)
+	tags := make([]string, 0, len(r.AddTags))
+	for _, tag := range r.AddTags {
+		tags = append(tags, replacer.Replace(tag))
+	}
+	return uniqueTags(tags)
+}
+
+// koujiTagReplacer はタグのプレースホルダーを工事の値に置換する
+func koujiTagReplacer(entry *models.KoujiEntry) *strings.Replacer {
+	year, fiscalYear := "", ""
+	if !entry.StartDate.Time.IsZero() {
+		year = entry.StartDate.Time.Format("2006")
+	}
+	if entry.FiscalYear != 0 {
+		fiscalYear = strconv.Itoa(entry.FiscalYear)
+	}
+	return strings.NewReplacer(
+		"{company}", entry.CompanyName,
+		"{location}", entry.LocationName,
+		"{year}", year,
+		"{fiscal_year}", fiscalYear,
+		"{name}", entry.Name,
+	)
+}
+
+// datedTag は年・年度を含むタグの、現在の開始日・年度での値と、他の年の値でも一致する正規表現
+type datedTag struct {
+	current string
+	pattern *regexp.Regexp
+}
+
+// datedTags は年・年度を含むタグの雛形ごとに現在のタグと正規表現を返す（開始日がない場合はnil）
+func (r *compiledKoujiRule) datedTags(entry *models.KoujiEntry) []datedTag {
+	if entry.StartDate.Time.IsZero() {
+		return nil
+	}
+	var dated []datedTag
+	for _, tag := range r.AddTags {
+		if !strings.Contains(tag, "{year}") && !strings.Contains(tag, "{fiscal_year}") {
+			continue
+		}
+		replacer := strings.NewReplacer(
+			`\{company\}`, regexp.QuoteMeta(entry.CompanyName),
+			`\{location\}`, regexp.QuoteMeta(entry.LocationName),
+			`\{year\}`, `\d{4}`,
+			`\{fiscal_year\}`, `\d{4}`,
+			`\{name\}`, regexp.QuoteMeta(entry.Name),
+		)
+		re, err := regexp.Compile("^" + replacer.Replace(regexp.QuoteMeta(tag)) + "$")
+		if err != nil {
+			continue
+		}
+		dated = append(dated, datedTag{current: strings.TrimSpace(koujiTagReplacer(entry).Replace(tag)), pattern: re})
+	}
+	return dated
+}
+
+// withoutStaleDatedTags は年・年度のタグのうち、現在の開始日・年度と異なるもの（以前にルールが付与したもの）を除いたタグを返す
+func (r *compiledKoujiRule) withoutStaleDatedTags(tags []string, entry *models.KoujiEntry) []string {
+	dated := r.datedTags(entry)
+	if len(dated) == 0 {
+		return tags
+	}
+	return slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+		return slices.ContainsFunc(dated, func(d datedTag) bool {
+			return tag != d.current && d.pattern.MatchString(tag)
+		})
+	})
+}
+
+// refreshDatedTags は工事の年度を計算し直し、ルールが付与した年・年度のタグが古くなっていれば現在の値に置き換える
+// 保存のたびに呼ばれるため、ルールの条件（フォルダー内のファイルなど）は評価しない
+func (s *KoujiService) refreshDatedTags(entries []models.KoujiEntry) {
+	rules, err := s.loadCompiledKoujiRules()
+	if err != nil {
+		log.Printf("自動タグ付けルールを読み込めません: %v", err)
+		return
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.FiscalYear = s.FiscalYear(entry.StartDate.Time)
+		for _, rule := range rules {
+			if rule.Disabled {
+				continue
+			}
+			for _, d := range rule.datedTags(entry) {
+				stale := func(tag string) bool { return tag != d.current && d.pattern.MatchString(tag) }
+				if slices.ContainsFunc(entry.Tags, stale) {
+					entry.Tags = uniqueTags(append(slices.DeleteFunc(slices.Clone(entry.Tags), stale), d.current))
+				}
+			}
+		}
+	}
+}
+
+func (r *compiledKoujiRule) applyFields(entry *models.KoujiEntry, overwrite bool) {
+	if !overwrite {
+		return
+	}
+	for field, value := range r.SetFields {
+		set, _ := koujiRuleField(field, r.schema)
+		// カスタムフィールドは工事の会社で使用できない場合があるため、適用時にも検証する
+		if err := set(entry, value); err != nil {
+			log.Printf("ルール %s: 工事 %s の %s を設定できません: %v", r.Name, entry.Id, field, err)
+		}
+	}
+}
+
+// containsMatchingFile はフォルダー内にパターンに一致するファイルがあるかを判定する
+// パターンに "/" を含む場合はフォルダーからの相対パス、含まない場合はファイル名と照合する
+func containsMatchingFile(dir, pattern string) bool {
+	if dir == "" {
+		return false
+	}
+	matchPath := strings.Contains(pattern, "/")
+	found := false
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+		target := d.Name()
+		if matchPath {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			target = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found
+}
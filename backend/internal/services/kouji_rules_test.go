@@ -0,0 +1,99 @@
+package services
+
+import (
+	"os"
+	"penguin-backend/internal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKoujiRulesOnlyForNewEntries(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	rules := []models.KoujiRule{{Name: "会社", AddTags: []string{"{company}"}}}
+	if err := s.SaveKoujiRules(rules); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新しく見つかった工事にはルールのタグを付与するが、データベースには書き込まない
+	entries := s.GetKoujiEntries()
+	if len(entries) != 1 || !slices.Equal(entries[0].Tags, []string{"豊田築炉"}) {
+		t.Fatalf("GetKoujiEntries() tags = %v, want [豊田築炉]", entries[0].Tags)
+	}
+	if _, err := os.Stat(s.DatabasePath); !os.IsNotExist(err) {
+		t.Fatalf("GetKoujiEntries() wrote the database: %v", err)
+	}
+
+	// 変更したタグは次の取得で元に戻らない
+	if _, err := s.RenameTag("豊田築炉", "豊田"); err != nil {
+		t.Fatal(err)
+	}
+	entries = s.GetKoujiEntries()
+	if !slices.Equal(entries[0].Tags, []string{"豊田"}) {
+		t.Fatalf("tags after rename = %v, want [豊田]", entries[0].Tags)
+	}
+
+	// 一括適用すると既存の工事にもルールのタグを付与する
+	updated, err := s.ApplyKoujiRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries = s.GetKoujiEntries()
+	if updated != 1 || !slices.Equal(entries[0].Tags, []string{"豊田", "豊田築炉"}) {
+		t.Fatalf("ApplyKoujiRules() = %d, tags %v; want 1, [豊田 豊田築炉]", updated, entries[0].Tags)
+	}
+
+	// 変更する工事がない場合はデータベースに書き込まない
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+	if err := os.Chtimes(s.DatabasePath, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := s.ApplyKoujiRules(); err != nil || updated != 0 {
+		t.Fatalf("second ApplyKoujiRules() = %d, %v; want 0", updated, err)
+	}
+	if info, err := os.Stat(s.DatabasePath); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("second ApplyKoujiRules() rewrote the database: %v", err)
+	}
+}
+
+func TestKoujiRuleCustomFields(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場", "2099-07-01 中部炉材 大府工場")
+	schema := []models.CustomFieldDefinition{
+		{Key: "受注金額", Type: models.CustomFieldTypeInteger},
+		{Key: "炉の種類", Type: models.CustomFieldTypeEnum, Enum: []string{"溶解炉", "加熱炉"}},
+		{Key: "契約番号", Type: models.CustomFieldTypeString, Companies: []string{"豊田築炉"}},
+	}
+	if err := s.SaveCustomFieldSchema(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	// 定義にないキー・定義に合わない値・対象外の会社のルールは保存しない
+	for _, rule := range []models.KoujiRule{
+		{Name: "未定義", SetFields: map[string]string{"custom.未定義": "値"}},
+		{Name: "選択肢外", SetFields: map[string]string{"custom.炉の種類": "高炉"}},
+		{Name: "整数", SetFields: map[string]string{"custom.受注金額": "1.5"}},
+		{Name: "対象外", Conditions: models.KoujiRuleConditions{Company: "中部炉材"}, SetFields: map[string]string{"custom.契約番号": "TC-0001"}},
+	} {
+		if err := s.SaveKoujiRules([]models.KoujiRule{rule}); err == nil {
+			t.Errorf("SaveKoujiRules(%s) succeeded, want error", rule.Name)
+		}
+	}
+
+	rules := []models.KoujiRule{{
+		Name:      "既定値",
+		SetFields: map[string]string{"custom.受注金額": "1,000", "custom.契約番号": "TC-0001"},
+	}}
+	if err := s.SaveKoujiRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	// 値は定義の型に揃え、工事の会社で使用できないフィールドは設定しない
+	for _, entry := range s.GetKoujiEntries() {
+		if v := entry.CustomFields["受注金額"]; v != int64(1000) {
+			t.Errorf("%s: 受注金額 = %#v, want int64(1000)", entry.CompanyName, v)
+		}
+		_, ok := entry.CustomFields["契約番号"]
+		if want := entry.CompanyName == "豊田築炉"; ok != want {
+			t.Errorf("%s: 契約番号 set = %v, want %v", entry.CompanyName, ok, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"penguin-backend/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultFiscalYearStartMonth は年度の開始月の既定値（4月始まり）
+const DefaultFiscalYearStartMonth = time.April
+
+// DefaultKoujiSettings は設定ファイルがない場合の設定
+var DefaultKoujiSettings = models.KoujiSettings{
+	FiscalYearStartMonth:    int(DefaultFiscalYearStartMonth),
+	TimesheetLockedStatuses: []string{"完了"},
+	LabourBudgetField:       "労務費予算",
+	ConsumptionTaxRates: []models.ConsumptionTaxRate{
+		{From: "1989-04-01", Rate: 3},
+		{From: "1997-04-01", Rate: 5},
+		{From: "2014-04-01", Rate: 8},
+		{From: "2019-10-01", Rate: 10},
+	},
+}
+
+// GetSettings は現在の設定を返す
+func (s *KoujiService) GetSettings() models.KoujiSettings {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.settings
+}
+
+// SaveSettings は設定を検証して保存する
+// 年度の開始月を変更した場合は、工事の年度のタグも新しい年度に置き換える
+func (s *KoujiService) SaveSettings(settings models.KoujiSettings) error {
+	if settings.FiscalYearStartMonth < 1 || settings.FiscalYearStartMonth > 12 {
+		return fmt.Errorf("年度の開始月は1〜12で指定してください: %d", settings.FiscalYearStartMonth)
+	}
+	if settings.TimesheetLockedStatuses == nil {
+		settings.TimesheetLockedStatuses = DefaultKoujiSettings.TimesheetLockedStatuses
+	}
+	if settings.ConsumptionTaxRates == nil {
+		settings.ConsumptionTaxRates = DefaultKoujiSettings.ConsumptionTaxRates
+	}
+	rates, err := sortedConsumptionTaxRates(settings.ConsumptionTaxRates)
+	if err != nil {
+		return err
+	}
+	settings.ConsumptionTaxRates = rates
+	if settings.UIBaseURL != "" {
+		u, err := url.Parse(settings.UIBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("UIのベースURLはhttp://またはhttps://で始まるURLで指定してください: %s", settings.UIBaseURL)
+		}
+		settings.UIBaseURL = strings.TrimRight(settings.UIBaseURL, "/")
+	}
+	if err := saveYAMLFile(s.SettingsPath, settings); err != nil {
+		return err
+	}
+	s.settingsMu.Lock()
+	previous := s.settings
+	s.settings = settings
+	s.settingsMu.Unlock()
+
+	if settings.FiscalYearStartMonth != previous.FiscalYearStartMonth {
+		s.storeMu.Lock()
+		defer s.storeMu.Unlock()
+		if entries := s.GetKoujiEntries(); len(entries) > 0 {
+			return s.saveKoujiEntries(entries)
+		}
+	}
+	return nil
+}
+
+// loadSettings は設定ファイルを読み込む（ファイルがない場合は既定の設定）
+func (s *KoujiService) loadSettings() error {
+	settings := DefaultKoujiSettings
+	if err := loadYAMLFile(s.SettingsPath, &settings); err != nil {
+		return fmt.Errorf("設定を読み込めません: %w", err)
+	}
+	if settings.FiscalYearStartMonth < 1 || settings.FiscalYearStartMonth > 12 {
+		settings.FiscalYearStartMonth = int(DefaultFiscalYearStartMonth)
+	}
+	if settings.TimesheetLockedStatuses == nil {
+		settings.TimesheetLockedStatuses = DefaultKoujiSettings.TimesheetLockedStatuses
+	}
+	if settings.ConsumptionTaxRates == nil {
+		settings.ConsumptionTaxRates = DefaultKoujiSettings.ConsumptionTaxRates
+	}
+	// 手で編集した設定ファイルでは税率が適用開始日の順に並んでいるとは限らない
+	rates, err := sortedConsumptionTaxRates(settings.ConsumptionTaxRates)
+	if err != nil {
+		return fmt.Errorf("設定を読み込めません: %w", err)
+	}
+	settings.ConsumptionTaxRates = rates
+	s.settingsMu.Lock()
+	s.settings = settings
+	s.settingsMu.Unlock()
+	return nil
+}
+
+// sortedConsumptionTaxRates は消費税率の表を検証し、TaxRateOn で使えるように適用開始日の昇順に並べたコピーを返す
+func sortedConsumptionTaxRates(rates []models.ConsumptionTaxRate) ([]models.ConsumptionTaxRate, error) {
+	for _, r := range rates {
+		if _, err := time.Parse("2006-01-02", r.From); err != nil {
+			return nil, fmt.Errorf("消費税率の適用開始日はYYYY-MM-DDで指定してください: %s", r.From)
+		}
+		if r.Rate < 0 || r.Rate > 100 {
+			return nil, fmt.Errorf("消費税率は0〜100で指定してください: %d", r.Rate)
+		}
+	}
+	rates = slices.Clone(rates)
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].From < rates[j].From })
+	return rates, nil
+}
+
+// FiscalYear は設定された開始月に基づいて日付が属する年度を返す
+func (s *KoujiService) FiscalYear(t time.Time) int {
+	return FiscalYearOf(t, time.Month(s.GetSettings().FiscalYearStartMonth))
+}
+
+// FiscalYearOf は日付が属する年度を返す（例: 4月始まりの場合、2025年3月は2024年度）
+// 日付がゼロ値の場合は0を返す
+func FiscalYearOf(t time.Time, startMonth time.Month) int {
+	if t.IsZero() {
+		return 0
+	}
+	if t.Month() < startMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"os"
+	"penguin-backend/internal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFiscalYearOf(t *testing.T) {
+	tests := []struct {
+		date       time.Time
+		startMonth time.Month
+		want       int
+	}{
+		{time.Date(2025, 3, 31, 0, 0, 0, 0, time.Local), time.April, 2024},
+		{time.Date(2025, 4, 1, 0, 0, 0, 0, time.Local), time.April, 2025},
+		{time.Date(2025, 3, 31, 0, 0, 0, 0, time.Local), time.January, 2025},
+		{time.Date(2025, 9, 30, 0, 0, 0, 0, time.Local), time.October, 2024},
+		{time.Time{}, time.April, 0},
+	}
+	for _, tt := range tests {
+		if got := FiscalYearOf(tt.date, tt.startMonth); got != tt.want {
+			t.Errorf("FiscalYearOf(%s, %d) = %d, want %d", tt.date.Format("2006-01-02"), tt.startMonth, got, tt.want)
+		}
+	}
+}
+
+func TestSaveSettingsUIBaseURL(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	settings := s.GetSettings()
+	for _, invalid := range []string{"javascript:alert(1)", "//evil.example.com", "penguin.example.com"} {
+		settings.UIBaseURL = invalid
+		if err := s.SaveSettings(settings); err == nil {
+			t.Errorf("UI base URL %q was accepted", invalid)
+		}
+	}
+	settings.UIBaseURL = "https://penguin.example.com/"
+	if err := s.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetSettings().UIBaseURL; got != "https://penguin.example.com" {
+		t.Errorf("UIBaseURL = %s", got)
+	}
+}
+
+func TestFiscalYearTagsFollowDates(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	entries := s.GetKoujiEntries()
+	if !slices.Contains(entries[0].Tags, "2099年度") {
+		t.Fatalf("tags = %v, want 2099年度", entries[0].Tags)
+	}
+	if err := s.SaveKoujiEntries(entries); err != nil {
+		t.Fatal(err)
+	}
+	id := entries[0].Id
+
+	fiscalYearTags := func() []string {
+		t.Helper()
+		entry, err := s.GetKoujiEntryByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slices.DeleteFunc(slices.Clone(entry.Tags), func(tag string) bool {
+			return len(tag) != len("2099年度") || tag[4:] != "年度"
+		})
+	}
+
+	// 年度の開始月を変更すると古い年度のタグを置き換える
+	settings := s.GetSettings()
+	settings.FiscalYearStartMonth = 7
+	if err := s.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if got := fiscalYearTags(); !slices.Equal(got, []string{"2098年度"}) {
+		t.Fatalf("fiscal year tags after settings change = %v, want [2098年度]", got)
+	}
+
+	// 開始日を変更しても同様
+	start := models.NewTimestamp(time.Date(2100, 8, 1, 0, 0, 0, 0, time.Local))
+	if _, err := s.UpdateProjectDates(id, start, start); err != nil {
+		t.Fatal(err)
+	}
+	if got := fiscalYearTags(); !slices.Equal(got, []string{"2100年度"}) {
+		t.Fatalf("fiscal year tags after date change = %v, want [2100年度]", got)
+	}
+
+	// 一括適用しても古い年度のタグは増えない
+	if _, err := s.ApplyKoujiRules(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fiscalYearTags(); !slices.Equal(got, []string{"2100年度"}) {
+		t.Fatalf("fiscal year tags after apply = %v, want [2100年度]", got)
+	}
+}
+
+func TestLoadSettingsSortsTaxRates(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+
+	// 手で編集して適用開始日の順に並んでいない税率の表
+	data := "consumption_tax_rates:\n  - {from: \"2019-10-01\", rate: 10}\n  - {from: \"1997-04-01\", rate: 5}\n  - {from: \"2014-04-01\", rate: 8}\n"
+	if err := os.WriteFile(s.SettingsPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	for date, want := range map[string]int{"1997-03-31": 0, "2000-01-01": 5, "2015-01-01": 8, "2099-06-18": 10} {
+		if got := s.TaxRateOn(date); got != want {
+			t.Errorf("TaxRateOn(%s) = %d, want %d", date, got, want)
+		}
+	}
+
+	if err := os.WriteFile(s.SettingsPath, []byte("consumption_tax_rates:\n  - {from: \"2019/10/01\", rate: 10}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadSettings(); err == nil {
+		t.Error("loadSettings() with an invalid tax rate date succeeded, want error")
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"sort"
+	"strconv"
+)
+
+// GetKoujiStats は絞り込み条件に一致する工事の集計結果を返す
+func (s *KoujiService) GetKoujiStats(filter *KoujiFilter) *models.KoujiStats {
+	entries := filter.Apply(s.GetKoujiEntries())
+
+	stats := &models.KoujiStats{
+		Count:              len(entries),
+		ByYear:             make(map[string]int),
+		ByFiscalYear:       make(map[string]int),
+		ByCompany:          make(map[string]int),
+		ByStatus:           make(map[string]int),
+		Running:            make([]models.KoujiEntry, 0),
+		DiskUsageByCompany: make(map[string]int64),
+	}
+
+	var durations []float64
+	for _, entry := range entries {
+		start := entry.StartDate.Time
+		if !start.IsZero() {
+			stats.ByYear[strconv.Itoa(start.Year())]++
+			stats.ByFiscalYear[strconv.Itoa(entry.FiscalYear)]++
+		}
+		stats.ByCompany[entry.CompanyName]++
+		stats.ByStatus[entry.Status]++
+
+		// 終了日の既定値は開始日のため、終了日が開始日と同じ工事は1日で終わった工事か終了日が未入力の工事か区別できない
+		// 未入力の工事で平均・中央値が下がらないように、工期の集計から除外する
+		if !start.IsZero() && entry.EndDate.Time.After(start) {
+			durations = append(durations, entry.EndDate.Time.Sub(start).Hours()/24)
+		}
+
+		if entry.Status == "進行中" {
+			stats.Running = append(stats.Running, entry)
+		}
+
+		size, err := s.FileSystemService.GetDirectorySize(entry.Path)
+		if err != nil {
+			continue
+		}
+		stats.DiskUsageByCompany[entry.CompanyName] += size
+		stats.TotalDiskUsage += size
+	}
+
+	stats.DurationSampleCount = len(durations)
+	if len(durations) > 0 {
+		sort.Float64s(durations)
+		var sum float64
+		for _, d := range durations {
+			sum += d
+		}
+		stats.AverageDurationDays = sum / float64(len(durations))
+		mid := len(durations) / 2
+		if len(durations)%2 == 0 {
+			stats.MedianDurationDays = (durations[mid-1] + durations[mid]) / 2
+		} else {
+			stats.MedianDurationDays = durations[mid]
+		}
+	}
+
+	return stats
+}
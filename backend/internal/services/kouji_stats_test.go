@@ -0,0 +1,80 @@
+package services
+
+import (
+	"maps"
+	"penguin-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestGetKoujiStats(t *testing.T) {
+	s := newTestKoujiService(t,
+		"2024-06-18 豊田築炉 名和工場",
+		"2025-01-10 豊田築炉 刈谷工場",
+		"2026-04-01 愛知製鋼 知多工場",
+		"2026-05-01 中部炉材 大府工場",
+	)
+	if err := s.SaveKoujiEntries(s.GetKoujiEntries()); err != nil {
+		t.Fatal(err)
+	}
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	// 大府工場は終了日が開始日のまま
+	ends := map[string]models.Timestamp{
+		"名和工場": date(2024, 6, 28),
+		"刈谷工場": date(2025, 1, 30),
+		"知多工場": date(2026, 4, 4),
+	}
+	for _, entry := range s.GetKoujiEntries() {
+		if end, ok := ends[entry.LocationName]; ok {
+			if _, err := s.UpdateProjectDates(entry.Id, entry.StartDate, end); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stats := s.GetKoujiStats(nil)
+	if stats.Count != 4 {
+		t.Errorf("Count = %d, want 4", stats.Count)
+	}
+	if want := map[string]int{"2024": 1, "2025": 1, "2026": 2}; !maps.Equal(stats.ByYear, want) {
+		t.Errorf("ByYear = %v, want %v", stats.ByYear, want)
+	}
+	// 4月始まりの年度では2025年1月の工事は2024年度
+	if want := map[string]int{"2024": 2, "2026": 2}; !maps.Equal(stats.ByFiscalYear, want) {
+		t.Errorf("ByFiscalYear = %v, want %v", stats.ByFiscalYear, want)
+	}
+	if want := map[string]int{"豊田築炉": 2, "愛知製鋼": 1, "中部炉材": 1}; !maps.Equal(stats.ByCompany, want) {
+		t.Errorf("ByCompany = %v, want %v", stats.ByCompany, want)
+	}
+	// 工期は10日・20日・3日。終了日が開始日のままの工事は含めない
+	if stats.DurationSampleCount != 3 || stats.AverageDurationDays != 11 || stats.MedianDurationDays != 10 {
+		t.Errorf("durations = %d samples, average %v, median %v; want 3, 11, 10",
+			stats.DurationSampleCount, stats.AverageDurationDays, stats.MedianDurationDays)
+	}
+
+	// 件数が偶数の場合の中央値は中央の2件の平均
+	stats = s.GetKoujiStats(&KoujiFilter{Company: "豊田"})
+	if stats.Count != 2 || stats.DurationSampleCount != 2 || stats.MedianDurationDays != 15 {
+		t.Errorf("豊田 stats = %d entries, %d samples, median %v; want 2, 2, 15",
+			stats.Count, stats.DurationSampleCount, stats.MedianDurationDays)
+	}
+	if want := map[string]int{"2024": 2}; !maps.Equal(stats.ByFiscalYear, want) {
+		t.Errorf("豊田 ByFiscalYear = %v, want %v", stats.ByFiscalYear, want)
+	}
+
+	// 年度の区切りを変更すると年度ごとの件数も変わる
+	settings := s.GetSettings()
+	settings.FiscalYearStartMonth = 1
+	if err := s.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	stats = s.GetKoujiStats(&KoujiFilter{FiscalYear: 2025})
+	if stats.Count != 1 || stats.ByFiscalYear["2025"] != 1 {
+		t.Errorf("fiscal year 2025 stats = %d entries, %v; want the 刈谷工場 entry", stats.Count, stats.ByFiscalYear)
+	}
+	if stats.DurationSampleCount != 1 || stats.AverageDurationDays != 20 {
+		t.Errorf("fiscal year 2025 durations = %d samples, average %v; want 1, 20", stats.DurationSampleCount, stats.AverageDurationDays)
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"fmt"
+	"penguin-backend/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// GetTagVocabulary は管理語彙を読み込む
+func (s *KoujiService) GetTagVocabulary() (models.TagVocabulary, error) {
+	vocabulary := models.TagVocabulary{Tags: []string{}}
+	if err := loadYAMLFile(s.TagVocabularyPath, &vocabulary); err != nil {
+		return vocabulary, fmt.Errorf("管理語彙を読み込めません: %w", err)
+	}
+	return vocabulary, nil
+}
+
+// SaveTagVocabulary は管理語彙を保存する（重複と空のタグは除去する）
+func (s *KoujiService) SaveTagVocabulary(vocabulary models.TagVocabulary) (models.TagVocabulary, error) {
+	vocabulary.Tags = uniqueTags(vocabulary.Tags)
+	return vocabulary, saveYAMLFile(s.TagVocabularyPath, vocabulary)
+}
+
+// ListTags はすべてのタグと使用数、統合候補のグループを返す
+func (s *KoujiService) ListTags() (*models.TagListResponse, error) {
+	vocabulary, err := s.GetTagVocabulary()
+	if err != nil {
+		return nil, err
+	}
+	managed := make(map[string]bool, len(vocabulary.Tags))
+	for _, tag := range vocabulary.Tags {
+		managed[tag] = true
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range s.GetKoujiEntries() {
+		seen := make(map[string]bool, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+	}
+	// 未使用の管理語彙も一覧に含める
+	for tag := range managed {
+		if _, ok := counts[tag]; !ok {
+			counts[tag] = 0
+		}
+	}
+
+	response := &models.TagListResponse{
+		Tags:          make([]models.TagUsage, 0, len(counts)),
+		SimilarGroups: make([][]string, 0),
+		Restricted:    vocabulary.Restricted,
+	}
+	groups := make(map[string][]string)
+	for tag, count := range counts {
+		response.Tags = append(response.Tags, models.TagUsage{Tag: tag, Count: count, Managed: managed[tag]})
+		key := NormalizeTag(tag)
+		groups[key] = append(groups[key], tag)
+	}
+	sort.Slice(response.Tags, func(i, j int) bool {
+		if response.Tags[i].Count != response.Tags[j].Count {
+			return response.Tags[i].Count > response.Tags[j].Count
+		}
+		return response.Tags[i].Tag < response.Tags[j].Tag
+	})
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Strings(group)
+			response.SimilarGroups = append(response.SimilarGroups, group)
+		}
+	}
+	sort.Slice(response.SimilarGroups, func(i, j int) bool {
+		return response.SimilarGroups[i][0] < response.SimilarGroups[j][0]
+	})
+	return response, nil
+}
+
+// RenameTag はすべての工事のタグ from を to に変更する
+func (s *KoujiService) RenameTag(from, to string) (int, error) {
+	return s.MergeTags([]string{from}, to)
+}
+
+// MergeTags はすべての工事の sources のタグを target に統合する
+// 管理語彙に sources が含まれていた場合は target に置き換える
+// 管理語彙が制限されている場合、target は管理語彙のタグか、管理語彙のタグの変更後の名前でなければならない
+func (s *KoujiService) MergeTags(sources []string, target string) (int, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return 0, fmt.Errorf("変更後のタグが空です")
+	}
+	replace := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		if source != "" && source != target {
+			replace[source] = true
+		}
+	}
+	if len(replace) == 0 {
+		return 0, fmt.Errorf("変更対象のタグがありません")
+	}
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	vocabulary, err := s.GetTagVocabulary()
+	if err != nil {
+		return 0, err
+	}
+	vocabularyTags, vocabularyChanged := replaceTags(vocabulary.Tags, replace, target)
+	if vocabulary.Restricted && !vocabularyChanged && !slices.Contains(vocabulary.Tags, target) {
+		return 0, fmt.Errorf("管理語彙にないタグには統合できません: %s", target)
+	}
+
+	entries := s.GetKoujiEntries()
+	updated := 0
+	for i := range entries {
+		tags, changed := replaceTags(entries[i].Tags, replace, target)
+		if changed {
+			entries[i].Tags = tags
+			updated++
+		}
+	}
+	if updated > 0 {
+		if err := s.saveKoujiEntries(entries); err != nil {
+			return 0, err
+		}
+	}
+
+	if vocabularyChanged {
+		vocabulary.Tags = vocabularyTags
+		if _, err := s.SaveTagVocabulary(vocabulary); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// ValidateKoujiTags は管理語彙が制限されている場合に、既存の工事にない新しいタグが
+// 管理語彙に含まれているかを検証する。自動タグ付けルールで付与されるタグは常に許可する
+func (s *KoujiService) ValidateKoujiTags(entries []models.KoujiEntry) error {
+	vocabulary, err := s.GetTagVocabulary()
+	if err != nil || !vocabulary.Restricted {
+		return err
+	}
+	allowed := make(map[string]bool, len(vocabulary.Tags))
+	for _, tag := range vocabulary.Tags {
+		allowed[tag] = true
+	}
+	rules, err := s.loadCompiledKoujiRules()
+	if err != nil {
+		return err
+	}
+	current := make(map[string][]string)
+	for _, entry := range s.GetKoujiEntriesFromDatabase() {
+		current[entry.Id] = entry.Tags
+	}
+
+	var invalid []string
+	for _, entry := range entries {
+		existing := make(map[string]bool)
+		for _, tag := range current[entry.Id] {
+			existing[tag] = true
+		}
+		for _, tag := range ruleTags(&entry, rules) {
+			existing[tag] = true
+		}
+		for _, tag := range entry.Tags {
+			if !allowed[tag] && !existing[tag] {
+				invalid = append(invalid, tag)
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("管理語彙にないタグです: %s", strings.Join(uniqueTags(invalid), ", "))
+	}
+	return nil
+}
+
+// NormalizeTag はタグ比較用に空白を除去し、全角・半角と大文字・小文字の違いをなくす
+func NormalizeTag(tag string) string {
+	folded := width.Fold.String(tag)
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, folded))
+}
+
+// replaceTags は replace に含まれるタグを target に置き換え、重複を除去する
+func replaceTags(tags []string, replace map[string]bool, target string) ([]string, bool) {
+	changed := false
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if replace[tag] {
+			tag = target
+			changed = true
+		}
+		result = append(result, tag)
+	}
+	if !changed {
+		return tags, false
+	}
+	return uniqueTags(result), true
+}
+
+// uniqueTags は順序を保ったまま重複と空のタグを除去する
+func uniqueTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
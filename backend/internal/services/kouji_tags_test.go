@@ -0,0 +1,177 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"slices"
+	"testing"
+)
+
+// newTestTaggedKoujiService は工事にタグを付けたKoujiServiceを返す（tagsは工事の現場名ごとのタグ）
+func newTestTaggedKoujiService(t *testing.T, tags map[string][]string) *KoujiService {
+	t.Helper()
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場", "2099-07-01 豊田築炉 刈谷工場", "2099-08-01 愛知製鋼 知多工場")
+	entries := s.GetKoujiEntries()
+	for i := range entries {
+		entries[i].Tags = tags[entries[i].LocationName]
+	}
+	if err := s.SaveKoujiEntries(entries); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// koujiTags は現場名ごとのタグを返す
+func koujiTags(s *KoujiService) map[string][]string {
+	tags := make(map[string][]string)
+	for _, entry := range s.GetKoujiEntries() {
+		tags[entry.LocationName] = entry.Tags
+	}
+	return tags
+}
+
+func TestListTags(t *testing.T) {
+	s := newTestTaggedKoujiService(t, map[string][]string{
+		"名和工場": {"築炉", "溶解炉"},
+		"刈谷工場": {"築炉", "ＡＢＣ"},
+		"知多工場": {"築 炉", "abc"},
+	})
+	if _, err := s.SaveTagVocabulary(models.TagVocabulary{Tags: []string{"築炉", "見積のみ"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := s.ListTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 使用数の多い順、同じ使用数はタグ名順。未使用の管理語彙も含める
+	want := []models.TagUsage{
+		{Tag: "築炉", Count: 2, Managed: true},
+		{Tag: "abc", Count: 1},
+		{Tag: "溶解炉", Count: 1},
+		{Tag: "築 炉", Count: 1},
+		{Tag: "ＡＢＣ", Count: 1},
+		{Tag: "見積のみ", Count: 0, Managed: true},
+	}
+	if !slices.Equal(list.Tags, want) {
+		t.Errorf("ListTags().Tags = %+v, want %+v", list.Tags, want)
+	}
+	// 空白・全角半角・大文字小文字だけが違うタグは統合候補にする
+	if len(list.SimilarGroups) != 2 || !slices.Equal(list.SimilarGroups[0], []string{"abc", "ＡＢＣ"}) ||
+		!slices.Equal(list.SimilarGroups[1], []string{"築 炉", "築炉"}) {
+		t.Errorf("ListTags().SimilarGroups = %v", list.SimilarGroups)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	s := newTestTaggedKoujiService(t, map[string][]string{
+		"名和工場": {"築炉", "築 炉"},
+		"刈谷工場": {"築 炉", "溶解炉"},
+		"知多工場": {"加熱炉"},
+	})
+	if _, err := s.SaveTagVocabulary(models.TagVocabulary{Tags: []string{"築 炉", "加熱炉"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		sources []string
+		target  string
+	}{
+		{[]string{"築炉"}, " "},
+		{[]string{"築炉"}, "築炉"},
+		{nil, "築炉"},
+	} {
+		if _, err := s.MergeTags(tt.sources, tt.target); err == nil {
+			t.Errorf("MergeTags(%v, %q) succeeded, want error", tt.sources, tt.target)
+		}
+	}
+
+	// 統合後の重複は除去し、管理語彙のタグも置き換える
+	updated, err := s.MergeTags([]string{"築炉", "築 炉", "未使用"}, "築炉工事")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := koujiTags(s)
+	if updated != 2 || !slices.Equal(tags["名和工場"], []string{"築炉工事"}) || !slices.Equal(tags["刈谷工場"], []string{"築炉工事", "溶解炉"}) {
+		t.Errorf("MergeTags() = %d, tags %v", updated, tags)
+	}
+	vocabulary, err := s.GetTagVocabulary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(vocabulary.Tags, []string{"築炉工事", "加熱炉"}) {
+		t.Errorf("vocabulary after merge = %v, want [築炉工事 加熱炉]", vocabulary.Tags)
+	}
+}
+
+func TestMergeTagsRestricted(t *testing.T) {
+	s := newTestTaggedKoujiService(t, map[string][]string{
+		"名和工場": {"築炉"},
+		"刈谷工場": {"ちくろ"},
+		"知多工場": {"加熱炉"},
+	})
+	if _, err := s.SaveTagVocabulary(models.TagVocabulary{Restricted: true, Tags: []string{"築炉", "加熱炉"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 管理語彙にないタグには統合できず、工事のタグも変更しない
+	if _, err := s.MergeTags([]string{"ちくろ"}, "チクロ"); err == nil {
+		t.Error("MergeTags() into an unmanaged tag succeeded, want error")
+	}
+	if tags := koujiTags(s); !slices.Equal(tags["刈谷工場"], []string{"ちくろ"}) {
+		t.Errorf("tags after rejected merge = %v", tags)
+	}
+
+	// 管理語彙のタグには統合できる
+	if updated, err := s.MergeTags([]string{"ちくろ"}, "築炉"); err != nil || updated != 1 {
+		t.Errorf("MergeTags() into a managed tag = %d, %v; want 1", updated, err)
+	}
+	// 管理語彙のタグの名前を変えると、管理語彙も新しい名前になる
+	if updated, err := s.RenameTag("加熱炉", "加熱炉工事"); err != nil || updated != 1 {
+		t.Errorf("RenameTag() of a managed tag = %d, %v; want 1", updated, err)
+	}
+	vocabulary, err := s.GetTagVocabulary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(vocabulary.Tags, []string{"築炉", "加熱炉工事"}) {
+		t.Errorf("vocabulary after rename = %v, want [築炉 加熱炉工事]", vocabulary.Tags)
+	}
+}
+
+func TestValidateKoujiTags(t *testing.T) {
+	s := newTestTaggedKoujiService(t, map[string][]string{"名和工場": {"旧タグ"}})
+	withTags := func(tags ...string) []models.KoujiEntry {
+		entries := s.GetKoujiEntries()
+		for i := range entries {
+			if entries[i].LocationName == "名和工場" {
+				entries[i].Tags = tags
+			}
+		}
+		return entries
+	}
+
+	// 制限されていなければ新しいタグも追加できる
+	if err := s.ValidateKoujiTags(withTags("旧タグ", "新タグ")); err != nil {
+		t.Errorf("ValidateKoujiTags() without restriction = %v", err)
+	}
+
+	if _, err := s.SaveTagVocabulary(models.TagVocabulary{Restricted: true, Tags: []string{"築炉"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveKoujiRules([]models.KoujiRule{{Name: "会社", AddTags: []string{"{company}"}}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tags    []string
+		wantErr bool
+	}{
+		// 管理語彙のタグ、既に付いているタグ、ルールで付与されるタグは許可する
+		{[]string{"旧タグ", "築炉", "豊田築炉"}, false},
+		{[]string{"新タグ"}, true},
+	}
+	for _, tt := range tests {
+		if err := s.ValidateKoujiTags(withTags(tt.tags...)); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateKoujiTags(%v) error = %v, wantErr %v", tt.tags, err, tt.wantErr)
+		}
+	}
+}
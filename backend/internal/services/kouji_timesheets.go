@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrTimesheetLocked は工事が工数表を編集できない状態の場合のエラー
+var ErrTimesheetLocked = errors.New("工事の状態により工数表を編集できません")
+
+// ErrTimesheetNotFound は指定したIDの工数表の行がない場合のエラー
+var ErrTimesheetNotFound = errors.New("工数表の行がありません")
+
+// TimesheetQuery は工数表の絞り込み条件（空のフィールドは条件として扱わない）
+type TimesheetQuery struct {
+	KoujiId    string
+	WorkerName string
+	// 期間（YYYY-MM-DD、両端を含む）
+	From, To string
+}
+
+// GetTimesheets は条件に一致する工数表の行を日付順に返す
+func (s *KoujiService) GetTimesheets(query TimesheetQuery) ([]models.TimesheetEntry, error) {
+	rows, err := s.loadTimesheets()
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]models.TimesheetEntry, 0)
+	for _, row := range rows {
+		if query.KoujiId != "" && row.KoujiId != query.KoujiId {
+			continue
+		}
+		if query.WorkerName != "" && !sameAssignee(row.WorkerName, query.WorkerName) {
+			continue
+		}
+		if (query.From != "" && row.Date < query.From) || (query.To != "" && row.Date > query.To) {
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	return filtered, nil
+}
+
+// SaveTimesheetEntry は工数表の行を登録する（同じ工事・日付・作業員の行があれば置き換える）
+// 作業員名簿の現在の時間単価を行に記録する。工事が編集できない状態の場合はErrTimesheetLockedを返す
+func (s *KoujiService) SaveTimesheetEntry(entry models.TimesheetEntry) (models.TimesheetEntry, error) {
+	entry.WorkerName = strings.TrimSpace(entry.WorkerName)
+	if entry.WorkerName == "" {
+		return models.TimesheetEntry{}, fmt.Errorf("作業員名が空です")
+	}
+	if _, err := time.Parse("2006-01-02", entry.Date); err != nil {
+		return models.TimesheetEntry{}, fmt.Errorf("日付はYYYY-MM-DDで指定してください: %s", entry.Date)
+	}
+	if entry.Hours <= 0 || entry.Hours > 24 {
+		return models.TimesheetEntry{}, fmt.Errorf("作業時間は0より大きく24時間以下で指定してください: %v", entry.Hours)
+	}
+
+	// 1日の合計の確認から保存までの間に他の行が登録されないようにする
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.checkTimesheetEditable(entry.KoujiId); err != nil {
+		return models.TimesheetEntry{}, err
+	}
+
+	rates, err := s.hourlyRates()
+	if err != nil {
+		return models.TimesheetEntry{}, err
+	}
+	entry.HourlyRate = rates[NormalizeTag(entry.WorkerName)]
+
+	rows, err := s.loadTimesheets()
+	if err != nil {
+		return models.TimesheetEntry{}, err
+	}
+	entry.Id = timesheetID(entry.KoujiId, entry.Date, entry.WorkerName)
+	dayTotal := entry.Hours
+	updated := make([]models.TimesheetEntry, 0, len(rows)+1)
+	for _, row := range rows {
+		if row.Id == entry.Id {
+			continue
+		}
+		if row.Date == entry.Date && sameAssignee(row.WorkerName, entry.WorkerName) {
+			dayTotal += row.Hours
+		}
+		updated = append(updated, row)
+	}
+	if dayTotal > 24 {
+		return models.TimesheetEntry{}, fmt.Errorf("%s の %s の作業時間の合計が24時間を超えます: %v", entry.WorkerName, entry.Date, dayTotal)
+	}
+	updated = append(updated, entry)
+	if err := s.saveTimesheets(updated); err != nil {
+		return models.TimesheetEntry{}, err
+	}
+	return entry, nil
+}
+
+// DeleteTimesheetEntry は工数表の行を削除する
+func (s *KoujiService) DeleteTimesheetEntry(id string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	rows, err := s.loadTimesheets()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(rows, func(row models.TimesheetEntry) bool { return row.Id == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrTimesheetNotFound, id)
+	}
+	if err := s.checkTimesheetEditable(rows[i].KoujiId); err != nil {
+		return err
+	}
+	return s.saveTimesheets(slices.Delete(rows, i, i+1))
+}
+
+// GetLabourCostSummary は工事の労務費を工数表の行に記録した時間単価で集計し、予算のカスタムフィールドと比較する
+// 作業時間は工数表を正とし、日報の作業時間とは照合して差異を返すのみとする
+func (s *KoujiService) GetLabourCostSummary(koujiId string) (*models.LabourCostSummary, error) {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.GetTimesheets(TimesheetQuery{KoujiId: koujiId})
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.hourlyRates()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.LabourCostSummary{
+		KoujiId:  koujiId,
+		ByWorker: make([]models.WorkerLabourCost, 0),
+		ByMonth:  make(map[string]models.LabourCostTotal),
+		Locked:   s.timesheetLocked(&entry),
+	}
+	byWorker := make(map[string]int)
+	for _, row := range rows {
+		rate := rowHourlyRate(row, rates)
+		cost := row.Hours * rate
+		summary.TotalHours += row.Hours
+		summary.TotalCost += cost
+
+		month := summary.ByMonth[row.Date[:7]]
+		month.Hours += row.Hours
+		month.Cost += cost
+		summary.ByMonth[row.Date[:7]] = month
+
+		i, ok := byWorker[NormalizeTag(row.WorkerName)]
+		if !ok {
+			i = len(summary.ByWorker)
+			byWorker[NormalizeTag(row.WorkerName)] = i
+			summary.ByWorker = append(summary.ByWorker, models.WorkerLabourCost{WorkerName: row.WorkerName})
+		}
+		summary.ByWorker[i].Hours += row.Hours
+		summary.ByWorker[i].Cost += cost
+	}
+	for i := range summary.ByWorker {
+		worker := &summary.ByWorker[i]
+		if worker.Cost > 0 {
+			worker.HourlyRate = worker.Cost / worker.Hours
+		} else {
+			summary.UnratedWorkers = append(summary.UnratedWorkers, worker.WorkerName)
+		}
+	}
+
+	reports, err := s.GetDailyReports(koujiId, "", "")
+	if err != nil {
+		return nil, err
+	}
+	summary.DailyReportHours, summary.HoursDiscrepancies = compareDailyReportHours(rows, reports)
+
+	field := s.GetSettings().LabourBudgetField
+	if value, ok := entry.CustomFields[field]; ok && field != "" {
+		if budget, err := toFloat(value); err == nil {
+			variance := budget - summary.TotalCost
+			summary.BudgetField = field
+			summary.Budget = &budget
+			summary.Variance = &variance
+			if budget > 0 {
+				ratio := summary.TotalCost / budget
+				summary.BudgetUsedRatio = &ratio
+			}
+		}
+	}
+	return summary, nil
+}
+
+// GetMonthlyLabourCosts は全工事の労務費を月ごとに集計する（期間はYYYY-MM、空の場合は制限なし）
+func (s *KoujiService) GetMonthlyLabourCosts(fromMonth, toMonth string) ([]models.MonthlyLabourCost, error) {
+	rows, err := s.loadTimesheets()
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.hourlyRates()
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth := make(map[string]*models.MonthlyLabourCost)
+	for _, row := range rows {
+		month := row.Date[:7]
+		if (fromMonth != "" && month < fromMonth) || (toMonth != "" && month > toMonth) {
+			continue
+		}
+		m, ok := byMonth[month]
+		if !ok {
+			m = &models.MonthlyLabourCost{Month: month, ByKouji: make(map[string]models.LabourCostTotal)}
+			byMonth[month] = m
+		}
+		cost := row.Hours * rowHourlyRate(row, rates)
+		m.Hours += row.Hours
+		m.Cost += cost
+		total := m.ByKouji[row.KoujiId]
+		total.Hours += row.Hours
+		total.Cost += cost
+		m.ByKouji[row.KoujiId] = total
+	}
+
+	months := make([]models.MonthlyLabourCost, 0, len(byMonth))
+	for _, m := range byMonth {
+		months = append(months, *m)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Month < months[j].Month })
+	return months, nil
+}
+
+// checkTimesheetEditable は工事があり、工数表を編集できる状態かを確認する
+func (s *KoujiService) checkTimesheetEditable(koujiId string) error {
+	entry, err := s.GetKoujiEntryByID(koujiId)
+	if err != nil {
+		return err
+	}
+	if s.timesheetLocked(&entry) {
+		return fmt.Errorf("%w: %s（%s）", ErrTimesheetLocked, koujiId, entry.Status)
+	}
+	return nil
+}
+
+func (s *KoujiService) timesheetLocked(entry *models.KoujiEntry) bool {
+	return slices.Contains(s.GetSettings().TimesheetLockedStatuses, entry.Status)
+}
+
+// rowHourlyRate は工数表の行の時間単価を返す
+// 単価を記録する前に登録された行（単価が0）は作業員名簿の現在の単価を使う
+func rowHourlyRate(row models.TimesheetEntry, rates map[string]float64) float64 {
+	if row.HourlyRate > 0 {
+		return row.HourlyRate
+	}
+	return rates[NormalizeTag(row.WorkerName)]
+}
+
+// compareDailyReportHours は日報の延べ作業時間と、日報がある日の工数表との差異を返す
+func compareDailyReportHours(rows []models.TimesheetEntry, reports []models.DailyReport) (float64, []models.HoursDiscrepancy) {
+	type key struct{ date, name string }
+	timesheet := make(map[key]float64)
+	names := make(map[key]string)
+	for _, row := range rows {
+		k := key{row.Date, NormalizeTag(row.WorkerName)}
+		timesheet[k] += row.Hours
+		names[k] = row.WorkerName
+	}
+
+	var total float64
+	var discrepancies []models.HoursDiscrepancy
+	for _, report := range reports {
+		total += report.ManHours
+		reported := make(map[key]float64)
+		var order []key
+		for _, worker := range report.Workers {
+			k := key{report.Date, NormalizeTag(worker.Name)}
+			if _, ok := reported[k]; !ok {
+				order = append(order, k)
+				names[k] = worker.Name
+			}
+			reported[k] += worker.Hours
+		}
+		// 工数表にのみある作業員も差異とする
+		for _, row := range rows {
+			k := key{row.Date, NormalizeTag(row.WorkerName)}
+			if _, ok := reported[k]; !ok && row.Date == report.Date {
+				reported[k] = 0
+				order = append(order, k)
+			}
+		}
+		for _, k := range order {
+			if timesheet[k] != reported[k] {
+				discrepancies = append(discrepancies, models.HoursDiscrepancy{
+					Date:             k.date,
+					WorkerName:       names[k],
+					TimesheetHours:   timesheet[k],
+					DailyReportHours: reported[k],
+				})
+			}
+		}
+	}
+	return total, discrepancies
+}
+
+// hourlyRates は作業員名（正規化済み）ごとの時間単価を返す
+func (s *KoujiService) hourlyRates() (map[string]float64, error) {
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(workers))
+	for _, worker := range workers {
+		if worker.HourlyRate > 0 {
+			rates[NormalizeTag(worker.Name)] = worker.HourlyRate
+		}
+	}
+	return rates, nil
+}
+
+func (s *KoujiService) loadTimesheets() ([]models.TimesheetEntry, error) {
+	rows := []models.TimesheetEntry{}
+	if err := loadYAMLFile(s.TimesheetsPath, &rows); err != nil {
+		return nil, fmt.Errorf("工数表を読み込めません: %w", err)
+	}
+	return rows, nil
+}
+
+// saveTimesheets は工数表を工事ID・日付・作業員名の順に並べて保存する
+func (s *KoujiService) saveTimesheets(rows []models.TimesheetEntry) error {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.KoujiId != b.KoujiId {
+			return a.KoujiId < b.KoujiId
+		}
+		return a.WorkerName < b.WorkerName
+	})
+	return saveYAMLFile(s.TimesheetsPath, rows)
+}
+
+// timesheetID は工事ID・日付・作業員名から工数表の行のIDを生成する
+func timesheetID(koujiId, date, workerName string) string {
+	return models.NewIDFromString(strings.Join([]string{koujiId, date, NormalizeTag(workerName)}, "\x00")).Len5()
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"sync"
+	"testing"
+)
+
+// newTestKoujiService は一時フォルダーに工事フォルダーを作成してKoujiServiceを返す
+func newTestKoujiService(t *testing.T, folders ...string) *KoujiService {
+	t.Helper()
+	root := t.TempDir()
+	for _, folder := range folders {
+		if err := os.MkdirAll(filepath.Join(root, "工事", folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsService, err := NewFileSystemService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewKoujiService(fsService, "工事")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLabourCostSummary(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	entries := s.GetKoujiEntries()
+	if len(entries) != 1 {
+		t.Fatalf("GetKoujiEntries() = %d entries, want 1", len(entries))
+	}
+	id := entries[0].Id
+	entries[0].CustomFields = map[string]any{"労務費予算": 100000.0}
+	if err := s.SaveKoujiEntries(entries); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateWorker(models.Worker{Name: "山田 太郎", HourlyRate: 3000}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range []models.TimesheetEntry{
+		{KoujiId: id, Date: "2099-06-18", WorkerName: "山田 太郎", Hours: 8},
+		{KoujiId: id, Date: "2099-07-01", WorkerName: "山田太郎", Hours: 10},
+		{KoujiId: id, Date: "2099-07-01", WorkerName: "鈴木 一郎", Hours: 8},
+		// 同じ工事・日付・作業員の行は置き換える
+		{KoujiId: id, Date: "2099-06-18", WorkerName: "山田 太郎", Hours: 6},
+	} {
+		if _, err := s.SaveTimesheetEntry(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := s.GetLabourCostSummary(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalHours != 24 || summary.TotalCost != 48000 {
+		t.Errorf("total = %vh %v円, want 24h 48000円", summary.TotalHours, summary.TotalCost)
+	}
+	if got := summary.ByMonth["2099-07"]; got.Hours != 18 || got.Cost != 30000 {
+		t.Errorf("ByMonth[2099-07] = %+v, want 18h 30000円", got)
+	}
+	if summary.Variance == nil || *summary.Variance != 52000 {
+		t.Errorf("Variance = %v, want 52000", summary.Variance)
+	}
+	if len(summary.UnratedWorkers) != 1 || summary.UnratedWorkers[0] != "鈴木 一郎" {
+		t.Errorf("UnratedWorkers = %v, want [鈴木 一郎]", summary.UnratedWorkers)
+	}
+
+	// 名簿の単価を変更しても登録済みの行の労務費は変わらない
+	if _, err := s.UpdateWorker("山田 太郎", models.Worker{Name: "山田 太郎", HourlyRate: 5000}); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err = s.GetLabourCostSummary(id); err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCost != 48000 {
+		t.Errorf("total after rate change = %v円, want 48000円", summary.TotalCost)
+	}
+	months, err := s.GetMonthlyLabourCosts("2099-06", "2099-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 1 || months[0].Cost != 18000 {
+		t.Errorf("GetMonthlyLabourCosts(2099-06) = %+v, want 18000円", months)
+	}
+
+	// 日報がある日は工数表と照合する
+	if _, err := s.CreateDailyReport(id, models.DailyReport{Date: "2099-07-01", Workers: []models.DailyReportWorker{
+		{Name: "山田太郎", Hours: 10},
+		{Name: "鈴木 一郎", Hours: 6},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err = s.GetLabourCostSummary(id); err != nil {
+		t.Fatal(err)
+	}
+	if summary.DailyReportHours != 16 || len(summary.HoursDiscrepancies) != 1 {
+		t.Fatalf("daily report hours = %v, discrepancies = %+v; want 16 and one", summary.DailyReportHours, summary.HoursDiscrepancies)
+	}
+	if d := summary.HoursDiscrepancies[0]; d.WorkerName != "鈴木 一郎" || d.TimesheetHours != 8 || d.DailyReportHours != 6 {
+		t.Errorf("discrepancy = %+v, want 鈴木 一郎 8h/6h", d)
+	}
+
+	settings := s.GetSettings()
+	settings.TimesheetLockedStatuses = []string{"予定"}
+	if err := s.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.SaveTimesheetEntry(models.TimesheetEntry{KoujiId: id, Date: "2099-07-02", WorkerName: "山田 太郎", Hours: 8})
+	if !errors.Is(err, ErrTimesheetLocked) {
+		t.Errorf("SaveTimesheetEntry on a locked kouji: error = %v, want ErrTimesheetLocked", err)
+	}
+}
+
+func TestTimesheetConcurrentSaves(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場")
+	id := s.GetKoujiEntries()[0].Id
+
+	// 同時に登録しても、どの行も失われない
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := s.SaveTimesheetEntry(models.TimesheetEntry{KoujiId: id, Date: "2099-06-18", WorkerName: fmt.Sprintf("作業員%02d", i), Hours: 8})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rows, err := s.GetTimesheets(TimesheetQuery{KoujiId: id}); err != nil || len(rows) != n {
+		t.Errorf("timesheets after concurrent saves = %d, %v; want %d", len(rows), err, n)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"penguin-backend/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrWorkerNotFound は作業員が登録されていない場合のエラー
+var ErrWorkerNotFound = errors.New("作業員が登録されていません")
+
+// GetWorkers は登録されている作業員の一覧を返す
+func (s *KoujiService) GetWorkers() ([]models.Worker, error) {
+	workers := []models.Worker{}
+	if err := loadYAMLFile(s.WorkersPath, &workers); err != nil {
+		return nil, fmt.Errorf("作業員の一覧を読み込めません: %w", err)
+	}
+	return workers, nil
+}
+
+// GetWorker は名前で作業員を検索する（空白・全角半角の違いは無視する）
+func (s *KoujiService) GetWorker(name string) (models.Worker, error) {
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return models.Worker{}, err
+	}
+	if i := findWorker(workers, name); i >= 0 {
+		return workers[i], nil
+	}
+	return models.Worker{}, fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+}
+
+// CreateWorker は作業員を登録する
+func (s *KoujiService) CreateWorker(worker models.Worker) (models.Worker, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return models.Worker{}, err
+	}
+	workers = append(workers, worker)
+	if err := s.saveWorkers(workers); err != nil {
+		return models.Worker{}, err
+	}
+	return workers[len(workers)-1], nil
+}
+
+// UpdateWorker は名前で指定した作業員を更新する
+func (s *KoujiService) UpdateWorker(name string, worker models.Worker) (models.Worker, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return models.Worker{}, err
+	}
+	i := findWorker(workers, name)
+	if i < 0 {
+		return models.Worker{}, fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+	workers[i] = worker
+	if err := s.saveWorkers(workers); err != nil {
+		return models.Worker{}, err
+	}
+	return workers[i], nil
+}
+
+// DeleteWorker は名前で指定した作業員を削除する（工事の割り当ては変更しない）
+func (s *KoujiService) DeleteWorker(name string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return err
+	}
+	i := findWorker(workers, name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrWorkerNotFound, name)
+	}
+	return s.saveWorkers(append(workers[:i], workers[i+1:]...))
+}
+
+// GetQualificationExpiries は有効期限が今日からdays日以内の資格・安全書類を期限の昇順で返す
+// 既に期限が切れているものも含める
+func (s *KoujiService) GetQualificationExpiries(days int) ([]models.QualificationExpiry, error) {
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	limit := truncateDay(now).AddDate(0, 0, days)
+	expiries := make([]models.QualificationExpiry, 0)
+	for _, worker := range workers {
+		for _, q := range worker.Qualifications {
+			if q.ExpiresAt.Time.IsZero() || q.ExpiresAt.Time.After(limit) {
+				continue
+			}
+			expiries = append(expiries, models.QualificationExpiry{
+				WorkerName:    worker.Name,
+				Qualification: q.Name,
+				Category:      q.Category,
+				ExpiresAt:     q.ExpiresAt,
+				DaysLeft:      daysBetween(now, q.ExpiresAt.Time),
+			})
+		}
+	}
+	sort.SliceStable(expiries, func(i, j int) bool {
+		return expiries[i].ExpiresAt.Time.Before(expiries[j].ExpiresAt.Time)
+	})
+	return expiries, nil
+}
+
+// CheckKoujiQualifications は工事に割り当てた作業員の資格・安全書類を確認する
+// koujiIdが空の場合は完了していないすべての工事を確認する
+func (s *KoujiService) CheckKoujiQualifications(koujiId string) ([]models.QualificationIssue, error) {
+	workers, err := s.GetWorkers()
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]models.QualificationIssue, 0)
+	found := koujiId == ""
+	for _, entry := range s.GetKoujiEntries() {
+		if koujiId != "" && entry.Id != koujiId {
+			continue
+		}
+		if koujiId == "" && entry.Status == "完了" {
+			continue
+		}
+		found = true
+		for _, a := range entry.Assignments {
+			issues = append(issues, qualificationIssues(&entry, a, workers)...)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrKoujiNotFound, koujiId)
+	}
+	return issues, nil
+}
+
+// qualificationIssues は作業員の割り当てについて、割り当ての終了日より前に期限が切れる資格・安全書類を返す
+// 作業員が登録されていない場合もその旨を返す（協力会社・機材の割り当ては確認しない）
+func qualificationIssues(entry *models.KoujiEntry, a models.KoujiAssignment, workers []models.Worker) []models.QualificationIssue {
+	if a.Kind != "" && a.Kind != models.AssignmentKindWorker {
+		return nil
+	}
+	_, to := AssignmentPeriod(entry, a)
+	i := findWorker(workers, a.Name)
+	if i < 0 {
+		return []models.QualificationIssue{{
+			KoujiId:      entry.Id,
+			WorkerName:   a.Name,
+			AssignmentTo: models.NewTimestamp(to),
+			Message:      fmt.Sprintf("%s は作業員として登録されていません", a.Name),
+		}}
+	}
+
+	var issues []models.QualificationIssue
+	for _, q := range workers[i].Qualifications {
+		if q.ExpiresAt.Time.IsZero() || !truncateDay(q.ExpiresAt.Time).Before(truncateDay(to)) {
+			continue
+		}
+		issues = append(issues, models.QualificationIssue{
+			KoujiId:       entry.Id,
+			WorkerName:    workers[i].Name,
+			Qualification: q.Name,
+			ExpiresAt:     q.ExpiresAt,
+			AssignmentTo:  models.NewTimestamp(to),
+			Message:       fmt.Sprintf("%s の %s の有効期限（%s）が割り当ての終了日より前です", workers[i].Name, q.Name, formatImportDate(q.ExpiresAt)),
+		})
+	}
+	return issues
+}
+
+// saveWorkers は作業員の一覧を検証して保存する
+func (s *KoujiService) saveWorkers(workers []models.Worker) error {
+	seen := make(map[string]bool, len(workers))
+	for i := range workers {
+		worker := &workers[i]
+		worker.Name = strings.TrimSpace(worker.Name)
+		if worker.Name == "" {
+			return fmt.Errorf("作業員名が空です")
+		}
+		key := NormalizeTag(worker.Name)
+		if seen[key] {
+			return fmt.Errorf("作業員が重複しています: %s", worker.Name)
+		}
+		seen[key] = true
+		if worker.HourlyRate < 0 {
+			return fmt.Errorf("%s: 時間単価が負の値です", worker.Name)
+		}
+		for _, q := range worker.Qualifications {
+			if strings.TrimSpace(q.Name) == "" {
+				return fmt.Errorf("%s: 資格・書類の名前が空です", worker.Name)
+			}
+			if !q.IssuedAt.Time.IsZero() && !q.ExpiresAt.Time.IsZero() && q.ExpiresAt.Time.Before(q.IssuedAt.Time) {
+				return fmt.Errorf("%s: %s の有効期限が取得日より前です", worker.Name, q.Name)
+			}
+		}
+	}
+	return saveYAMLFile(s.WorkersPath, workers)
+}
+
+// findWorker は名前が一致する作業員の位置を返す（見つからない場合は-1）
+func findWorker(workers []models.Worker, name string) int {
+	for i, worker := range workers {
+		if sameAssignee(worker.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"penguin-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestQualificationIssues(t *testing.T) {
+	date := func(y, m, d int) models.Timestamp {
+		return models.NewTimestamp(time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local))
+	}
+	workers := []models.Worker{
+		{Name: "山田 太郎", Qualifications: []models.WorkerQualification{
+			{Name: "健康診断", Category: "健康診断", ExpiresAt: date(2030, 5, 15)},
+			{Name: "酸素欠乏危険作業", Category: "技能講習"},
+			{Name: "粉じん作業", Category: "特別教育", ExpiresAt: date(2030, 5, 31)},
+		}},
+	}
+	entry := &models.KoujiEntry{Id: "A", StartDate: date(2030, 5, 1), EndDate: date(2030, 5, 31)}
+
+	tests := []struct {
+		assignment models.KoujiAssignment
+		want       int
+	}{
+		// 工事の終了日（5/31）より前に健康診断が切れる
+		{models.KoujiAssignment{Name: "山田太郎", Kind: models.AssignmentKindWorker}, 1},
+		// 割り当ての終了日（5/10）までは有効
+		{models.KoujiAssignment{Name: "山田 太郎", To: date(2030, 5, 10)}, 0},
+		// 登録されていない作業員
+		{models.KoujiAssignment{Name: "鈴木 一郎", Kind: models.AssignmentKindWorker}, 1},
+		// 機材は確認しない
+		{models.KoujiAssignment{Name: "クレーン", Kind: models.AssignmentKindEquipment}, 0},
+	}
+	for _, tt := range tests {
+		if got := qualificationIssues(entry, tt.assignment, workers); len(got) != tt.want {
+			t.Errorf("qualificationIssues(%s) = %+v, want %d issues", tt.assignment.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/models"
+	"penguin-backend/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPhotoHashJobRunning は写真のハッシュの計算が既に実行中の場合のエラー
+var ErrPhotoHashJobRunning = errors.New("写真のハッシュの計算は実行中です")
+
+// DefaultPhotoDuplicateThreshold は重複とみなすハッシュの距離の既定値
+const DefaultPhotoDuplicateThreshold = 5
+
+// 途中経過を保存する間隔（新たにハッシュを計算した画像の数）
+const photoHashSaveInterval = 100
+
+// photoHashStore は写真の知覚ハッシュの保存形式
+type photoHashStore struct {
+	// 最後にハッシュの計算が完了した日時
+	ScannedAt models.Timestamp `yaml:"scanned_at"`
+	// 工事フォルダーのルートからの相対パスごとのハッシュ
+	Photos map[string]photoHashRecord `yaml:"photos"`
+}
+
+// photoHashRecord は1枚の画像のハッシュと、計算したときのサイズ・更新日時を表す
+type photoHashRecord struct {
+	Size int64 `yaml:"size"`
+	// 更新日時（UNIX時刻のナノ秒、比較で誤差が出ないように数値で保存する）
+	ModTime int64 `yaml:"mod_time"`
+	// 16進数の知覚ハッシュ（読み込めなかった画像は空）
+	Hash string `yaml:"hash"`
+	// 画像がある工事のID（工事フォルダーの外の場合は空）
+	KoujiId string `yaml:"kouji_id,omitempty"`
+}
+
+func (r photoHashRecord) matches(info fs.FileInfo) bool {
+	return r.Size == info.Size() && r.ModTime == info.ModTime().UnixNano()
+}
+
+// StartPhotoHashJob は工事フォルダーのルート配下の画像の知覚ハッシュをバックグラウンドで計算する
+// 前回から変わっていない画像は計算せず、削除された画像はハッシュの一覧から外す
+func (s *KoujiService) StartPhotoHashJob() (models.PhotoHashJobStatus, error) {
+	s.photoHashMu.Lock()
+	defer s.photoHashMu.Unlock()
+	if s.photoHashJob.Running {
+		return s.photoHashJob, ErrPhotoHashJobRunning
+	}
+	s.photoHashJob = models.PhotoHashJobStatus{Running: true, StartedAt: models.NewTimestamp(time.Now())}
+	go func() {
+		err := s.runPhotoHashJob()
+		s.updatePhotoHashJob(func(job *models.PhotoHashJobStatus) {
+			job.Running = false
+			job.FinishedAt = models.NewTimestamp(time.Now())
+			if err != nil {
+				job.Error = err.Error()
+			}
+		})
+		if err != nil {
+			log.Printf("写真のハッシュの計算に失敗しました: %v", err)
+		}
+	}()
+	return s.photoHashJob, nil
+}
+
+// GetPhotoHashJobStatus は写真のハッシュの計算の状況を返す
+func (s *KoujiService) GetPhotoHashJobStatus() models.PhotoHashJobStatus {
+	s.photoHashMu.Lock()
+	defer s.photoHashMu.Unlock()
+	return s.photoHashJob
+}
+
+func (s *KoujiService) updatePhotoHashJob(update func(job *models.PhotoHashJobStatus)) {
+	s.photoHashMu.Lock()
+	defer s.photoHashMu.Unlock()
+	update(&s.photoHashJob)
+}
+
+// runPhotoHashJob は画像を集めてハッシュを計算し、途中経過と結果を保存する
+func (s *KoujiService) runPhotoHashJob() error {
+	store := photoHashStore{}
+	if err := loadYAMLFile(s.PhotoHashesPath, &store); err != nil {
+		return err
+	}
+
+	type target struct {
+		rel  string
+		path string
+		info fs.FileInfo
+	}
+	var targets []target
+	err := filepath.WalkDir(s.FileSystemPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == s.FileSystemPath {
+				return err
+			}
+			return nil
+		}
+		// 隠しフォルダー（サムネイルのキャッシュなど）と隠しファイルは対象外
+		if strings.HasPrefix(d.Name(), ".") && path != s.FileSystemPath {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !thumbnailExtensions[strings.ToLower(filepath.Ext(d.Name()))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(s.FileSystemPath, path)
+		if err != nil {
+			return nil
+		}
+		targets = append(targets, target{filepath.ToSlash(rel), path, info})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.updatePhotoHashJob(func(job *models.PhotoHashJobStatus) { job.Total = len(targets) })
+
+	// 工事フォルダーのパスから工事IDを引く
+	koujiIds := make(map[string]string)
+	for _, entry := range s.GetKoujiEntries() {
+		koujiIds[entry.Path] = entry.Id
+	}
+
+	next := photoHashStore{ScannedAt: store.ScannedAt, Photos: make(map[string]photoHashRecord, len(targets))}
+	hashed := 0
+	for _, t := range targets {
+		record, ok := store.Photos[t.rel]
+		computed := !ok || !record.matches(t.info)
+		if computed {
+			record = photoHashRecord{Size: t.info.Size(), ModTime: t.info.ModTime().UnixNano()}
+			if hash, err := photoHash(t.path); err == nil {
+				record.Hash = fmt.Sprintf("%016x", hash)
+			}
+			hashed++
+		}
+		record.KoujiId = ""
+		if folder, _, ok := strings.Cut(t.rel, "/"); ok {
+			record.KoujiId = koujiIds[filepath.Join(s.FileSystemPath, folder)]
+		}
+		next.Photos[t.rel] = record
+		s.updatePhotoHashJob(func(job *models.PhotoHashJobStatus) {
+			job.Processed++
+			if computed {
+				job.Hashed++
+			}
+			if record.Hash == "" {
+				job.Failed++
+			}
+		})
+		if computed && hashed%photoHashSaveInterval == 0 {
+			// 途中で止まっても計算済みのハッシュを使えるように、前回の結果と合わせて保存する
+			progress := photoHashStore{ScannedAt: store.ScannedAt, Photos: make(map[string]photoHashRecord, len(store.Photos))}
+			for rel, r := range store.Photos {
+				progress.Photos[rel] = r
+			}
+			for rel, r := range next.Photos {
+				progress.Photos[rel] = r
+			}
+			if err := saveYAMLFile(s.PhotoHashesPath, progress); err != nil {
+				return err
+			}
+		}
+	}
+	next.ScannedAt = models.NewTimestamp(time.Now())
+	if err := saveYAMLFile(s.PhotoHashesPath, next); err != nil {
+		return err
+	}
+
+	// 既定の距離のグループはここで求めておき、一覧の取得では計算しない
+	index := newPhotoDuplicateIndex(s.FileSystemPath, next)
+	index.clusters[DefaultPhotoDuplicateThreshold] = index.computeClusters(DefaultPhotoDuplicateThreshold)
+	s.photoHashMu.Lock()
+	s.photoDuplicates = index
+	s.photoHashMu.Unlock()
+	return nil
+}
+
+// photoHash は画像を読み込み、EXIFの向きを反映した知覚ハッシュを返す
+func photoHash(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		return 0, err
+	}
+	img = utils.ScaleToFit(img, 64)
+	if exif, err := utils.ReadEXIF(bytes.NewReader(data), time.Local); err == nil {
+		img = utils.ApplyOrientation(img, exif.Orientation)
+	}
+	return utils.DifferenceHash(img), nil
+}
+
+// photoDuplicateIndex は最後に計算したハッシュを、大きい写真から順に並べたもの
+type photoDuplicateIndex struct {
+	scannedAt models.Timestamp
+	photos    []models.PhotoDuplicate
+	hashes    []uint64
+	// 距離の上限ごとのグループ（求めた距離のみ、photoHashMuで保護する）
+	// photosとhashesは作成後に変更しないため、ロックの外で読んでよい
+	clusters map[int][]models.PhotoDuplicateCluster
+}
+
+// newPhotoDuplicateIndex は保存したハッシュから写真の一覧を作る（ファイルは読まない）
+func newPhotoDuplicateIndex(root string, store photoHashStore) *photoDuplicateIndex {
+	index := &photoDuplicateIndex{scannedAt: store.ScannedAt, clusters: make(map[int][]models.PhotoDuplicateCluster)}
+	type hashedPhoto struct {
+		photo models.PhotoDuplicate
+		hash  uint64
+	}
+	var photos []hashedPhoto
+	for rel, record := range store.Photos {
+		hash, err := strconv.ParseUint(record.Hash, 16, 64)
+		if err != nil {
+			continue
+		}
+		photos = append(photos, hashedPhoto{models.PhotoDuplicate{
+			Path:         filepath.Join(root, filepath.FromSlash(rel)),
+			Size:         record.Size,
+			ModifiedTime: models.NewTimestamp(time.Unix(0, record.ModTime)),
+			KoujiId:      record.KoujiId,
+		}, hash})
+	}
+	// 大きい写真（元の写真の可能性が高い）を先に並べ、グループの代表にする
+	sort.Slice(photos, func(i, j int) bool {
+		if photos[i].photo.Size != photos[j].photo.Size {
+			return photos[i].photo.Size > photos[j].photo.Size
+		}
+		return photos[i].photo.Path < photos[j].photo.Path
+	})
+	for _, p := range photos {
+		index.photos = append(index.photos, p.photo)
+		index.hashes = append(index.hashes, p.hash)
+	}
+	return index
+}
+
+// computeClusters は距離がthreshold以下の写真のグループを求める
+// まだグループに入っていない最大の写真を代表とし、代表との距離がthreshold以下の写真だけを同じグループにする
+// 似た写真を順にたどって別の場面の写真までつながらないように、代表以外の写真同士の距離ではまとめない
+func (index *photoDuplicateIndex) computeClusters(threshold int) []models.PhotoDuplicateCluster {
+	clusters := []models.PhotoDuplicateCluster{}
+	assigned := make([]bool, len(index.photos))
+	for i := range index.photos {
+		if assigned[i] {
+			continue
+		}
+		first := index.hashes[i]
+		cluster := models.PhotoDuplicateCluster{Hash: fmt.Sprintf("%016x", first)}
+		for j := i; j < len(index.photos); j++ {
+			if assigned[j] {
+				continue
+			}
+			distance := utils.HammingDistance(first, index.hashes[j])
+			if distance > threshold {
+				continue
+			}
+			assigned[j] = true
+			photo := index.photos[j]
+			photo.Distance = distance
+			cluster.Photos = append(cluster.Photos, photo)
+		}
+		if len(cluster.Photos) >= 2 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
+}
+
+// GetPhotoDuplicates は最後に計算したハッシュから、代表の写真との距離がthreshold以下で見た目が重複する写真のグループを返す
+// グループは計算の完了時（既定以外の距離は最初の取得時）に求めておき、ここでは各グループの写真が計算後に変わっていないかだけを確かめる
+// 計算後に変更・削除された画像は次の計算まで対象外とし、代表の写真が変わったグループは返さない
+func (s *KoujiService) GetPhotoDuplicates(threshold int) (*models.PhotoDuplicatesResponse, error) {
+	if threshold < 0 || threshold > 16 {
+		return nil, fmt.Errorf("重複とみなす距離は0〜16で指定してください: %d", threshold)
+	}
+	s.photoHashMu.Lock()
+	index := s.photoDuplicates
+	var clusters []models.PhotoDuplicateCluster
+	cached := false
+	if index != nil {
+		clusters, cached = index.clusters[threshold]
+	}
+	s.photoHashMu.Unlock()
+
+	if index == nil {
+		// 起動後に計算していない場合は保存したハッシュを使う
+		store := photoHashStore{}
+		if err := loadYAMLFile(s.PhotoHashesPath, &store); err != nil {
+			return nil, err
+		}
+		index = newPhotoDuplicateIndex(s.FileSystemPath, store)
+	}
+	if !cached {
+		// グループの計算は写真の数の2乗に比例するため、ロックの外で行い計算の状況の取得などを待たせない
+		clusters = index.computeClusters(threshold)
+		s.photoHashMu.Lock()
+		if s.photoDuplicates == nil {
+			s.photoDuplicates = index
+		}
+		// 計算中に新しいハッシュに置き換わった場合は古い結果を残さない
+		if s.photoDuplicates == index {
+			index.clusters[threshold] = clusters
+		}
+		s.photoHashMu.Unlock()
+	}
+
+	response := &models.PhotoDuplicatesResponse{Threshold: threshold, ScannedAt: index.scannedAt, Clusters: []models.PhotoDuplicateCluster{}}
+	for _, cached := range clusters {
+		cluster := models.PhotoDuplicateCluster{Hash: cached.Hash}
+		for i, photo := range cached.Photos {
+			info, err := os.Stat(photo.Path)
+			if err != nil || info.Size() != photo.Size || !info.ModTime().Equal(photo.ModifiedTime.Time) {
+				if i == 0 {
+					break
+				}
+				continue
+			}
+			cluster.Photos = append(cluster.Photos, photo)
+			cluster.TotalSize += photo.Size
+		}
+		if len(cluster.Photos) < 2 {
+			continue
+		}
+		cluster.ReclaimableSize = cluster.TotalSize - cluster.Photos[0].Size
+		response.Clusters = append(response.Clusters, cluster)
+		response.ReclaimableSize += cluster.ReclaimableSize
+	}
+	sort.Slice(response.Clusters, func(i, j int) bool {
+		ci, cj := response.Clusters[i], response.Clusters[j]
+		if ci.ReclaimableSize != cj.ReclaimableSize {
+			return ci.ReclaimableSize > cj.ReclaimableSize
+		}
+		return ci.Photos[0].Path < cj.Photos[0].Path
+	})
+	response.Count = len(response.Clusters)
+	return response, nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitPhotoHashJob はバックグラウンドの計算が終わるまで待つ
+func waitPhotoHashJob(t *testing.T, s *KoujiService) {
+	t.Helper()
+	if _, err := s.StartPhotoHashJob(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for s.GetPhotoHashJobStatus().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("photo hash job did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := s.GetPhotoHashJobStatus(); status.Error != "" {
+		t.Fatal(status.Error)
+	}
+}
+
+func encodeTestPhoto(t *testing.T, img image.Image, quality int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPhotoDuplicates(t *testing.T) {
+	s := newTestKoujiService(t, "2099-06-18 豊田築炉 名和工場", "2099-07-01 豊田築炉 碧南工場")
+	entries := s.GetKoujiEntries()
+
+	// 左右で明暗が変わる縞模様の写真と、それを縮小した写真、明暗が逆の別の写真
+	photo := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	other := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			v := uint8((x*7 + y*3) % 256)
+			if (x/40+y/60)%2 == 0 {
+				v = 255 - v
+			}
+			photo.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+			other.Set(x, y, color.RGBA{R: 255 - v, G: 255 - v, B: 255 - v, A: 255})
+		}
+	}
+	original := encodeTestPhoto(t, photo, 90)
+	files := map[string][]byte{
+		filepath.Join(entries[0].Path, "IMG_0001.JPG"):       original,
+		filepath.Join(entries[1].Path, "写真", "IMG_0001.JPG"): original,
+		filepath.Join(s.FileSystemPath, "縮小.jpg"):            encodeTestPhoto(t, utils.ResizeToFit(photo, 160), 60),
+		filepath.Join(entries[0].Path, "IMG_0002.JPG"):       encodeTestPhoto(t, other, 90),
+		// 隠しフォルダーの画像は対象外
+		filepath.Join(s.FileSystemPath, ".thumbnails", "IMG_0001.jpg"): original,
+	}
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitPhotoHashJob(t, s)
+	if status := s.GetPhotoHashJobStatus(); status.Total != 4 || status.Hashed != 4 || status.Failed != 0 {
+		t.Errorf("job status = %+v", status)
+	}
+	result, err := s.GetPhotoDuplicates(DefaultPhotoDuplicateThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || len(result.Clusters[0].Photos) != 3 {
+		t.Fatalf("duplicates = %+v", result)
+	}
+	cluster := result.Clusters[0]
+	koujiIds := map[string]bool{}
+	for _, p := range cluster.Photos {
+		koujiIds[p.KoujiId] = true
+		if filepath.Base(p.Path) == "IMG_0002.JPG" {
+			t.Errorf("different photo %s is in the cluster", p.Path)
+		}
+	}
+	if !koujiIds[entries[0].Id] || !koujiIds[entries[1].Id] || !koujiIds[""] {
+		t.Errorf("kouji ids in cluster = %v", koujiIds)
+	}
+	if cluster.ReclaimableSize != cluster.TotalSize-cluster.Photos[0].Size || cluster.Photos[0].Size != int64(len(original)) {
+		t.Errorf("cluster sizes = %+v", cluster)
+	}
+
+	// 計算後に変更した画像は次の計算まで対象外にする
+	shrunk := filepath.Join(s.FileSystemPath, "縮小.jpg")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(shrunk, later, later); err != nil {
+		t.Fatal(err)
+	}
+	result, err = s.GetPhotoDuplicates(DefaultPhotoDuplicateThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || len(result.Clusters[0].Photos) != 2 {
+		t.Errorf("duplicates after change = %+v", result)
+	}
+
+	// 変更した画像だけを再計算し、削除した画像は一覧から外す
+	if err := os.Remove(filepath.Join(entries[1].Path, "写真", "IMG_0001.JPG")); err != nil {
+		t.Fatal(err)
+	}
+	waitPhotoHashJob(t, s)
+	if status := s.GetPhotoHashJobStatus(); status.Total != 3 || status.Hashed != 1 {
+		t.Errorf("second job status = %+v", status)
+	}
+	result, err = s.GetPhotoDuplicates(DefaultPhotoDuplicateThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || len(result.Clusters[0].Photos) != 2 {
+		t.Errorf("duplicates after removal = %+v", result)
+	}
+
+	if _, err := s.GetPhotoDuplicates(20); err == nil {
+		t.Error("threshold 20 was accepted")
+	}
+}
+
+func TestPhotoDuplicateClustersDoNotChain(t *testing.T) {
+	// B は A から距離3、C は B から距離3だが A からは距離6
+	store := photoHashStore{Photos: map[string]photoHashRecord{
+		"A.jpg": {Size: 300, Hash: "0000000000000000"},
+		"B.jpg": {Size: 200, Hash: "0000000000000007"},
+		"C.jpg": {Size: 100, Hash: "000000000000003f"},
+		"D.jpg": {Size: 50, Hash: "000000000000003f"},
+	}}
+	index := newPhotoDuplicateIndex("/root", store)
+	clusters := index.computeClusters(DefaultPhotoDuplicateThreshold)
+	if len(clusters) != 2 {
+		t.Fatalf("clusters = %+v, want {A B} and {C D}", clusters)
+	}
+	for i, want := range [][]string{{"A.jpg", "B.jpg"}, {"C.jpg", "D.jpg"}} {
+		var got []string
+		for _, p := range clusters[i].Photos {
+			got = append(got, filepath.Base(p.Path))
+			if p.Distance > DefaultPhotoDuplicateThreshold {
+				t.Errorf("%s is %d away from the first photo", p.Path, p.Distance)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("cluster %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestPhotoHashTooLarge(t *testing.T) {
+	// 巨大な大きさを宣言した画像は展開せずにエラーにする
+	path := filepath.Join(t.TempDir(), "huge.png")
+	if err := os.WriteFile(path, hugePNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := photoHash(path); !errors.Is(err, utils.ErrImageTooLarge) {
+		t.Errorf("photoHash(huge.png) error = %v, want ErrImageTooLarge", err)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/utils"
+	"sort"
+	"strings"
+	"time"
+
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrInvalidThumbnailSize = errors.New("サムネイルの大きさが不正です")
+	ErrUnsupportedImage     = errors.New("JPEG・PNG・WebP以外の画像には対応していません")
+	ErrOutsideRoot          = errors.New("ルートフォルダーの外のファイルは指定できません")
+)
+
+// ThumbnailSizes は標準のサムネイルの大きさ（長辺の画素数）
+var ThumbnailSizes = map[string]int{"small": 160, "medium": 320, "large": 640}
+
+// thumbnailExtensions はサムネイルを作成できる画像の拡張子
+var thumbnailExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+const (
+	// thumbnailCacheDir はサムネイルのキャッシュを置くフォルダー（ルートからの相対パス）
+	thumbnailCacheDir = ".thumbnails"
+	// DefaultThumbnailCacheSize はサムネイルのキャッシュの合計サイズの既定の上限
+	DefaultThumbnailCacheSize int64 = 256 << 20
+)
+
+// Thumbnail はサムネイルの画像を表す
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	// 元の画像の更新日時
+	ModTime time.Time
+}
+
+// GetThumbnail は画像のサムネイルを返す。EXIFの向きを反映し、透過のある画像はPNG、それ以外はJPEGにする
+// 作成したサムネイルはパスと更新日時をキーにキャッシュし、合計サイズが上限を超えたら古く使われたものから削除する
+func (s *FileSystemService) GetThumbnail(fsPath, size string) (*Thumbnail, error) {
+	maxSide, ok := ThumbnailSizes[size]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidThumbnailSize, size)
+	}
+	if !thumbnailExtensions[strings.ToLower(filepath.Ext(fsPath))] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, filepath.Base(fsPath))
+	}
+	path, err := s.resolvePath(fsPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, filepath.Base(path))
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%s", path, info.ModTime().UnixNano(), info.Size(), size)))
+	key := hex.EncodeToString(sum[:])
+	cacheBase := filepath.Join(s.Root, thumbnailCacheDir, key[:2], key)
+	for ext, contentType := range map[string]string{".jpg": "image/jpeg", ".png": "image/png"} {
+		if data, err := os.ReadFile(cacheBase + ext); err == nil {
+			// 最後に使った日時を更新日時に記録する（削除は古いものから）
+			now := time.Now()
+			os.Chtimes(cacheBase+ext, now, now)
+			return &Thumbnail{Data: data, ContentType: contentType, ModTime: info.ModTime()}, nil
+		}
+	}
+
+	thumb, err := createThumbnail(path, maxSide)
+	if err != nil {
+		return nil, err
+	}
+	thumb.ModTime = info.ModTime()
+	ext := ".jpg"
+	if thumb.ContentType == "image/png" {
+		ext = ".png"
+	}
+	// キャッシュに保存できなくてもサムネイルは返す
+	if err := os.MkdirAll(filepath.Dir(cacheBase), 0755); err == nil {
+		if err := writeFileAtomic(cacheBase+ext, thumb.Data, 0644); err == nil {
+			s.evictThumbnails(cacheBase+ext, int64(len(thumb.Data)))
+		}
+	}
+	return thumb, nil
+}
+
+// createThumbnail は画像を読み込み、向きを補正して縮小したサムネイルを作る
+func createThumbnail(path string, maxSide int) (*Thumbnail, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnsupportedImage, filepath.Base(path), err)
+	}
+	img = utils.ScaleToFit(img, maxSide)
+	if exif, err := utils.ReadEXIF(bytes.NewReader(data), time.Local); err == nil {
+		img = utils.ApplyOrientation(img, exif.Orientation)
+	}
+
+	var buf bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return &Thumbnail{Data: buf.Bytes(), ContentType: "image/png"}, nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return &Thumbnail{Data: buf.Bytes(), ContentType: "image/jpeg"}, nil
+}
+
+// thumbnailEvictRatio は上限を超えたときに削除して合計サイズを下げる割合（上限の直下で毎回削除しないように余裕を持たせる）
+const thumbnailEvictRatio = 10
+
+// evictThumbnails は保存したサムネイル（keep、sizeバイト）をキャッシュの合計サイズに加え、上限を超えたら最後に使った日時の古いものから削除する
+// フォルダーを調べるのは最初の保存時と上限を超えたときだけで、作成したばかりのサムネイルは上限を超えていても残す
+func (s *FileSystemService) evictThumbnails(keep string, size int64) {
+	s.thumbnailMu.Lock()
+	defer s.thumbnailMu.Unlock()
+
+	limit := s.ThumbnailCacheSize
+	if limit <= 0 {
+		limit = DefaultThumbnailCacheSize
+	}
+	if !s.thumbnailCacheCounted {
+		// 最初の保存ではkeepも含めて数える
+		_, s.thumbnailCacheTotal = s.cachedThumbnails()
+		s.thumbnailCacheCounted = true
+	} else {
+		s.thumbnailCacheTotal += size
+	}
+	if s.thumbnailCacheTotal <= limit {
+		return
+	}
+
+	files, total := s.cachedThumbnails()
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	target := limit - limit/thumbnailEvictRatio
+	for _, f := range files {
+		if total <= target {
+			break
+		}
+		if f.path == keep {
+			continue
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	s.thumbnailCacheTotal = total
+}
+
+// cachedThumbnail はキャッシュにあるサムネイルのファイルを表す
+type cachedThumbnail struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// cachedThumbnails はキャッシュにあるサムネイルと合計サイズを返す（書き込み中の一時ファイルは対象外）
+func (s *FileSystemService) cachedThumbnails() ([]cachedThumbnail, int64) {
+	var files []cachedThumbnail
+	var total int64
+	filepath.WalkDir(filepath.Join(s.Root, thumbnailCacheDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cachedThumbnail{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	return files, total
+}
+
+// resolvePath はルートからの相対パス（またはルート配下の絶対パス）を絶対パスにし、ルートの外を指す場合はエラーにする
+// シンボリックリンクは実体のパスに解決してから確かめるため、ルート配下のリンクからルートの外は読めない
+func (s *FileSystemService) resolvePath(fsPath string) (string, error) {
+	if fsPath != s.Root && !strings.HasPrefix(fsPath, s.Root+string(filepath.Separator)) {
+		fsPath = filepath.Join(s.Root, fsPath)
+	}
+	path, err := filepath.Abs(fsPath)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(s.Root, path) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, fsPath)
+	}
+	root, err := filepath.EvalSymlinks(s.Root)
+	if err != nil {
+		return "", err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", err
+	}
+	if !isWithin(root, path) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, fsPath)
+	}
+	return path, nil
+}
+
+// isWithin はpathがrootまたはroot配下のパスかを返す
+func isWithin(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
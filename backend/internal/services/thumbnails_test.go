@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"penguin-backend/internal/utils"
+	"testing"
+)
+
+func TestGetThumbnail(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewFileSystemService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 横長で保存され、時計回りに90度回転して表示する写真
+	photo := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, photo, nil); err != nil {
+		t.Fatal(err)
+	}
+	tiff := testTIFF([]testTag{shortTag(0x0112, 6)}, nil)
+	if err := os.WriteFile(filepath.Join(root, "IMG_0001.JPG"), withEXIF(buf.Bytes(), tiff), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 透過のあるPNG
+	icon := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	icon.Set(0, 0, color.NRGBA{R: 255, A: 128})
+	buf.Reset()
+	if err := png.Encode(&buf, icon); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "icon.png"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := s.GetThumbnail("IMG_0001.JPG", "small")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb.Data))
+	if err != nil || thumb.ContentType != "image/jpeg" {
+		t.Fatalf("thumbnail is not a JPEG: %s %v", thumb.ContentType, err)
+	}
+	if cfg.Width != 80 || cfg.Height != 160 {
+		t.Errorf("thumbnail size = %dx%d, want 80x160 after orientation", cfg.Width, cfg.Height)
+	}
+	cached, err := filepath.Glob(filepath.Join(root, thumbnailCacheDir, "*", "*.jpg"))
+	if err != nil || len(cached) != 1 {
+		t.Fatalf("cached thumbnails = %v", cached)
+	}
+	again, err := s.GetThumbnail(filepath.Join(root, "IMG_0001.JPG"), "small")
+	if err != nil || !bytes.Equal(again.Data, thumb.Data) {
+		t.Errorf("cached thumbnail differs: %v", err)
+	}
+
+	thumb, err = s.GetThumbnail("icon.png", "large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.ContentType != "image/png" {
+		t.Errorf("thumbnail of transparent PNG is %s", thumb.ContentType)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "huge.png"), hugePNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// ルートの外の画像を指すシンボリックリンク
+	outside := filepath.Join(t.TempDir(), "secret.jpg")
+	if err := os.WriteFile(outside, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		path, size string
+		want       error
+	}{
+		{"IMG_0001.JPG", "huge", ErrInvalidThumbnailSize},
+		{"../IMG_0001.JPG", "small", ErrOutsideRoot},
+		{"link.jpg", "small", ErrOutsideRoot},
+		{"memo.txt", "small", ErrUnsupportedImage},
+		{"huge.png", "small", utils.ErrImageTooLarge},
+		{"IMG_9999.JPG", "small", os.ErrNotExist},
+	} {
+		if _, err := s.GetThumbnail(tt.path, tt.size); !errors.Is(err, tt.want) {
+			t.Errorf("GetThumbnail(%s, %s) error = %v, want %v", tt.path, tt.size, err, tt.want)
+		}
+	}
+}
+
+// hugePNG はIHDRで65535×65535を宣言した（画素のデータは1×1の）PNGを返す
+func hugePNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 65535)
+	binary.BigEndian.PutUint32(data[20:], 65535)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestEvictThumbnails(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewFileSystemService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for x := 0; x < 400; x++ {
+		img.Set(x, x%300, color.RGBA{G: uint8(x), A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "IMG_0001.JPG"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	small, err := s.GetThumbnail("IMG_0001.JPG", "small")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 最初のサムネイルだけが入る上限にすると、次のサムネイルで古いものが削除される
+	s.ThumbnailCacheSize = int64(len(small.Data)) + 1
+	medium, err := s.GetThumbnail("IMG_0001.JPG", "medium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _ := filepath.Glob(filepath.Join(root, thumbnailCacheDir, "*", "*"))
+	if len(cached) != 1 {
+		t.Fatalf("cached thumbnails = %v, want only the newest", cached)
+	}
+	if data, err := os.ReadFile(cached[0]); err != nil || !bytes.Equal(data, medium.Data) {
+		t.Errorf("the newest thumbnail was evicted")
+	}
+	if s.thumbnailCacheTotal != int64(len(medium.Data)) {
+		t.Errorf("cache total = %d, want %d", s.thumbnailCacheTotal, len(medium.Data))
+	}
+
+	// 上限を超えるまではフォルダーを調べ直さず、保存したサイズを加えていく
+	s.ThumbnailCacheSize = DefaultThumbnailCacheSize
+	large, err := s.GetThumbnail("IMG_0001.JPG", "large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len(medium.Data) + len(large.Data)); s.thumbnailCacheTotal != want {
+		t.Errorf("cache total = %d, want %d", s.thumbnailCacheTotal, want)
+	}
+}
@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// EXIFData は写真のEXIFから読み取った情報を表す
+type EXIFData struct {
+	// 撮影日時（DateTimeOriginal、なければDateTime）。タイムゾーンは撮影地の現地時刻として扱う
+	DateTime time.Time
+	// 画像の向き（1〜8、EXIFがない場合は1）
+	Orientation int
+	// カメラのメーカー・機種
+	Make  string
+	Model string
+	// 撮影位置（GPSの記録がない場合はnil）
+	GPS *EXIFGPS
+}
+
+// EXIFGPS は写真の撮影位置を表す
+type EXIFGPS struct {
+	// 緯度・経度（十進度、南緯・西経は負）
+	Latitude  float64
+	Longitude float64
+	// 標高（メートル、記録がない場合はnil）
+	Altitude *float64
+}
+
+// ErrNoEXIF はJPEGにEXIFがない場合のエラー
+var ErrNoEXIF = errors.New("EXIFがありません")
+
+// EXIFのタグ
+const (
+	exifTagMake             = 0x010F
+	exifTagModel            = 0x0110
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagGPSIFD           = 0x8825
+	exifTagDateTimeOriginal = 0x9003
+
+	// GPS IFDのタグ
+	gpsTagLatitudeRef  = 0x0001
+	gpsTagLatitude     = 0x0002
+	gpsTagLongitudeRef = 0x0003
+	gpsTagLongitude    = 0x0004
+	gpsTagAltitudeRef  = 0x0005
+	gpsTagAltitude     = 0x0006
+)
+
+// ReadEXIF はJPEGのAPP1セグメントからEXIFを読み取る
+func ReadEXIF(r io.Reader, loc *time.Location) (*EXIFData, error) {
+	tiff, err := readEXIFSegment(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return parseEXIF(tiff, loc)
+}
+
+// readEXIFSegment はJPEGのマーカーを順に読み、EXIFのTIFFデータを返す
+func readEXIFSegment(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil, errors.New("JPEGではありません")
+	}
+	for {
+		marker, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrNoEXIF
+		}
+		if marker != 0xFF {
+			continue
+		}
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrNoEXIF
+		}
+		switch {
+		case kind == 0xFF || kind == 0x01 || (kind >= 0xD0 && kind <= 0xD7):
+			// 詰め物・長さのないマーカー
+			if kind == 0xFF {
+				r.UnreadByte()
+			}
+			continue
+		case kind == 0xDA || kind == 0xD9:
+			// 画像データの開始以降にEXIFはない
+			return nil, ErrNoEXIF
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, ErrNoEXIF
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return nil, ErrNoEXIF
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, ErrNoEXIF
+		}
+		if kind == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+// parseEXIF はTIFF形式のEXIFを読み取る
+func parseEXIF(tiff []byte, loc *time.Location) (*EXIFData, error) {
+	if len(tiff) < 8 {
+		return nil, ErrNoEXIF
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("EXIFのバイト順が不正です")
+	}
+	t := exifReader{data: tiff, order: order}
+
+	data := &EXIFData{Orientation: 1}
+	var dateTime, dateTimeOriginal string
+	ifd0 := t.readIFD(int(order.Uint32(tiff[4:])))
+	if v, ok := ifd0[exifTagOrientation]; ok {
+		if o := int(t.short(v)); o >= 1 && o <= 8 {
+			data.Orientation = o
+		}
+	}
+	if v, ok := ifd0[exifTagDateTime]; ok {
+		dateTime = t.ascii(v)
+	}
+	if v, ok := ifd0[exifTagMake]; ok {
+		data.Make = t.ascii(v)
+	}
+	if v, ok := ifd0[exifTagModel]; ok {
+		data.Model = t.ascii(v)
+	}
+	if v, ok := ifd0[exifTagGPSIFD]; ok {
+		data.GPS = t.gps(t.readIFD(int(t.long(v))))
+	}
+	if v, ok := ifd0[exifTagExifIFD]; ok {
+		exif := t.readIFD(int(t.long(v)))
+		if v, ok := exif[exifTagDateTimeOriginal]; ok {
+			dateTimeOriginal = t.ascii(v)
+		}
+	}
+	for _, s := range []string{dateTimeOriginal, dateTime} {
+		if parsed, err := time.ParseInLocation("2006:01:02 15:04:05", strings.TrimSpace(s), loc); err == nil {
+			data.DateTime = parsed
+			break
+		}
+	}
+	return data, nil
+}
+
+// exifEntry はIFDの1項目（型・個数・値またはオフセット）を表す
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	// 値が4バイト以下の場合は値そのもの、それ以外はオフセット
+	value []byte
+}
+
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// readIFD はIFDの項目をタグごとに返す（壊れている場合は読めた分のみ）
+func (t exifReader) readIFD(offset int) map[uint16]exifEntry {
+	entries := make(map[uint16]exifEntry)
+	if offset <= 0 || offset+2 > len(t.data) {
+		return entries
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	for i := 0; i < n; i++ {
+		pos := offset + 2 + i*12
+		if pos+12 > len(t.data) {
+			break
+		}
+		tag := t.order.Uint16(t.data[pos:])
+		entries[tag] = exifEntry{
+			typ:   t.order.Uint16(t.data[pos+2:]),
+			count: t.order.Uint32(t.data[pos+4:]),
+			value: t.data[pos+8 : pos+12],
+		}
+	}
+	return entries
+}
+
+// valueBytes は項目の値のバイト列を返す
+func (t exifReader) valueBytes(e exifEntry) []byte {
+	size := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}[e.typ] * int(e.count)
+	if size <= 4 {
+		return e.value[:size]
+	}
+	offset := int(t.order.Uint32(e.value))
+	if offset < 0 || offset+size > len(t.data) {
+		return nil
+	}
+	return t.data[offset : offset+size]
+}
+
+func (t exifReader) short(e exifEntry) uint16 {
+	if b := t.valueBytes(e); len(b) >= 2 {
+		return t.order.Uint16(b)
+	}
+	return 0
+}
+
+func (t exifReader) long(e exifEntry) uint32 {
+	if b := t.valueBytes(e); len(b) >= 4 {
+		return t.order.Uint32(b)
+	}
+	return 0
+}
+
+// rationals は符号なし有理数（RATIONAL）の値を返す
+func (t exifReader) rationals(e exifEntry) []float64 {
+	if e.typ != 5 {
+		return nil
+	}
+	b := t.valueBytes(e)
+	values := make([]float64, 0, len(b)/8)
+	for i := 0; i+8 <= len(b); i += 8 {
+		num, den := t.order.Uint32(b[i:]), t.order.Uint32(b[i+4:])
+		if den == 0 {
+			return nil
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values
+}
+
+// gps はGPS IFDから撮影位置を読み取る（緯度・経度がそろわない場合はnil）
+func (t exifReader) gps(ifd map[uint16]exifEntry) *EXIFGPS {
+	degrees := func(tag, refTag uint16, negative string) (float64, bool) {
+		dms := t.rationals(ifd[tag])
+		if len(dms) != 3 {
+			return 0, false
+		}
+		v := dms[0] + dms[1]/60 + dms[2]/3600
+		if ref, ok := ifd[refTag]; ok && strings.EqualFold(t.ascii(ref), negative) {
+			v = -v
+		}
+		return v, true
+	}
+	lat, ok := degrees(gpsTagLatitude, gpsTagLatitudeRef, "S")
+	if !ok || lat < -90 || lat > 90 {
+		return nil
+	}
+	lon, ok := degrees(gpsTagLongitude, gpsTagLongitudeRef, "W")
+	if !ok || lon < -180 || lon > 180 {
+		return nil
+	}
+	gps := &EXIFGPS{Latitude: lat, Longitude: lon}
+	if alt := t.rationals(ifd[gpsTagAltitude]); len(alt) == 1 {
+		// AltitudeRefが1の場合は海面下
+		if ref, ok := ifd[gpsTagAltitudeRef]; ok {
+			if b := t.valueBytes(ref); len(b) == 1 && b[0] == 1 {
+				alt[0] = -alt[0]
+			}
+		}
+		gps.Altitude = &alt[0]
+	}
+	return gps
+}
+
+func (t exifReader) ascii(e exifEntry) string {
+	return strings.TrimRight(string(t.valueBytes(e)), "\x00 ")
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+)
+
+// MaxImagePixels は展開する画像の画素数の上限（1億画素、RGBAで約400MB）
+const MaxImagePixels = 100_000_000
+
+// ErrImageTooLarge は画像の画素数が MaxImagePixels を超える場合のエラー
+var ErrImageTooLarge = errors.New("画像の画素数が多すぎます")
+
+// DecodeImage は画像の大きさを確認してから展開する
+// 数KBのファイルでもヘッダーで巨大な大きさを宣言できるため、画素数が MaxImagePixels を超える画像は展開しない
+func DecodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// ResizeToFit は長辺がmaxSide以下になるように画像を縮小する（拡大はしない）
+// 縮小は面積平均で行うため、写真の縮小でもモアレが出にくい
+func ResizeToFit(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) || w == 0 || h == 0 {
+		return src
+	}
+	dw, dh := maxSide, h*maxSide/w
+	if h > w {
+		dw, dh = w*maxSide/h, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := b.Min.Y + dy*h/dh
+		y1 := max(b.Min.Y+(dy+1)*h/dh, y0+1)
+		for dx := 0; dx < dw; dx++ {
+			x0 := b.Min.X + dx*w/dw
+			x1 := max(b.Min.X+(dx+1)*w/dw, x0+1)
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// ScaleToFit は長辺がmaxSide以下になるように画像をCatmull-Romで縮小する（拡大はしない）
+// 面積平均のResizeToFitより速いため、サムネイルや知覚ハッシュのように多くの写真を縮小する処理で使う
+func ScaleToFit(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) || w == 0 || h == 0 {
+		return src
+	}
+	dw, dh := maxSide, h*maxSide/w
+	if h > w {
+		dw, dh = w*maxSide/h, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// ApplyOrientation はEXIFのOrientation（1〜8）に従って画像を回転・反転し、正しい向きの画像を返す
+func ApplyOrientation(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// 5〜8は90度回転を含むため縦横が入れ替わる
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 左右反転
+				dx, dy = w-1-x, y
+			case 3: // 180度回転
+				dx, dy = w-1-x, h-1-y
+			case 4: // 上下反転
+				dx, dy = x, h-1-y
+			case 5: // 左上と右下を結ぶ対角線で反転
+				dx, dy = y, x
+			case 6: // 時計回りに90度回転
+				dx, dy = h-1-y, x
+			case 7: // 右上と左下を結ぶ対角線で反転
+				dx, dy = h-1-y, w-1-x
+			case 8: // 反時計回りに90度回転
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// DifferenceHash は画像の知覚ハッシュ（dHash、64ビット）を返す
+// 9×8のグレースケールに縮小して左右に隣り合う画素の明暗を比べるため、縮小・再圧縮・軽い色調補正では値がほとんど変わらない
+func DifferenceHash(src image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, 9, 8))
+	small := ScaleToFit(src, 64)
+	draw.CatmullRom.Scale(gray, gray.Bounds(), small, small.Bounds(), draw.Src, nil)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y < gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HammingDistance は2つのハッシュの異なるビットの数を返す
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
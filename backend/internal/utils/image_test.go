@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestResizeToFit(t *testing.T) {
+	// 白黒の縦縞は面積平均で灰色になる
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x += 2 {
+			src.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			src.SetRGBA(x+1, y, color.RGBA{A: 255})
+		}
+	}
+	dst, ok := ResizeToFit(src, 100).(*image.RGBA)
+	if !ok || dst.Bounds().Dx() != 100 || dst.Bounds().Dy() != 50 {
+		t.Fatalf("ResizeToFit() = %T %v, want 100x50 RGBA", dst, dst.Bounds())
+	}
+	if c := dst.RGBAAt(10, 10); c.R != 127 || c.A != 255 {
+		t.Errorf("ResizeToFit() pixel = %v, want the average gray", c)
+	}
+	if ResizeToFit(src, 400) != image.Image(src) {
+		t.Error("ResizeToFit() enlarged or copied an image that already fits")
+	}
+
+	scaled := ScaleToFit(src, 100)
+	if b := scaled.Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Errorf("ScaleToFit() bounds = %v, want 100x50", b)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeImage(buf.Bytes())
+	if err != nil || img.Bounds().Dx() != 4 || img.Bounds().Dy() != 3 {
+		t.Fatalf("DecodeImage() = %v, %v; want 4x3", img, err)
+	}
+
+	// IHDRで65535×65535を宣言したPNGは展開せずにエラーにする
+	data := buf.Bytes()
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:], 65535)
+	binary.BigEndian.PutUint32(ihdr[4:], 65535)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	if _, err := DecodeImage(data); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("DecodeImage(65535x65535) error = %v, want ErrImageTooLarge", err)
+	}
+}
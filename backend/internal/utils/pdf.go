@@ -0,0 +1,530 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	// 印影などのPNG画像を読み込む
+	_ "image/png"
+)
+
+// A4の用紙サイズ（ポイント）
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFDocument は日本語の帳票を出力するための最小構成のPDFを表す
+// 座標はポイント単位で、用紙の左上を原点とする
+type PDFDocument struct {
+	// fontがnilの場合は埋め込まない標準の日本語フォント（HeiseiKakuGo-W5）を使用する
+	font   *TrueTypeFont
+	pages  []*PDFPage
+	images []*PDFImage
+	title  string
+}
+
+// PDFPage はPDFの1ページを表す
+type PDFPage struct {
+	doc     *PDFDocument
+	content bytes.Buffer
+	images  map[*PDFImage]bool
+}
+
+// PDFImage はPDFに埋め込む画像を表す
+type PDFImage struct {
+	// 画素数
+	Width, Height int
+	name          string
+	data          []byte
+	filter        string
+	colorSpace    string
+	bits          int
+	// 透過情報（PNGのアルファチャンネル）
+	mask *PDFImage
+}
+
+// NewPDFDocument はPDFを作成する
+// fontDataにTrueTypeフォントを指定すると使用した文字のみを埋め込み、空の場合は埋め込まない標準の日本語フォントを使用する
+func NewPDFDocument(title string, fontData []byte) (*PDFDocument, error) {
+	doc := &PDFDocument{title: title}
+	if len(fontData) > 0 {
+		font, err := ParseTrueTypeFont(fontData)
+		if err != nil {
+			return nil, err
+		}
+		doc.font = font
+	}
+	return doc, nil
+}
+
+// EmbedsFont はフォントを埋め込むかどうかを返す
+func (d *PDFDocument) EmbedsFont() bool {
+	return d.font != nil
+}
+
+// AddPage はA4縦のページを追加する
+func (d *PDFDocument) AddPage() *PDFPage {
+	page := &PDFPage{doc: d, images: make(map[*PDFImage]bool)}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// TextWidth は文字列を指定したサイズで描画したときの幅を返す
+func (d *PDFDocument) TextWidth(s string, size float64) float64 {
+	var width float64
+	for _, r := range s {
+		width += d.advance(r)
+	}
+	return width * size / 1000
+}
+
+func (d *PDFDocument) advance(r rune) float64 {
+	if d.font != nil {
+		return d.font.Advance(r)
+	}
+	// 標準フォントはASCIIと半角カナを半角、それ以外を全角とみなす
+	if (r >= 0x20 && r <= 0x7E) || (r >= 0xFF61 && r <= 0xFF9F) {
+		return 500
+	}
+	return 1000
+}
+
+// AddImage はJPEGまたはPNGの画像を読み込む
+// JPEGはそのまま埋め込み、それ以外は画素を展開して圧縮する（アルファチャンネルは透過として扱う）
+func (d *PDFDocument) AddImage(data []byte) (*PDFImage, error) {
+	img := &PDFImage{name: fmt.Sprintf("Im%d", len(d.images)+1), bits: 8}
+	if cfg, err := jpeg.DecodeConfig(bytes.NewReader(data)); err == nil {
+		img.Width, img.Height = cfg.Width, cfg.Height
+		img.data, img.filter = data, "DCTDecode"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			img.colorSpace = "DeviceGray"
+		case color.CMYKModel:
+			img.colorSpace = "DeviceCMYK"
+		default:
+			img.colorSpace = "DeviceRGB"
+		}
+		d.images = append(d.images, img)
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("画像を読み込めません: %w", err)
+	}
+	return d.AddDecodedImage(decoded), nil
+}
+
+// AddDecodedImage は展開済みの画像を圧縮して埋め込む
+func (d *PDFDocument) AddDecodedImage(decoded image.Image) *PDFImage {
+	bounds := decoded.Bounds()
+	img := &PDFImage{
+		name:       fmt.Sprintf("Im%d", len(d.images)+1),
+		Width:      bounds.Dx(),
+		Height:     bounds.Dy(),
+		bits:       8,
+		filter:     "FlateDecode",
+		colorSpace: "DeviceRGB",
+	}
+	rgb := make([]byte, 0, img.Width*img.Height*3)
+	alpha := make([]byte, 0, img.Width*img.Height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			if a > 0 && a < 0xFFFF {
+				// 乗算済みのアルファを戻す
+				r, g, b = r*0xFFFF/a, g*0xFFFF/a, b*0xFFFF/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(b>>8))
+			alpha = append(alpha, byte(a>>8))
+			if a != 0xFFFF {
+				opaque = false
+			}
+		}
+	}
+	img.data = deflate(rgb)
+	if !opaque {
+		img.mask = &PDFImage{
+			Width: img.Width, Height: img.Height, bits: 8,
+			data: deflate(alpha), filter: "FlateDecode", colorSpace: "DeviceGray",
+		}
+	}
+	d.images = append(d.images, img)
+	return img
+}
+
+// Text は文字列を描画する（yは文字のベースライン）
+func (p *PDFPage) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td %s Tj ET\n",
+		pdfNumber(size), pdfNumber(x), pdfNumber(PDFPageHeight-y), p.doc.encodeText(s))
+}
+
+// TextRight は右端をxに揃えて文字列を描画する
+func (p *PDFPage) TextRight(x, y, size float64, s string) {
+	p.Text(x-p.doc.TextWidth(s, size), y, size, s)
+}
+
+// TextCenter は中央をxに揃えて文字列を描画する
+func (p *PDFPage) TextCenter(x, y, size float64, s string) {
+	p.Text(x-p.doc.TextWidth(s, size)/2, y, size, s)
+}
+
+// TextBox は幅に収まるように折り返して文字列を描画し、描画した行数を返す
+// 改行文字でも改行する
+func (p *PDFPage) TextBox(x, y, width, size, leading float64, s string) int {
+	lines := p.doc.WrapText(s, width, size)
+	for i, line := range lines {
+		p.Text(x, y+float64(i)*leading, size, line)
+	}
+	return len(lines)
+}
+
+// WrapText は幅に収まるように文字列を行に分割する
+func (d *PDFDocument) WrapText(s string, width, size float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		var line []rune
+		var lineWidth float64
+		for _, r := range paragraph {
+			w := d.advance(r) * size / 1000
+			if lineWidth+w > width && len(line) > 0 && !isLineStartProhibited(r) {
+				lines = append(lines, string(line))
+				line, lineWidth = nil, 0
+			}
+			line = append(line, r)
+			lineWidth += w
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// isLineStartProhibited は行頭に置かない文字（句読点・閉じ括弧）かどうかを返す
+func isLineStartProhibited(r rune) bool {
+	return strings.ContainsRune("、。，．）」』】〕〉》”’,.)]}:;!?！？ー", r)
+}
+
+// Line は線を描画する
+func (p *PDFPage) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", pdfNumber(width),
+		pdfNumber(x1), pdfNumber(PDFPageHeight-y1), pdfNumber(x2), pdfNumber(PDFPageHeight-y2))
+}
+
+// Rect は矩形の枠を描画する（(x, y)は左上）
+func (p *PDFPage) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n", pdfNumber(width),
+		pdfNumber(x), pdfNumber(PDFPageHeight-y-h), pdfNumber(w), pdfNumber(h))
+}
+
+// FillRect は矩形をグレー（0は黒、1は白）で塗りつぶす
+func (p *PDFPage) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n", pdfNumber(gray),
+		pdfNumber(x), pdfNumber(PDFPageHeight-y-h), pdfNumber(w), pdfNumber(h))
+}
+
+// Image は画像を(x, y)を左上とする矩形に描画する
+func (p *PDFPage) Image(img *PDFImage, x, y, w, h float64) {
+	p.images[img] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		pdfNumber(w), pdfNumber(h), pdfNumber(x), pdfNumber(PDFPageHeight-y-h), img.name)
+}
+
+// ImageFit は縦横比を保って矩形に収まるように画像を中央に描画する
+func (p *PDFPage) ImageFit(img *PDFImage, x, y, w, h float64) {
+	p.ImageFitOriented(img, x, y, w, h, 1)
+}
+
+// ImageFitOriented はEXIFの向き（1〜8）に従って回転・反転した画像を矩形の中央に収まるように描画する
+func (p *PDFPage) ImageFitOriented(img *PDFImage, x, y, w, h float64, orientation int) {
+	if img.Width == 0 || img.Height == 0 {
+		return
+	}
+	iw, ih := float64(img.Width), float64(img.Height)
+	if orientation >= 5 && orientation <= 8 {
+		iw, ih = ih, iw
+	}
+	scale := min(w/iw, h/ih)
+	dw, dh := iw*scale, ih*scale
+	// 表示する矩形の左下（PDFの座標）
+	px, py := x+(w-dw)/2, PDFPageHeight-(y+(h-dh)/2)-dh
+
+	// 画像の単位正方形を表示する矩形に写す行列
+	var m [6]float64
+	switch orientation {
+	case 2:
+		m = [6]float64{-dw, 0, 0, dh, px + dw, py}
+	case 3:
+		m = [6]float64{-dw, 0, 0, -dh, px + dw, py + dh}
+	case 4:
+		m = [6]float64{dw, 0, 0, -dh, px, py + dh}
+	case 5:
+		m = [6]float64{0, -dh, -dw, 0, px + dw, py + dh}
+	case 6:
+		m = [6]float64{0, -dh, dw, 0, px, py + dh}
+	case 7:
+		m = [6]float64{0, dh, dw, 0, px, py}
+	case 8:
+		m = [6]float64{0, dh, -dw, 0, px + dw, py}
+	default:
+		m = [6]float64{dw, 0, 0, dh, px, py}
+	}
+	p.images[img] = true
+	fmt.Fprintf(&p.content, "q %s %s %s %s %s %s cm /%s Do Q\n",
+		pdfNumber(m[0]), pdfNumber(m[1]), pdfNumber(m[2]), pdfNumber(m[3]), pdfNumber(m[4]), pdfNumber(m[5]), img.name)
+}
+
+// encodeText は文字列をPDFの16進文字列に変換する
+func (d *PDFDocument) encodeText(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if d.font != nil {
+			fmt.Fprintf(&b, "%04X", d.font.GlyphID(r))
+			continue
+		}
+		// UniJIS-UCS2-HW-HはBMPの文字のみ対応する
+		if r > 0xFFFF || !unicode.IsPrint(r) && r != ' ' {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// WriteTo はPDFを書き出す
+func (d *PDFDocument) WriteTo(w io.Writer) (int64, error) {
+	pw := &pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// オブジェクト番号: 1=カタログ, 2=ページツリー, 3=フォント, 4=情報
+	const catalogID, pagesID, fontID, infoID = 1, 2, 3, 4
+	pw.next = 5
+
+	imageIDs := make(map[*PDFImage]int)
+	for _, img := range d.images {
+		maskID := 0
+		if img.mask != nil {
+			maskID = pw.writeImage(img.mask, 0)
+		}
+		imageIDs[img] = pw.writeImage(img, maskID)
+	}
+
+	pageIDs := make([]int, 0, len(d.pages))
+	for _, page := range d.pages {
+		contentID := pw.writeStream("", deflate(page.content.Bytes()), "/Filter /FlateDecode")
+		var xobjects strings.Builder
+		for _, img := range d.images {
+			if page.images[img] {
+				fmt.Fprintf(&xobjects, " /%s %d 0 R", img.name, imageIDs[img])
+			}
+		}
+		resources := fmt.Sprintf("/Font << /F1 %d 0 R >>", fontID)
+		if xobjects.Len() > 0 {
+			resources += " /XObject <<" + xobjects.String() + " >>"
+		}
+		pageIDs = append(pageIDs, pw.writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pagesID, pdfNumber(PDFPageWidth), pdfNumber(PDFPageHeight), resources, contentID)))
+	}
+
+	if d.font != nil {
+		d.writeEmbeddedFont(pw, fontID)
+	} else {
+		descendantID := pw.writeObject(`<< /Type /Font /Subtype /CIDFontType0 /BaseFont /HeiseiKakuGo-W5 ` +
+			`/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> /FontDescriptor ` +
+			fmt.Sprint(pw.next+1) + ` 0 R /DW 1000 /W [231 389 500] >>`)
+		pw.writeObject(`<< /Type /FontDescriptor /FontName /HeiseiKakuGo-W5 /Flags 4 /FontBBox [-92 -250 1010 922] ` +
+			`/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 737 /StemV 93 >>`)
+		pw.writeObjectAt(fontID, fmt.Sprintf(`<< /Type /Font /Subtype /Type0 /BaseFont /HeiseiKakuGo-W5 `+
+			`/Encoding /UniJIS-UCS2-HW-H /DescendantFonts [%d 0 R] >>`, descendantID))
+	}
+
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	pw.writeObjectAt(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs)))
+	pw.writeObjectAt(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	pw.writeObjectAt(infoID, fmt.Sprintf("<< /Title %s /Producer (penguin) >>", pdfUTF16String(d.title)))
+
+	// 相互参照表
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", pw.next)
+	for id := 1; id < pw.next; id++ {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", pw.offsets[id])
+	}
+	fmt.Fprintf(&pw.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		pw.next, catalogID, infoID, xref)
+
+	n, err := w.Write(pw.buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes はPDFのバイト列を返す
+func (d *PDFDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// writeEmbeddedFont は使用した文字のサブセットをCIDFontType2（Identity-H）として書き出す
+func (d *PDFDocument) writeEmbeddedFont(pw *pdfWriter, fontID int) {
+	f := d.font
+	// サブセットのフォント名には使用したグリフから決まる6文字の大文字の接頭辞を付ける
+	gids := f.usedGlyphs()
+	h := fnv.New32a()
+	for _, gid := range gids {
+		h.Write([]byte{byte(gid >> 8), byte(gid)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = byte('A' + sum%26)
+		sum /= 26
+	}
+	baseFont := string(tag) + "+" + f.Name
+
+	subset := f.Subset()
+	fontFileID := pw.writeStream("", deflate(subset), fmt.Sprintf("/Filter /FlateDecode /Length1 %d", len(subset)))
+	descriptorID := pw.writeObject(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+			"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseFont, f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], f.ascent, f.descent, f.capHeight, fontFileID))
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, int(float64(f.advances[min(int(gid), len(f.advances)-1)])*1000/float64(f.unitsPerEm)))
+	}
+	cidFontID := pw.writeObject(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		baseFont, descriptorID, strings.TrimSpace(widths.String())))
+
+	// 文字のコピーや検索のためのToUnicode
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		chunk := gids[i:min(i+100, len(gids))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			fmt.Fprintf(&cmap, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{f.used[gid]}) {
+				fmt.Fprintf(&cmap, "%04X", u)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	toUnicodeID := pw.writeStream("", deflate([]byte(cmap.String())), "/Filter /FlateDecode")
+
+	pw.writeObjectAt(fontID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, cidFontID, toUnicodeID))
+}
+
+// pdfWriter はPDFのオブジェクトを順に書き出し、相互参照表のためのオフセットを記録する
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+	next    int
+}
+
+func (pw *pdfWriter) writeObject(body string) int {
+	id := pw.next
+	pw.next++
+	pw.writeObjectAt(id, body)
+	return id
+}
+
+func (pw *pdfWriter) writeObjectAt(id int, body string) {
+	if pw.offsets == nil {
+		pw.offsets = make(map[int]int)
+	}
+	pw.offsets[id] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (pw *pdfWriter) writeStream(dict string, data []byte, extra string) int {
+	id := pw.next
+	pw.next++
+	if pw.offsets == nil {
+		pw.offsets = make(map[int]int)
+	}
+	pw.offsets[id] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n<< %s%s /Length %d >>\nstream\n", id, dict, extra, len(data))
+	pw.buf.Write(data)
+	pw.buf.WriteString("\nendstream\nendobj\n")
+	return id
+}
+
+func (pw *pdfWriter) writeImage(img *PDFImage, maskID int) int {
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent %d ",
+		img.Width, img.Height, img.colorSpace, img.bits)
+	if img.colorSpace == "DeviceCMYK" {
+		// AdobeのCMYK JPEGは反転して保存されている
+		dict += "/Decode [1 0 1 0 1 0 1 0] "
+	}
+	if maskID > 0 {
+		dict += fmt.Sprintf("/SMask %d 0 R ", maskID)
+	}
+	return pw.writeStream(dict, img.data, "/Filter /"+img.filter)
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// pdfNumber は数値を小数点以下2桁までの文字列にする
+func pdfNumber(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfUTF16String は文字列をUTF-16BEのPDF文字列にする
+func pdfUTF16String(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// sanitizePDFName はPDFの名前オブジェクトに使用できる文字のみを残す
+func sanitizePDFName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0x20 && r < 0x7F && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,425 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// TrueTypeFont はPDFに埋め込むためのTrueTypeフォント（glyfアウトライン）を表す
+// サブセット化のため、文字からグリフIDと幅を引き、使用したグリフを記録する
+type TrueTypeFont struct {
+	tables map[string][]byte
+	// PostScript名（nameテーブルのID 6）
+	Name       string
+	unitsPerEm int
+	// 1000単位に換算したフォントの寸法
+	ascent, descent, capHeight int
+	bbox                       [4]int
+	numGlyphs                  int
+	longLoca                   bool
+	// 文字コードからグリフIDへの対応（cmap）
+	cmap map[rune]uint16
+	// グリフIDごとの送り幅（フォント単位）
+	advances []uint16
+	// 使用したグリフと対応する文字（ToUnicode用）
+	used map[uint16]rune
+}
+
+// ParseTrueTypeFont はTrueTypeフォント（.ttf）またはフォントコレクション（.ttc、先頭のフォントを使用）を読み込む
+// CFFアウトラインのOpenTypeフォント（.otf）は対応しない
+func ParseTrueTypeFont(data []byte) (*TrueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("フォントファイルが短すぎます")
+	}
+	offset := 0
+	if string(data[:4]) == "ttcf" {
+		if len(data) < 16 {
+			return nil, errors.New("フォントコレクションが壊れています")
+		}
+		offset = int(binary.BigEndian.Uint32(data[12:16]))
+	}
+	if offset+12 > len(data) {
+		return nil, errors.New("フォントファイルが壊れています")
+	}
+	switch string(data[offset : offset+4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, errors.New("CFFアウトラインのフォント（.otf）には対応していません。TrueTypeフォント（.ttf/.ttc）を指定してください")
+	default:
+		return nil, errors.New("TrueTypeフォントではありません")
+	}
+
+	f := &TrueTypeFont{tables: make(map[string][]byte), used: make(map[uint16]rune)}
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	for i := 0; i < numTables; i++ {
+		rec := offset + 12 + i*16
+		if rec+16 > len(data) {
+			return nil, errors.New("フォントのテーブル一覧が壊れています")
+		}
+		tag := string(data[rec : rec+4])
+		start := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("フォントの%sテーブルが壊れています", tag)
+		}
+		f.tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap", "loca", "glyf"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("フォントに%sテーブルがありません", tag)
+		}
+	}
+	if err := f.parseMetrics(); err != nil {
+		return nil, err
+	}
+	if err := f.parseCmap(); err != nil {
+		return nil, err
+	}
+	f.Name = f.postScriptName()
+	return f, nil
+}
+
+func (f *TrueTypeFont) parseMetrics() error {
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return errors.New("フォントのヘッダーが壊れています")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return errors.New("フォントのunitsPerEmが0です")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = f.scale(int(int16(binary.BigEndian.Uint16(head[36+i*2:]))))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[4:]))))
+	f.descent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[6:]))))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = f.scale(int(int16(binary.BigEndian.Uint16(os2[88:]))))
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < numHMetrics*4 {
+		return errors.New("フォントのhmtxテーブルが壊れています")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for gid := range f.advances {
+		i := min(gid, numHMetrics-1)
+		f.advances[gid] = binary.BigEndian.Uint16(hmtx[i*4:])
+	}
+	return nil
+}
+
+// parseCmap はUnicodeのcmap（形式12を優先し、なければ形式4）を読み込む
+func (f *TrueTypeFont) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return errors.New("フォントのcmapテーブルが壊れています")
+	}
+	var format4, format12 []byte
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + i*8
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if offset+4 > len(cmap) || (platform != 0 && platform != 3) {
+			continue
+		}
+		sub := cmap[offset:]
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if platform == 0 || encoding == 1 {
+				format4 = sub
+			}
+		case 12:
+			if platform == 0 || encoding == 10 {
+				format12 = sub
+			}
+		}
+	}
+
+	f.cmap = make(map[rune]uint16)
+	switch {
+	case len(format12) >= 16:
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		for i := 0; i < groups && 16+i*12+12 <= len(format12); i++ {
+			g := format12[16+i*12:]
+			start, end := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:])
+			gid := binary.BigEndian.Uint32(g[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				f.cmap[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case len(format4) >= 14:
+		segX2 := int(binary.BigEndian.Uint16(format4[6:]))
+		if 16+segX2*4 > len(format4) {
+			return errors.New("フォントのcmapテーブルが壊れています")
+		}
+		ends := format4[14:]
+		starts := format4[16+segX2:]
+		deltas := format4[16+segX2*2:]
+		rangeOffsets := format4[16+segX2*3:]
+		for i := 0; i < segX2/2; i++ {
+			end := binary.BigEndian.Uint16(ends[i*2:])
+			start := binary.BigEndian.Uint16(starts[i*2:])
+			delta := binary.BigEndian.Uint16(deltas[i*2:])
+			rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[i*2:]))
+			for c := uint32(start); c <= uint32(end) && c != 0xFFFF; c++ {
+				var gid uint16
+				if rangeOffset == 0 {
+					gid = uint16(c) + delta
+				} else {
+					pos := 16 + segX2*3 + i*2 + rangeOffset + int(c-uint32(start))*2
+					if pos+2 > len(format4) {
+						continue
+					}
+					if gid = binary.BigEndian.Uint16(format4[pos:]); gid != 0 {
+						gid += delta
+					}
+				}
+				if gid != 0 {
+					f.cmap[rune(c)] = gid
+				}
+			}
+		}
+	default:
+		return errors.New("フォントにUnicodeのcmapがありません")
+	}
+	return nil
+}
+
+// postScriptName はnameテーブルからPostScript名を返す（取得できない場合はEmbeddedFont）
+func (f *TrueTypeFont) postScriptName() string {
+	name := f.tables["name"]
+	if len(name) < 6 {
+		return "EmbeddedFont"
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + i*12
+		if rec+12 > len(name) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(name[rec:])
+		nameID := binary.BigEndian.Uint16(name[rec+6:])
+		length := int(binary.BigEndian.Uint16(name[rec+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(name[rec+10:]))
+		if nameID != 6 || offset+length > len(name) {
+			continue
+		}
+		raw := name[offset : offset+length]
+		var s string
+		if platform == 1 {
+			s = string(raw)
+		} else {
+			u := make([]uint16, len(raw)/2)
+			for j := range u {
+				u[j] = binary.BigEndian.Uint16(raw[j*2:])
+			}
+			s = string(utf16.Decode(u))
+		}
+		if s = sanitizePDFName(s); s != "" {
+			return s
+		}
+	}
+	return "EmbeddedFont"
+}
+
+// GlyphID は文字のグリフIDを返し、使用したグリフとして記録する（フォントにない文字は0）
+func (f *TrueTypeFont) GlyphID(r rune) uint16 {
+	gid := f.cmap[r]
+	if _, ok := f.used[gid]; !ok {
+		f.used[gid] = r
+	}
+	return gid
+}
+
+// Advance は文字の送り幅を1000単位で返す
+func (f *TrueTypeFont) Advance(r rune) float64 {
+	gid := int(f.cmap[r])
+	if gid >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+func (f *TrueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// usedGlyphs は使用したグリフIDを昇順で返す
+func (f *TrueTypeFont) usedGlyphs() []uint16 {
+	gids := make([]uint16, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+// glyph はグリフIDのglyfデータを返す
+func (f *TrueTypeFont) glyph(gid int) []byte {
+	loca, glyf := f.tables["loca"], f.tables["glyf"]
+	var start, end int
+	if f.longLoca {
+		if (gid+2)*4 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(loca[gid*4:]))
+		end = int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+	} else {
+		if (gid+2)*2 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+		end = int(binary.BigEndian.Uint16(loca[gid*2+2:])) * 2
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// Subset は使用したグリフ（と複合グリフの部品）のみを残したフォントを返す
+// グリフIDを変えないため、使用しないグリフは空のアウトラインにする
+func (f *TrueTypeFont) Subset() []byte {
+	keep := map[int]bool{0: true}
+	queue := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		queue = append(queue, int(gid))
+	}
+	for len(queue) > 0 {
+		gid := queue[0]
+		queue = queue[1:]
+		if gid >= f.numGlyphs || keep[gid] && gid != 0 {
+			continue
+		}
+		keep[gid] = true
+		// 複合グリフ（numberOfContours < 0）の部品を追加する
+		g := f.glyph(gid)
+		if len(g) < 10 || int16(binary.BigEndian.Uint16(g)) >= 0 {
+			continue
+		}
+		for pos := 10; pos+4 <= len(g); {
+			flags := binary.BigEndian.Uint16(g[pos:])
+			component := int(binary.BigEndian.Uint16(g[pos+2:]))
+			if !keep[component] {
+				queue = append(queue, component)
+			}
+			pos += 4
+			if flags&0x0001 != 0 {
+				pos += 4
+			} else {
+				pos += 2
+			}
+			switch {
+			case flags&0x0008 != 0:
+				pos += 2
+			case flags&0x0040 != 0:
+				pos += 4
+			case flags&0x0080 != 0:
+				pos += 8
+			}
+			if flags&0x0020 == 0 {
+				break
+			}
+		}
+	}
+
+	var glyf []byte
+	loca := make([]byte, (f.numGlyphs+1)*4)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[gid*4:], uint32(len(glyf)))
+		if keep[gid] {
+			glyf = append(glyf, f.glyph(gid)...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(len(glyf)))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": loca,
+		"glyf": glyf,
+	}
+	// ヒンティングのテーブルと、ビューアーによっては必要になるcmapなどはそのまま残す
+	for _, tag := range []string{"cvt ", "fpgm", "prep", "cmap", "name", "OS/2"} {
+		if t, ok := f.tables[tag]; ok {
+			tables[tag] = t
+		}
+	}
+	// postテーブルはグリフ名を持たない形式3にする
+	if post := f.tables["post"]; len(post) >= 32 {
+		post = append([]byte(nil), post[:32]...)
+		binary.BigEndian.PutUint32(post, 0x00030000)
+		tables["post"] = post
+	}
+	return buildTrueTypeFont(tables)
+}
+
+// buildTrueTypeFont はテーブルからTrueTypeフォントのファイルを組み立てる
+func buildTrueTypeFont(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= n {
+		searchRange *= 2
+		entrySelector++
+	}
+	header := make([]byte, 12+n*16)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(n))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(n*16-searchRange*16))
+
+	var body []byte
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := header[12+i*16:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], trueTypeChecksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		body = append(body, data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+func trueTypeChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestTrueTypeFontSubset(t *testing.T) {
+	font, err := ParseTrueTypeFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Name == "" || font.numGlyphs == 0 {
+		t.Fatalf("parsed font = %q with %d glyphs", font.Name, font.numGlyphs)
+	}
+
+	used := []rune{'A', 'g', 'é'}
+	for _, r := range used {
+		if font.GlyphID(r) == 0 {
+			t.Fatalf("font has no glyph for %q", r)
+		}
+	}
+	subset := font.Subset()
+	if len(subset) >= len(goregular.TTF)/2 {
+		t.Errorf("subset is %d bytes, original %d bytes", len(subset), len(goregular.TTF))
+	}
+
+	parsed, err := ParseTrueTypeFont(subset)
+	if err != nil {
+		t.Fatalf("subset does not parse: %v", err)
+	}
+	if parsed.numGlyphs != font.numGlyphs {
+		t.Errorf("subset has %d glyphs, want %d (glyph IDs are kept)", parsed.numGlyphs, font.numGlyphs)
+	}
+	for _, r := range used {
+		gid := font.cmap[r]
+		if parsed.cmap[r] != gid || parsed.Advance(r) != font.Advance(r) {
+			t.Errorf("%q: subset glyph %d advance %v, want %d advance %v", r, parsed.cmap[r], parsed.Advance(r), gid, font.Advance(r))
+		}
+		if !bytes.Equal(parsed.glyph(int(gid)), font.glyph(int(gid))) {
+			t.Errorf("%q: subset outline differs from the original", r)
+		}
+	}
+	// 使用していないグリフは空にする
+	if g := parsed.glyph(int(font.cmap['Z'])); g != nil {
+		t.Errorf("unused glyph Z has %d bytes of outline", len(g))
+	}
+}
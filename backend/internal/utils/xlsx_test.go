@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewriteXLSXSheet はExcelブックのシートのXMLを書き換えたブックを返す
+func rewriteXLSXSheet(t *testing.T, book []byte, old, new string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(f.Name, "xl/worksheets/") {
+			data = bytes.Replace(data, []byte(old), []byte(new), 1)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "工事", [][]any{{"会社", "現場"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ old, new string }{
+		{`<row r="1"`, `<row r="1048577"`},
+		{`r="B1"`, `r="XFE1"`},
+		{`r="B1"`, `r="ZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ1"`},
+	} {
+		book := rewriteXLSXSheet(t, buf.Bytes(), tt.old, tt.new)
+		if rows, err := ReadXLSX(bytes.NewReader(book), int64(len(book))); err == nil {
+			t.Errorf("ReadXLSX(%s) = %d rows, want error", tt.new, len(rows))
+		}
+	}
+
+	// 上限ちょうどの列は読み込む
+	book := rewriteXLSXSheet(t, buf.Bytes(), `r="B1"`, `r="XFD1"`)
+	rows, err := ReadXLSX(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0]) != xlsxMaxColumns || rows[0][xlsxMaxColumns-1] != "現場" {
+		t.Errorf("XFD1 was not read as the last column")
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	for _, tt := range []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"Z1", 25},
+		{"AA12", 26},
+		{"XFD1", xlsxMaxColumns - 1},
+		{"XFE1", xlsxMaxColumns},
+		{strings.Repeat("Z", 40) + "1", xlsxMaxColumns},
+		{"12", -1},
+	} {
+		if got := xlsxColumnIndex(tt.ref); got != tt.want {
+			t.Errorf("xlsxColumnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestReadXLSXDates(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]any{
+		{"開始日", "年度", "番号"},
+		{time.Date(2024, 6, 18, 0, 0, 0, 0, time.Local), 2024, "20240618"},
+	}
+	if err := WriteXLSX(&buf, "工事", rows); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2024-06-18", "2024", "20240618"}
+	if len(got) != 2 || strings.Join(got[1], ",") != strings.Join(want, ",") {
+		t.Fatalf("rows = %q, want second row %q", got, want)
+	}
+}
+
+func TestReadXLSXMalformedRef(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "工事", [][]any{{"会社", "現場"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2つ目のセルの参照から列の文字を取り除く
+	book := rewriteXLSXSheet(t, buf.Bytes(), `r="B1"`, `r="1"`)
+	got, err := ReadXLSX(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got[0]) == 0 || got[0][0] != "会社" {
+		t.Fatalf("rows = %q, want the malformed cell skipped", got)
+	}
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	for _, tt := range []struct {
+		code string
+		want bool
+	}{
+		{"yyyy/m/d", true},
+		{"yyyy\"年\"m\"月\"d\"日\"", true},
+		{"[$-ja-JP]ggge\"年\"m\"月\"d\"日\"", true},
+		{"ee/mm/dd", true},
+		{"h:mm:ss", false},
+		{"0.00E+00", false},
+		{"##0.0E-0", false},
+		{"0.00e+00", false},
+		{"#,##0", false},
+		{"\"Date\" 0", false},
+		{"[Red]0.00", false},
+		{"0\\d", false},
+	} {
+		if got := isDateFormatCode(tt.code); got != tt.want {
+			t.Errorf("isDateFormatCode(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}